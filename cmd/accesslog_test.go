@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRequestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := newTestHandler(t, map[string]interface{}{})
+	server := withRequestLogging(logger, handler.routes())
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions?address=0x01", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "http request", record["msg"])
+	require.Equal(t, http.MethodGet, record["method"])
+	require.Equal(t, "/transactions", record["path"])
+	require.Equal(t, float64(http.StatusBadRequest), record["status"])
+	require.Contains(t, record, "duration")
+}
+
+func TestStatusRecorderFlushes(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sr := &statusRecorder{ResponseWriter: rec}
+
+	// streams check for a Flusher
+	flusher, ok := http.ResponseWriter(sr).(http.Flusher)
+	require.True(t, ok)
+	flusher.Flush()
+	require.True(t, rec.Flushed)
+	require.Equal(t, http.StatusOK, sr.status)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"ethparser/internal/models"
+	"ethparser/internal/parser"
+)
+
+// usage lists the subcommands, serve being run when none is given
+const usage = `usage: cmd [-config=FILE] [serve | block | txs --address=ADDRESS [--from=BLOCK] | subscribe --address=ADDRESS]`
+
+// newParser creates the parser configured by cfg, shared by the server and
+// the subcommands
+func newParser(cfg config, opts ...parser.EthParserOpt) (parser.Parser, error) {
+	return parser.NewEthParser(append(cfg.parserOpts(), opts...)...)
+}
+
+// runCommand runs a one-off subcommand against the node configured by cfg,
+// writing its result to stdout
+func runCommand(ctx context.Context, cfg config, command string, args []string, stdout io.Writer) error {
+	switch command {
+	case "block":
+		return runBlock(ctx, cfg, args, stdout)
+	case "txs":
+		return runTransactions(ctx, cfg, args, stdout)
+	case "subscribe":
+		return runSubscribe(ctx, cfg, args, stdout)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+}
+
+// runBlock prints the current block number
+func runBlock(ctx context.Context, cfg config, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("block", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	p, err := newParser(cfg)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	blockNumber, err := p.GetCurrentBlockCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdout, blockNumber)
+	return err
+}
+
+// runTransactions prints the transactions of a subscribed address as JSON,
+// or of any address from a given block
+func runTransactions(ctx context.Context, cfg config, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("txs", flag.ContinueOnError)
+	address := fs.String("address", "", "address to get the transactions of")
+	fromBlock := fs.Int("from", -1, "block to scan from, the subscription block otherwise")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := checkAddressFlag(*address); err != nil {
+		return err
+	}
+
+	p, err := newParser(cfg)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	var transactions []*models.Transaction
+	if *fromBlock >= 0 {
+		transactions, err = p.GetTransactionsFromCtx(ctx, *address, *fromBlock)
+	} else {
+		transactions, err = p.GetTransactionsCtx(ctx, *address)
+	}
+	if errors.Is(err, parser.ErrResultTruncated) {
+		// the most recent transactions are printed, as many as
+		// MAX_RESULT_SIZE
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// an empty list rather than null
+	if transactions == nil {
+		transactions = []*models.Transaction{}
+	}
+
+	return json.NewEncoder(stdout).Encode(transactions)
+}
+
+// runSubscribe subscribes an address, saved to the subscriptions file for
+// the server and later subcommands to pick up
+func runSubscribe(ctx context.Context, cfg config, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("subscribe", flag.ContinueOnError)
+	address := fs.String("address", "", "address to subscribe")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := checkAddressFlag(*address); err != nil {
+		return err
+	}
+
+	// the subscription would be lost on exit otherwise
+	if cfg.subscriptionsFile == "" {
+		return errors.New("SUBSCRIPTIONS_FILE is required to save the subscription")
+	}
+
+	p, err := newParser(cfg)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	if err := p.SubscribeCtx(ctx, *address); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "subscribed %s\n", *address)
+	return err
+}
+
+// checkAddressFlag rejects a missing or malformed --address
+func checkAddressFlag(address string) error {
+	if address == "" {
+		return errors.New("--address is required")
+	}
+
+	if !models.IsValidAddress(address) {
+		return errors.New("--address must be a 0x-prefixed 20-byte hex string")
+	}
+
+	return nil
+}
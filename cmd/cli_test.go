@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+	"ethparser/internal/parser"
+)
+
+// newTestNodeURL serves a fake JSON-RPC node answering each method with the
+// given result
+func newTestNodeURL(t *testing.T, results map[string]interface{}) string {
+	t.Helper()
+
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req parser.JsonRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      req.ID,
+			"jsonrpc": "2.0",
+			"result":  results[req.Method],
+		})
+	}))
+	t.Cleanup(node.Close)
+
+	return node.URL
+}
+
+func TestRunCommandBlock(t *testing.T) {
+	cfg := config{nodeURL: newTestNodeURL(t, map[string]interface{}{"eth_blockNumber": "0x10"})}
+
+	var out bytes.Buffer
+	require.NoError(t, runCommand(context.Background(), cfg, "block", nil, &out))
+	require.Equal(t, "16\n", out.String())
+}
+
+func TestRunCommandSubscribeAndTransactions(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	cfg := config{
+		nodeURL: newTestNodeURL(t, map[string]interface{}{
+			"eth_blockNumber": "0x10",
+			"eth_getBlockByNumber": models.BlockWithDetails{
+				Hash:   "0xb16",
+				Number: "0x10",
+				Transactions: []models.Transaction{
+					{Hash: "0x01", From: address, To: "0x02", BlockHash: "0xb16", BlockNumber: "0x10"},
+					{Hash: "0x02", From: "0x02", To: "0x03", BlockHash: "0xb16", BlockNumber: "0x10"},
+				},
+			},
+		}),
+		subscriptionsFile: filepath.Join(t.TempDir(), "subscriptions.json"),
+	}
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	err := runCommand(ctx, cfg, "txs", []string{"--address=" + address}, &out)
+	require.ErrorIs(t, err, parser.ErrNotSubscribed)
+
+	require.NoError(t, runCommand(ctx, cfg, "subscribe", []string{"--address=" + address}, &out))
+	require.Equal(t, "subscribed "+address+"\n", out.String())
+
+	// the subscription was saved for the next command
+	out.Reset()
+	require.NoError(t, runCommand(ctx, cfg, "txs", []string{"--address", address}, &out))
+
+	var txs []models.Transaction
+	require.NoError(t, json.Unmarshal(out.Bytes(), &txs))
+	require.Len(t, txs, 1)
+	require.Equal(t, "0x01", txs[0].Hash)
+
+	out.Reset()
+	require.NoError(t, runCommand(ctx, cfg, "txs", []string{"--address=" + address, "--from=17"}, &out))
+	require.Equal(t, "[]\n", out.String())
+}
+
+func TestRunCommandErrors(t *testing.T) {
+	ctx := context.Background()
+	var out bytes.Buffer
+
+	require.ErrorContains(t, runCommand(ctx, config{}, "unknown", nil, &out), `unknown command "unknown"`)
+	require.ErrorContains(t, runCommand(ctx, config{}, "txs", nil, &out), "--address is required")
+	require.ErrorContains(t, runCommand(ctx, config{}, "txs", []string{"--address=0x01"}, &out), "--address must be")
+	require.ErrorIs(t, runCommand(ctx, config{}, "block", []string{"-h"}, &out), flag.ErrHelp)
+
+	// without a file the subscription would be lost
+	err := runCommand(ctx, config{}, "subscribe", []string{"--address=0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"}, &out)
+	require.ErrorContains(t, err, "SUBSCRIPTIONS_FILE")
+	require.Empty(t, out.String())
+}
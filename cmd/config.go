@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ethparser/internal/parser"
+)
+
+const defaultListenAddr = ":9090"
+
+// config is the server configuration read from the environment
+type config struct {
+	// listenAddr is the address the HTTP server listens on
+	listenAddr string
+	// nodeURL is the JSON-RPC endpoint of the node, or a comma-separated
+	// list of endpoints to fail over across, empty for the parser default
+	nodeURL string
+	// nodeWSURL is the WebSocket endpoint new heads are followed on, empty
+	// to only poll the node
+	nodeWSURL string
+	// nodeIPCPath is the Unix socket of a local node requests are sent on
+	// instead of HTTP, empty to use HTTP
+	nodeIPCPath string
+	// subscriptionsFile is the JSON file the subscriptions are saved to,
+	// empty to forget them on exit
+	subscriptionsFile string
+	// grpcListenAddr is the address the gRPC server listens on, empty to
+	// only serve HTTP
+	grpcListenAddr string
+	// allowedOrigins are the origins browser pages may call the server
+	// from, "*" allowing any
+	allowedOrigins []string
+	// maxConcurrentRequests is the number of /transactions and /subscribe
+	// requests served at once
+	maxConcurrentRequests int
+	// maxResultSize is the most transactions /transactions lists, the most
+	// recent ones, 0 for no limit
+	maxResultSize int
+	// expectedChainID is the chain id the node must be on, 0 for any
+	expectedChainID int64
+	// ensRegistry is the address of the ENS registry names are resolved
+	// through, empty to only accept hex addresses
+	ensRegistry string
+	// fileOpts are the parser options read from the -config file, applied
+	// before those set by the environment
+	fileOpts []parser.EthParserOpt
+}
+
+// loadConfig reads LISTEN_ADDR, GRPC_LISTEN_ADDR, ETH_NODE_URL,
+// ETH_NODE_WS_URL, ETH_NODE_IPC_PATH, SUBSCRIPTIONS_FILE, ALLOWED_ORIGINS,
+// MAX_CONCURRENT_REQUESTS, MAX_RESULT_SIZE, EXPECTED_CHAIN_ID and ENS_REGISTRY
+// with getenv, falling back to the defaults when they are empty and failing
+// when a number is invalid
+func loadConfig(getenv func(string) string) (config, error) {
+	cfg := config{
+		listenAddr:        getenv("LISTEN_ADDR"),
+		grpcListenAddr:    getenv("GRPC_LISTEN_ADDR"),
+		nodeURL:           getenv("ETH_NODE_URL"),
+		nodeWSURL:         getenv("ETH_NODE_WS_URL"),
+		nodeIPCPath:       getenv("ETH_NODE_IPC_PATH"),
+		subscriptionsFile: getenv("SUBSCRIPTIONS_FILE"),
+		allowedOrigins:    parseOrigins(getenv("ALLOWED_ORIGINS")),
+		ensRegistry:       getenv("ENS_REGISTRY"),
+	}
+
+	var err error
+	if cfg.maxConcurrentRequests, err = parseMaxConcurrent(getenv("MAX_CONCURRENT_REQUESTS")); err != nil {
+		return config{}, fmt.Errorf("MAX_CONCURRENT_REQUESTS: %w", err)
+	}
+	if cfg.maxResultSize, err = parseMaxResultSize(getenv("MAX_RESULT_SIZE")); err != nil {
+		return config{}, fmt.Errorf("MAX_RESULT_SIZE: %w", err)
+	}
+	if cfg.expectedChainID, err = parseChainID(getenv("EXPECTED_CHAIN_ID")); err != nil {
+		return config{}, fmt.Errorf("EXPECTED_CHAIN_ID: %w", err)
+	}
+
+	if cfg.listenAddr == "" {
+		cfg.listenAddr = defaultListenAddr
+	}
+
+	return cfg, nil
+}
+
+// parserOpts are the parser options set by the configuration
+func (cfg config) parserOpts() []parser.EthParserOpt {
+	opts := append([]parser.EthParserOpt(nil), cfg.fileOpts...)
+	if cfg.nodeURL != "" {
+		opts = append(opts, parser.WithNodeUrls(strings.Split(cfg.nodeURL, ",")...))
+	}
+	if cfg.nodeWSURL != "" {
+		opts = append(opts, parser.WithWebSocketURL(cfg.nodeWSURL))
+	}
+	if cfg.nodeIPCPath != "" {
+		opts = append(opts, parser.WithIPCPath(cfg.nodeIPCPath))
+	}
+	if cfg.maxResultSize > 0 {
+		opts = append(opts, parser.WithMaxResultSize(cfg.maxResultSize))
+	}
+	if cfg.expectedChainID > 0 {
+		opts = append(opts, parser.WithExpectedChainID(cfg.expectedChainID))
+	}
+	if cfg.ensRegistry != "" {
+		opts = append(opts, parser.WithENSResolution(cfg.ensRegistry))
+	}
+	if cfg.subscriptionsFile != "" {
+		opts = append(opts, parser.WithSubscriptionStore(parser.NewFileSubscriptionStore(cfg.subscriptionsFile)))
+	}
+
+	return opts
+}
+
+// parseMaxResultSize parses the most transactions listed at once, no limit
+// when s is empty
+func parseMaxResultSize(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("must be positive, got %d", n)
+	}
+
+	return n, nil
+}
+
+// parseChainID parses the chain id the node must be on, any chain when s is
+// empty
+func parseChainID(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, fmt.Errorf("must be positive, got %d", id)
+	}
+
+	return id, nil
+}
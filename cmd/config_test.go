@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/parser"
+)
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := loadConfig(func(string) string { return "" })
+	require.NoError(t, err)
+	require.Equal(t, defaultListenAddr, cfg.listenAddr)
+	require.Empty(t, cfg.nodeURL)
+	require.Empty(t, cfg.nodeWSURL)
+	require.Empty(t, cfg.grpcListenAddr)
+	require.Equal(t, []string{"*"}, cfg.allowedOrigins)
+	require.Equal(t, defaultMaxConcurrentRequests, cfg.maxConcurrentRequests)
+
+	// an empty node URL keeps the parser default rather than failing
+	require.Empty(t, cfg.parserOpts())
+	_, err = parser.NewEthParser(cfg.parserOpts()...)
+	require.NoError(t, err)
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req parser.JsonRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "jsonrpc": "2.0", "result": "0x10"})
+	}))
+	t.Cleanup(node.Close)
+
+	t.Setenv("LISTEN_ADDR", "127.0.0.1:8080")
+	t.Setenv("GRPC_LISTEN_ADDR", "127.0.0.1:8081")
+	t.Setenv("ETH_NODE_URL", node.URL)
+
+	t.Setenv("ALLOWED_ORIGINS", "https://app.example.com")
+	t.Setenv("MAX_CONCURRENT_REQUESTS", "4")
+
+	cfg := mustLoadConfig(t)
+	require.Equal(t, 4, cfg.maxConcurrentRequests)
+	require.Equal(t, "127.0.0.1:8080", cfg.listenAddr)
+	require.Equal(t, "127.0.0.1:8081", cfg.grpcListenAddr)
+	require.Equal(t, []string{"https://app.example.com"}, cfg.allowedOrigins)
+
+	p, err := parser.NewEthParser(cfg.parserOpts()...)
+	require.NoError(t, err)
+
+	blockNumber, err := p.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, 16, blockNumber)
+}
+
+func TestLoadConfigNodeURLs(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req parser.JsonRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "jsonrpc": "2.0", "result": "0x10"})
+	}))
+	t.Cleanup(up.Close)
+
+	t.Setenv("ETH_NODE_URL", down.URL+","+up.URL)
+
+	p, err := parser.NewEthParser(mustLoadConfig(t).parserOpts()...)
+	require.NoError(t, err)
+
+	blockNumber, err := p.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, 16, blockNumber)
+}
+
+func TestLoadConfigWebSocketURL(t *testing.T) {
+	t.Setenv("ETH_NODE_WS_URL", "wss://node.example")
+
+	cfg := mustLoadConfig(t)
+	require.Equal(t, "wss://node.example", cfg.nodeWSURL)
+	require.Len(t, cfg.parserOpts(), 1)
+
+	_, err := parser.NewEthParser(cfg.parserOpts()...)
+	require.NoError(t, err)
+}
+
+func TestLoadConfigIPCPath(t *testing.T) {
+	t.Setenv("ETH_NODE_IPC_PATH", "/tmp/geth.ipc")
+
+	cfg := mustLoadConfig(t)
+	require.Equal(t, "/tmp/geth.ipc", cfg.nodeIPCPath)
+	require.Len(t, cfg.parserOpts(), 1)
+
+	_, err := parser.NewEthParser(cfg.parserOpts()...)
+	require.NoError(t, err)
+
+	t.Setenv("ETH_NODE_WS_URL", "wss://node.example")
+	_, err = parser.NewEthParser(mustLoadConfig(t).parserOpts()...)
+	require.Error(t, err)
+}
+
+func TestParserOptsConfigFile(t *testing.T) {
+	t.Setenv("ETH_NODE_URL", "http://node.example")
+
+	fileOpts, err := parser.LoadConfig("../internal/parser/testdata/config.json")
+	require.NoError(t, err)
+
+	cfg := mustLoadConfig(t)
+	cfg.fileOpts = fileOpts
+	opts := cfg.parserOpts()
+	require.Len(t, opts, len(fileOpts)+1)
+
+	_, err = parser.NewEthParser(opts...)
+	require.NoError(t, err)
+}
+
+func TestParseMaxResultSize(t *testing.T) {
+	n, err := parseMaxResultSize("")
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	n, err = parseMaxResultSize(" 500 ")
+	require.NoError(t, err)
+	require.Equal(t, 500, n)
+
+	// a bad size fails rather than silently listing everything
+	for _, s := range []string{"0", "-1", "many"} {
+		_, err = parseMaxResultSize(s)
+		require.Error(t, err, s)
+	}
+
+	t.Setenv("MAX_RESULT_SIZE", "500")
+	cfg := mustLoadConfig(t)
+	require.Equal(t, 500, cfg.maxResultSize)
+	require.Len(t, cfg.parserOpts(), 1)
+
+	t.Setenv("MAX_RESULT_SIZE", "-1")
+	_, err = loadConfig(os.Getenv)
+	require.ErrorContains(t, err, "MAX_RESULT_SIZE")
+}
+
+func TestLoadConfigExpectedChainID(t *testing.T) {
+	id, err := parseChainID("")
+	require.NoError(t, err)
+	require.Zero(t, id)
+
+	// a bad chain id fails rather than silently accepting any chain
+	for _, s := range []string{"mainnet", "0", "-1"} {
+		_, err = parseChainID(s)
+		require.Error(t, err, s)
+	}
+
+	url := newTestNodeURL(t, map[string]interface{}{"eth_chainId": "0x1"})
+	t.Setenv("ETH_NODE_URL", url)
+	t.Setenv("EXPECTED_CHAIN_ID", "1")
+	cfg := mustLoadConfig(t)
+	require.Equal(t, int64(1), cfg.expectedChainID)
+	_, err = parser.NewEthParser(cfg.parserOpts()...)
+	require.NoError(t, err)
+
+	t.Setenv("EXPECTED_CHAIN_ID", "11155111")
+	_, err = parser.NewEthParser(mustLoadConfig(t).parserOpts()...)
+	require.ErrorIs(t, err, parser.ErrChainMismatch)
+
+	t.Setenv("EXPECTED_CHAIN_ID", "mainnet")
+	_, err = loadConfig(os.Getenv)
+	require.ErrorContains(t, err, "EXPECTED_CHAIN_ID")
+}
+
+// mustLoadConfig loads the config from the environment, failing t on error
+func mustLoadConfig(t *testing.T) config {
+	t.Helper()
+
+	cfg, err := loadConfig(os.Getenv)
+	require.NoError(t, err)
+
+	return cfg
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	// allowAllOrigins lets any origin call the API
+	allowAllOrigins = "*"
+	// corsMaxAge is how long browsers may reuse a preflight answer, in
+	// seconds
+	corsMaxAge = "600"
+)
+
+// withCORS lets browser pages from allowedOrigins, or from anywhere when
+// they include "*", call next, answering preflight requests itself
+func withCORS(allowedOrigins []string, next http.Handler) http.Handler {
+	allowAll := slices.Contains(allowedOrigins, allowAllOrigins)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if allowAll {
+			w.Header().Set("Access-Control-Allow-Origin", allowAllOrigins)
+		} else {
+			// the answer depends on the origin, caches must not share it
+			w.Header().Add("Vary", "Origin")
+			if !slices.Contains(allowedOrigins, origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// parseOrigins splits a comma-separated list of origins, allowing every
+// origin when it is empty
+func parseOrigins(s string) []string {
+	var origins []string
+	for _, origin := range strings.Split(s, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	if len(origins) == 0 {
+		return []string{allowAllOrigins}
+	}
+
+	return origins
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCORSPreflight(t *testing.T) {
+	var served int
+	handler := withCORS([]string{"*"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/transactions", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	req.Header.Set("Access-Control-Request-Headers", "Accept")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "GET, POST, OPTIONS", rec.Header().Get("Access-Control-Allow-Methods"))
+	require.Equal(t, "Accept", rec.Header().Get("Access-Control-Allow-Headers"))
+	require.Equal(t, corsMaxAge, rec.Header().Get("Access-Control-Max-Age"))
+	require.Zero(t, served)
+
+	req = httptest.NewRequest(http.MethodGet, "/transactions", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, 1, served)
+}
+
+func TestWithCORSAllowedOrigins(t *testing.T) {
+	handler := withCORS([]string{"https://app.example.com"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		origin string
+		want   string
+	}{
+		{origin: "https://app.example.com", want: "https://app.example.com"},
+		{origin: "https://evil.example.com", want: ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodOptions, "/subscribe", nil)
+		req.Header.Set("Origin", tt.origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, tt.want, rec.Header().Get("Access-Control-Allow-Origin"), tt.origin)
+		require.Equal(t, "Origin", rec.Header().Get("Vary"), tt.origin)
+	}
+}
+
+func TestParseOrigins(t *testing.T) {
+	require.Equal(t, []string{"*"}, parseOrigins(""))
+	require.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, parseOrigins("https://a.example.com, https://b.example.com,"))
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"ethparser/internal/models"
+)
+
+// decodedTransaction is a transaction along with its value decoded and its
+// direction relative to the queried address, listed by /transactions with
+// decode=true
+type decodedTransaction struct {
+	*models.Transaction
+	ValueWei   string `json:"valueWei"`
+	ValueEther string `json:"valueEther"`
+	// Direction is in, out or self
+	Direction string `json:"direction"`
+}
+
+// decodeTransaction decodes the value of tx and its direction relative to
+// address
+func decodeTransaction(address string, tx *models.Transaction) (decodedTransaction, error) {
+	wei, err := tx.ValueWei()
+	if err != nil {
+		return decodedTransaction{}, err
+	}
+
+	ether, err := tx.ValueEther()
+	if err != nil {
+		return decodedTransaction{}, err
+	}
+
+	return decodedTransaction{
+		Transaction: tx,
+		ValueWei:    wei.String(),
+		ValueEther:  ether.Text('f', -1),
+		Direction:   tx.Direction(address),
+	}, nil
+}
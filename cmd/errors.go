@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"ethparser/internal/parser"
+)
+
+// The codes of the errors answered by the handlers, stable for clients to
+// switch on unlike the messages
+const (
+	codeInvalidRequest       = "invalid_request"
+	codeNotSubscribed        = "not_subscribed"
+	codeBlockNotFound        = "block_not_found"
+	codeBlockNotYetAvailable = "block_not_yet_available"
+	codeTransactionNotFound  = "transaction_not_found"
+	codeReceiptNotFound      = "receipt_not_found"
+	codeENSNameNotResolved   = "ens_name_not_resolved"
+	codeRangeTooLarge        = "range_too_large"
+	codeFullChainScan        = "full_chain_scan"
+	codeHistoryUnavailable   = "history_unavailable"
+	codeChainMismatch        = "chain_mismatch"
+	codeNodeUnavailable      = "node_unavailable"
+	codeTooManyRequests      = "too_many_requests"
+	codeTimeout              = "timeout"
+	codeInternal             = "internal"
+)
+
+// errorResponse is the JSON body of the errors answered by the handlers
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeError answers status with an errorResponse of code and message
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	header := w.Header()
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: errorBody{Code: code, Message: message}})
+}
+
+// writeParserError answers an error returned by the parser with the status
+// and code of its kind, 500 for the ones not expected
+func writeParserError(w http.ResponseWriter, err error) {
+	status, code := http.StatusInternalServerError, codeInternal
+	switch {
+	case errors.Is(err, parser.ErrNotSubscribed):
+		status, code = http.StatusNotFound, codeNotSubscribed
+	case errors.Is(err, parser.ErrBlockNotFound):
+		status, code = http.StatusNotFound, codeBlockNotFound
+	case errors.Is(err, parser.ErrTransactionNotFound):
+		status, code = http.StatusNotFound, codeTransactionNotFound
+	case errors.Is(err, parser.ErrReceiptNotFound):
+		status, code = http.StatusNotFound, codeReceiptNotFound
+	case errors.Is(err, parser.ErrENSNameNotResolved):
+		status, code = http.StatusNotFound, codeENSNameNotResolved
+	case errors.Is(err, parser.ErrRangeTooLarge):
+		status, code = http.StatusUnprocessableEntity, codeRangeTooLarge
+	case errors.Is(err, parser.ErrFullChainScan):
+		status, code = http.StatusUnprocessableEntity, codeFullChainScan
+	case errors.Is(err, parser.ErrHistoryUnavailable):
+		status, code = http.StatusBadGateway, codeHistoryUnavailable
+	case errors.Is(err, parser.ErrChainMismatch):
+		status, code = http.StatusBadGateway, codeChainMismatch
+	case errors.Is(err, parser.ErrBlockNotYetAvailable):
+		// the node has not caught up with the head it answered yet
+		w.Header().Set("Retry-After", limitRetryAfter)
+		status, code = http.StatusServiceUnavailable, codeBlockNotYetAvailable
+	case errors.Is(err, parser.ErrCircuitOpen):
+		status, code = http.StatusServiceUnavailable, codeNodeUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		status, code = http.StatusGatewayTimeout, codeTimeout
+	}
+
+	writeError(w, status, code, err.Error())
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/parser"
+)
+
+func TestWriteParserError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{parser.ErrNotSubscribed, http.StatusNotFound, codeNotSubscribed},
+		{parser.ErrBlockNotFound, http.StatusNotFound, codeBlockNotFound},
+		{parser.ErrTransactionNotFound, http.StatusNotFound, codeTransactionNotFound},
+		{parser.ErrReceiptNotFound, http.StatusNotFound, codeReceiptNotFound},
+		{parser.ErrENSNameNotResolved, http.StatusNotFound, codeENSNameNotResolved},
+		{parser.ErrRangeTooLarge, http.StatusUnprocessableEntity, codeRangeTooLarge},
+		{parser.ErrFullChainScan, http.StatusUnprocessableEntity, codeFullChainScan},
+		{parser.ErrHistoryUnavailable, http.StatusBadGateway, codeHistoryUnavailable},
+		{parser.ErrChainMismatch, http.StatusBadGateway, codeChainMismatch},
+		{parser.ErrBlockNotYetAvailable, http.StatusServiceUnavailable, codeBlockNotYetAvailable},
+		{parser.ErrCircuitOpen, http.StatusServiceUnavailable, codeNodeUnavailable},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout, codeTimeout},
+		{errors.New("unexpected status code: 500"), http.StatusInternalServerError, codeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			// the parser wraps its errors with details
+			err := fmt.Errorf("%w: 0x10", tt.err)
+
+			rec := httptest.NewRecorder()
+			writeParserError(rec, err)
+			require.Equal(t, tt.status, rec.Code)
+			require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var body errorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			require.Equal(t, errorResponse{Error: errorBody{Code: tt.code, Message: err.Error()}}, body)
+		})
+	}
+}
+
+func TestHandlerErrorResponses(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{"eth_blockNumber": "0x10"})
+	routes := handler.routes()
+
+	tests := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{"/transactions", http.StatusBadRequest, `{"error": {"code": "invalid_request", "message": "address is required"}}`},
+		{"/transactions?address=0x01", http.StatusBadRequest, `{"error": {"code": "invalid_request", "message": "address must be a 0x-prefixed 20-byte hex string"}}`},
+		{"/transactions?address=" + address, http.StatusNotFound, `{"error": {"code": "not_subscribed", "message": "address not found in the observer: ` + address + `"}}`},
+		{"/unsubscribe?address=" + address, http.StatusNotFound, `{"error": {"code": "not_subscribed", "message": "address not subscribed"}}`},
+		{"/block?number=0x", http.StatusBadRequest, `{"error": {"code": "invalid_request", "message": "number must be a decimal or 0x-prefixed hex block number"}}`},
+		{"/block?number=0x20", http.StatusNotFound, `{"error": {"code": "block_not_found", "message": "block not found: 32"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			require.Equal(t, tt.status, rec.Code)
+			require.JSONEq(t, tt.body, rec.Body.String())
+		})
+	}
+}
+
+func TestWithConcurrencyLimitErrorResponse(t *testing.T) {
+	sem := make(chan struct{}, 1)
+	sem <- struct{}{}
+	handler := withConcurrencyLimit(sem, http.NotFoundHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.JSONEq(t, `{"error": {"code": "too_many_requests", "message": "too many concurrent requests"}}`, rec.Body.String())
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+
+	"ethparser/internal/models"
+)
+
+// etherscanResponse is the envelope the Etherscan account API wraps results in
+type etherscanResponse struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message"`
+	Result  []etherscanTransaction `json:"result"`
+}
+
+// etherscanTransaction is a transaction as listed by the Etherscan
+// txlist action, with numbers encoded as decimal strings. Fields the parser
+// doesn't track are left empty.
+type etherscanTransaction struct {
+	BlockNumber      string `json:"blockNumber"`
+	TimeStamp        string `json:"timeStamp"`
+	Hash             string `json:"hash"`
+	Nonce            string `json:"nonce"`
+	BlockHash        string `json:"blockHash"`
+	TransactionIndex string `json:"transactionIndex"`
+	From             string `json:"from"`
+	To               string `json:"to"`
+	Value            string `json:"value"`
+	Gas              string `json:"gas"`
+	GasPrice         string `json:"gasPrice"`
+	Input            string `json:"input"`
+}
+
+func newEtherscanResponse(transactions []*models.Transaction) etherscanResponse {
+	if len(transactions) == 0 {
+		return etherscanResponse{
+			Status:  "0",
+			Message: "No transactions found",
+			Result:  []etherscanTransaction{},
+		}
+	}
+
+	result := make([]etherscanTransaction, 0, len(transactions))
+	for _, tx := range transactions {
+		result = append(result, etherscanTransaction{
+			BlockNumber:      hexToDecimal(tx.BlockNumber),
+			TimeStamp:        hexToDecimal(tx.BlockTimestamp),
+			Hash:             tx.Hash,
+			Nonce:            hexToDecimal(tx.Nonce),
+			BlockHash:        tx.BlockHash,
+			TransactionIndex: hexToDecimal(tx.TransactionIndex),
+			From:             tx.From,
+			To:               tx.To,
+			Value:            hexToDecimal(tx.Value),
+			Gas:              hexToDecimal(tx.Gas),
+			GasPrice:         hexToDecimal(tx.GasPrice),
+			Input:            tx.Input,
+		})
+	}
+
+	return etherscanResponse{
+		Status:  "1",
+		Message: "OK",
+		Result:  result,
+	}
+}
+
+// hexToDecimal converts a 0x-prefixed hex quantity to a decimal string,
+// leaving it untouched if it isn't valid hex
+func hexToDecimal(s string) string {
+	digits := strings.TrimPrefix(s, "0x")
+	if digits == "" {
+		return "0"
+	}
+
+	n, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return s
+	}
+
+	return n.String()
+}
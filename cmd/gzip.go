@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gzipMinSize is the smallest response compressed, smaller ones gaining
+// less than the gzip header costs
+const gzipMinSize = 1024
+
+// gzipResponseWriter compresses the response once it reaches gzipMinSize,
+// holding back the status and the first bytes until then
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status int
+	buf    []byte
+	gz     *gzip.Writer
+	// identity is set once the response is sent uncompressed
+	identity bool
+	hijacked bool
+}
+
+func (gw *gzipResponseWriter) WriteHeader(code int) {
+	if gw.gz != nil || gw.identity {
+		gw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if gw.status == 0 {
+		gw.status = code
+	}
+}
+
+func (gw *gzipResponseWriter) Write(b []byte) (int, error) {
+	if gw.identity {
+		return gw.ResponseWriter.Write(b)
+	}
+	if gw.gz != nil {
+		return gw.gz.Write(b)
+	}
+
+	// streams and already encoded bodies are sent as is
+	header := gw.Header()
+	if header.Get("Content-Encoding") != "" || strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") {
+		if err := gw.sendIdentity(); err != nil {
+			return 0, err
+		}
+		return gw.ResponseWriter.Write(b)
+	}
+
+	gw.buf = append(gw.buf, b...)
+	if len(gw.buf) >= gzipMinSize {
+		if err := gw.sendGzip(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// sendGzip writes the status and starts compressing the held back bytes
+func (gw *gzipResponseWriter) sendGzip() error {
+	header := gw.Header()
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	gw.ResponseWriter.WriteHeader(gw.statusCode())
+
+	gw.gz = gzip.NewWriter(gw.ResponseWriter)
+	_, err := gw.gz.Write(gw.buf)
+	gw.buf = nil
+	return err
+}
+
+// sendIdentity writes the status and the held back bytes uncompressed
+func (gw *gzipResponseWriter) sendIdentity() error {
+	gw.identity = true
+	gw.ResponseWriter.WriteHeader(gw.statusCode())
+
+	_, err := gw.ResponseWriter.Write(gw.buf)
+	gw.buf = nil
+	return err
+}
+
+func (gw *gzipResponseWriter) statusCode() int {
+	if gw.status == 0 {
+		return http.StatusOK
+	}
+	return gw.status
+}
+
+// Flush sends a response not compressed yet uncompressed, as streams flush
+// every event
+func (gw *gzipResponseWriter) Flush() {
+	if gw.gz == nil && !gw.identity {
+		gw.sendIdentity()
+	}
+	if gw.gz != nil {
+		gw.gz.Flush()
+	}
+	if flusher, ok := gw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSocket upgrades go through the writer
+func (gw *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := gw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not implement http.Hijacker")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		gw.hijacked = true
+	}
+	return conn, rw, err
+}
+
+func (gw *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return gw.ResponseWriter
+}
+
+// close sends what the handler left, finishing the gzip stream
+func (gw *gzipResponseWriter) close() error {
+	switch {
+	case gw.hijacked:
+		return nil
+	case gw.gz != nil:
+		return gw.gz.Close()
+	case !gw.identity:
+		return gw.sendIdentity()
+	default:
+		return nil
+	}
+}
+
+// withGzip compresses the responses of next for the clients accepting gzip
+func withGzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// the encoding depends on the request, caches must not share it
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether the Accept-Encoding of r lists gzip without a
+// zero quality
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.TrimSpace(name) != "gzip" {
+			continue
+		}
+
+		quality, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+		q, err := strconv.ParseFloat(quality, 64)
+		return err == nil && q > 0
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithGzip(t *testing.T) {
+	large := strings.Repeat(`{"hash":"0x01"}`, 200)
+	handler := withGzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/small" {
+			io.WriteString(w, "{}")
+			return
+		}
+		// written in pieces, as encoders do
+		for i := 0; i < len(large); i += 100 {
+			io.WriteString(w, large[i:min(i+100, len(large))])
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/large", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	require.Less(t, rec.Body.Len(), len(large))
+
+	gz, err := gzip.NewReader(rec.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	require.Equal(t, large, string(body))
+
+	// small responses are not worth compressing
+	req = httptest.NewRequest(http.MethodGet, "/small", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Empty(t, rec.Header().Get("Content-Encoding"))
+	require.Equal(t, "{}", rec.Body.String())
+
+	// clients not accepting gzip get the response as is
+	for _, encoding := range []string{"", "br", "gzip;q=0", "gzip; q=0.0"} {
+		req = httptest.NewRequest(http.MethodGet, "/large", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Empty(t, rec.Header().Get("Content-Encoding"), encoding)
+		require.Equal(t, large, rec.Body.String(), encoding)
+	}
+}
+
+func TestWithGzipKeepsStatus(t *testing.T) {
+	handler := withGzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "address is required", http.StatusBadRequest)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/transactions", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "address is required\n", rec.Body.String())
+}
+
+func TestWithGzipStreams(t *testing.T) {
+	handler := withGzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "data: {}\n\n")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.True(t, rec.Flushed)
+	require.Empty(t, rec.Header().Get("Content-Encoding"))
+	require.Equal(t, "data: {}\n\n", rec.Body.String())
+}
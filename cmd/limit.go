@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultMaxConcurrentRequests is the number of expensive requests
+	// served at once by default
+	defaultMaxConcurrentRequests = 16
+	// limitRetryAfter is how long clients turned away by the limit are told
+	// to wait, in seconds
+	limitRetryAfter = "1"
+)
+
+// withConcurrencyLimit serves next while fewer requests than the capacity of
+// sem are being served by the handlers sharing it, answering 503 otherwise
+// rather than queueing requests that each scan blocks
+func withConcurrencyLimit(sem chan struct{}, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		default:
+			w.Header().Set("Retry-After", limitRetryAfter)
+			writeError(w, http.StatusServiceUnavailable, codeTooManyRequests, "too many concurrent requests")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// parseMaxConcurrent parses the number of expensive requests served at once,
+// the default when s is empty
+func parseMaxConcurrent(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return defaultMaxConcurrentRequests, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("must be positive, got %d", n)
+	}
+
+	return n, nil
+}
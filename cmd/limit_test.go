@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/parser"
+)
+
+func TestWithConcurrencyLimit(t *testing.T) {
+	const limit = 2
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := withConcurrencyLimit(make(chan struct{}, limit), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+
+	var wg sync.WaitGroup
+	codes := make(chan int, limit)
+	for i := 0; i < limit; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions", nil))
+			codes <- rec.Code
+		}()
+		<-started
+	}
+
+	// the requests over the limit are turned away while the others run
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions", nil))
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		require.Equal(t, limitRetryAfter, rec.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		require.Equal(t, http.StatusOK, code)
+	}
+
+	// the slots are freed once the requests are served
+	go func() { <-started }()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRoutesConcurrencyLimit(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	// the node holds every request until released
+	requested := make(chan struct{}, 1)
+	release := make(chan struct{})
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req parser.JsonRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-release
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "jsonrpc": "2.0", "result": "0x10"})
+	}))
+	t.Cleanup(node.Close)
+
+	p, err := parser.NewEthParser(parser.WithNodeUrl(node.URL))
+	require.NoError(t, err)
+	handler := &httpHandler{parser: p, shutdown: make(chan struct{}), maxConcurrent: 1}
+	routes := handler.routes()
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/subscribe?address="+address, nil))
+		done <- rec.Code
+	}()
+	<-requested
+
+	// /transactions shares the limit the /subscribe in flight reached
+	rec := httptest.NewRecorder()
+	routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address, nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	// cheap endpoints are not limited
+	rec = httptest.NewRecorder()
+	routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	close(release)
+	require.Equal(t, http.StatusOK, <-done)
+}
+
+func TestParseMaxConcurrent(t *testing.T) {
+	n, err := parseMaxConcurrent("4")
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+
+	n, err = parseMaxConcurrent("")
+	require.NoError(t, err)
+	require.Equal(t, defaultMaxConcurrentRequests, n)
+
+	for _, s := range []string{"0", "-1", "many"} {
+		_, err = parseMaxConcurrent(s)
+		require.Error(t, err, s)
+	}
+}
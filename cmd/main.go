@@ -1,72 +1,836 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"math/big"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+
+	parsergrpc "ethparser/internal/grpc"
+	"ethparser/internal/models"
 	"ethparser/internal/parser"
 )
 
 type httpHandler struct {
 	parser parser.Parser
+	// shutdown is closed when the server shuts down
+	shutdown chan struct{}
+	// maxConcurrent is the number of expensive requests served at once, 0
+	// for no limit
+	maxConcurrent int
+	// ensNames is set when the parser resolves ENS names, which are then
+	// accepted as addresses
+	ensNames bool
+}
+
+// validAddress reports whether address is a hex address, or an ENS name the
+// parser resolves
+func (hh *httpHandler) validAddress(address string) bool {
+	if hh.ensNames && strings.HasSuffix(strings.ToLower(address), ".eth") {
+		return true
+	}
+
+	return models.IsValidAddress(address)
+}
+
+type gasPriceResponse struct {
+	Value string `json:"value"`
+	Unit  string `json:"unit"`
+}
+
+type subscribeResponse struct {
+	Subscribed bool `json:"subscribed"`
+}
+
+type currentBlockResponse struct {
+	CurrentBlock int `json:"currentBlock"`
+}
+
+type chainIDResponse struct {
+	ChainID int64 `json:"chainId"`
+}
+
+// healthResponse tells whether the node answers, and its current block when
+// it does
+type healthResponse struct {
+	OK    bool   `json:"ok"`
+	Block int    `json:"block,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// balanceResponse is the balance of an address in wei
+type balanceResponse struct {
+	Address string `json:"address"`
+	Balance string `json:"balance"`
+}
+
+// summaryResponse counts the transactions of an address and sums the wei
+// they send to and from it
+type summaryResponse struct {
+	Address  string `json:"address"`
+	Count    int    `json:"count"`
+	TotalIn  string `json:"totalIn"`
+	TotalOut string `json:"totalOut"`
+}
+
+// syncResponse tells how many blocks the transactions cached for an
+// address are behind the current block
+type syncResponse struct {
+	Address        string `json:"address"`
+	ProcessedBlock int    `json:"processedBlock"`
+	HeadBlock      int    `json:"headBlock"`
+	Behind         int    `json:"behind"`
+}
+
+// statusResponse tells whether the last scan for the transactions of an
+// address failed, and why
+type statusResponse struct {
+	Address   string `json:"address"`
+	Healthy   bool   `json:"healthy"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// refreshResponse is the range of blocks scanned again for an address
+type refreshResponse struct {
+	Address string `json:"address"`
+	From    int    `json:"from"`
+	To      int    `json:"to"`
+}
+
+// exportRecord is a line of the /export NDJSON stream
+type exportRecord struct {
+	Address     string              `json:"address"`
+	Transaction *models.Transaction `json:"transaction"`
 }
 
+type statsResponse struct {
+	Addresses map[string]addressStats `json:"addresses"`
+	RPC       parser.Stats            `json:"rpc"`
+}
+
+type addressStats struct {
+	Synced bool `json:"synced"`
+}
+
+const (
+	// shutdownTimeout bounds the wait for in-flight requests on shutdown
+	shutdownTimeout = 10 * time.Second
+	// healthTimeout bounds a health check, well under the interval load
+	// balancers check at
+	healthTimeout = 5 * time.Second
+)
+
 func main() {
-	parser, err := parser.NewEthParser()
+	configFile := flag.String("config", "", "JSON file the parser options are read from")
+	flag.Usage = func() { fmt.Fprintln(flag.CommandLine.Output(), usage) }
+	flag.Parse()
+
+	cfg, err := loadConfig(os.Getenv)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if *configFile != "" {
+		opts, err := parser.LoadConfig(*configFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.fileOpts = opts
+	}
 
-	handler := &httpHandler{parser: parser}
+	command, args := "serve", flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
 
-	http.HandleFunc("/transactions", handler.handleGetTransactions)
-	http.HandleFunc("/subscribe", handler.handleSubscribe)
-	http.HandleFunc("/currentBlock", handler.handleGetCurrentBlock)
+	if command == "serve" {
+		serve(cfg)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	fmt.Println("Starting server on 9090")
-	if err := http.ListenAndServe(":9090", nil); err != nil {
+	if err := runCommand(ctx, cfg, command, args, os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(2)
+	}
+}
+
+// serve runs the HTTP server, and the gRPC one if configured, until SIGINT
+// or SIGTERM
+func serve(cfg config) {
+	metrics := newPromMetrics(prometheus.DefaultRegisterer)
+
+	parser, err := newParser(cfg, parser.WithMetrics(metrics))
+	if err != nil {
 		log.Fatal(err)
 	}
+	registerCacheMetrics(prometheus.DefaultRegisterer, parser)
+
+	pollCtx, stopPolling := context.WithCancel(context.Background())
+	defer stopPolling()
+	parser.Start(pollCtx)
+
+	handler := &httpHandler{parser: parser, shutdown: make(chan struct{}), maxConcurrent: cfg.maxConcurrentRequests, ensNames: cfg.ensRegistry != ""}
+
+	srv := &http.Server{
+		Addr:    cfg.listenAddr,
+		Handler: withRequestLogging(slog.Default(), withCORS(cfg.allowedOrigins, withGzip(handler.routes()))),
+	}
+	// streams only end when their client disconnects, so they are closed
+	// for Shutdown not to wait for them
+	srv.RegisterOnShutdown(func() { close(handler.shutdown) })
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 2)
+	go func() {
+		fmt.Println("Starting server on " + cfg.listenAddr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	var grpcSrv *grpc.Server
+	if cfg.grpcListenAddr != "" {
+		listener, err := net.Listen("tcp", cfg.grpcListenAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		grpcSrv = parsergrpc.NewServer(parser)
+		go func() {
+			fmt.Println("Starting gRPC server on " + cfg.grpcListenAddr)
+			serveErr <- grpcSrv.Serve(listener)
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+
+	log.Println("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("failed to shut down gracefully:", err)
+	}
+	if grpcSrv != nil {
+		stopGRPC(shutdownCtx, grpcSrv)
+	}
+	stopPolling()
+	if err := parser.Close(); err != nil {
+		log.Println("failed to close the parser:", err)
+	}
+}
+
+// stopGRPC waits for the in-flight gRPC calls until ctx is done, then
+// cancels the ones left
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Println("failed to stop the gRPC server gracefully:", ctx.Err())
+		srv.Stop()
+	}
+}
+
+// routes maps the endpoints to their handlers
+func (hh *httpHandler) routes() http.Handler {
+	// the handlers that may scan blocks share a limit, the cheap ones
+	// don't need one
+	expensive := func(h http.HandlerFunc) http.Handler { return h }
+	if hh.maxConcurrent > 0 {
+		sem := make(chan struct{}, hh.maxConcurrent)
+		expensive = func(h http.HandlerFunc) http.Handler { return withConcurrencyLimit(sem, h) }
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/transactions", expensive(hh.handleGetTransactions))
+	mux.Handle("/subscribe", expensive(hh.handleSubscribe))
+	mux.HandleFunc("/unsubscribe", hh.handleUnsubscribe)
+	mux.HandleFunc("/currentBlock", hh.handleGetCurrentBlock)
+	mux.HandleFunc("/chainid", hh.handleGetChainID)
+	mux.HandleFunc("/balance", hh.handleGetBalance)
+	mux.HandleFunc("/summary", hh.handleGetSummary)
+	mux.HandleFunc("/refresh", hh.handleRefresh)
+	mux.HandleFunc("/sync", hh.handleGetSync)
+	mux.HandleFunc("/status", hh.handleGetStatus)
+	mux.HandleFunc("/block", hh.handleGetBlock)
+	mux.HandleFunc("/transaction", hh.handleGetTransaction)
+	mux.HandleFunc("/gasPrice", hh.handleGetGasPrice)
+	mux.HandleFunc("/stats", hh.handleGetStats)
+	mux.HandleFunc("/cache/stats", hh.handleGetCacheStats)
+	mux.HandleFunc("/subscriptions", hh.handleGetSubscriptions)
+	mux.HandleFunc("/export", hh.handleExport)
+	mux.HandleFunc("/debug/activity", hh.handleGetActivity)
+	mux.HandleFunc("/healthz", hh.handleHealthz)
+	mux.HandleFunc("/stream", hh.handleStream)
+	mux.HandleFunc("/ws", hh.handleWebSocket)
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return mux
 }
 
 func (hh *httpHandler) handleGetTransactions(w http.ResponseWriter, r *http.Request) {
 	address := r.URL.Query().Get("address")
 	if address == "" {
-		http.Error(w, "address is required", http.StatusBadRequest)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address is required")
 		return
 	}
 
-	transactions := hh.parser.GetTransactions(address)
-	w.WriteHeader(http.StatusOK)
+	if !hh.validAddress(address) {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address must be a 0x-prefixed 20-byte hex string")
+		return
+	}
+
+	// an ENS name is resolved once, the transactions being matched against
+	// the address it stands for
+	address, err := hh.parser.ResolveAddress(r.Context(), address)
+	if err != nil {
+		writeParserError(w, err)
+		return
+	}
+
+	direction := parser.All
+	if d := r.URL.Query().Get("direction"); d != "" {
+		if direction, err = parser.ParseDirection(d); err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidRequest, "direction must be in, out or all")
+			return
+		}
+	}
+
+	var fromBlock, toBlock, limit, offset int
+	params := []struct {
+		name  string
+		value *int
+	}{{"fromBlock", &fromBlock}, {"toBlock", &toBlock}, {"limit", &limit}, {"offset", &offset}}
+	for _, param := range params {
+		if *param.value, err = queryInt(r, param.name); err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidRequest, param.name+" must be a non-negative integer")
+			return
+		}
+	}
+
+	if toBlock > 0 && fromBlock > toBlock {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "fromBlock cannot be above toBlock")
+		return
+	}
+
+	page, err := hh.parser.QueryTransactions(r.Context(), address, parser.TransactionsQuery{
+		Direction: direction,
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	// the most recent transactions of the page are listed, the client
+	// being told to page through the others
+	if errors.Is(err, parser.ErrResultTruncated) {
+		w.Header().Set("X-Result-Truncated", "true")
+		err = nil
+	}
+	if err != nil {
+		writeParserError(w, err)
+		return
+	}
+	transactions := page.Transactions
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	// the blocks the transactions were fetched from, whatever the format
+	w.Header().Set("X-From-Block", strconv.Itoa(page.ScannedFrom))
+	w.Header().Set("X-To-Block", strconv.Itoa(page.ScannedTo))
 
+	if r.URL.Query().Get("format") == "etherscan" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newEtherscanResponse(transactions))
+		return
+	}
+
+	decode := r.URL.Query().Get("decode") == "true"
+
+	resp := response{
+		records: make([]interface{}, 0, len(transactions)),
+		header:  []string{"hash", "from", "to", "value", "blockHash", "blockNumber", "input"},
+	}
+	if decode {
+		resp.header = append(resp.header, "valueWei", "valueEther", "direction")
+	}
 	for _, tx := range transactions {
-		w.Write([]byte(fmt.Sprintf("%v", tx.Hash)))
+		var record interface{} = tx
+		row := []string{tx.Hash, tx.From, tx.To, tx.Value, tx.BlockHash, tx.BlockNumber, tx.Input}
+		if decode {
+			decoded, err := decodeTransaction(address, tx)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, codeInternal, fmt.Sprintf("transaction %s: %v", tx.Hash, err))
+				return
+			}
+			record = decoded
+			row = append(row, decoded.ValueWei, decoded.ValueEther, decoded.Direction)
+		}
+
+		resp.records = append(resp.records, record)
+		resp.rows = append(resp.rows, row)
+		resp.text += tx.Hash + "\n"
+	}
+
+	writeResponse(w, r, resp)
+}
+
+// queryInt parses an optional non-negative integer query parameter, which
+// is zero when missing
+func queryInt(r *http.Request, param string) (int, error) {
+	v := r.URL.Query().Get(param)
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
 	}
+	if n < 0 {
+		return 0, fmt.Errorf("negative %s: %d", param, n)
+	}
+
+	return n, nil
 }
 
 func (hh *httpHandler) handleSubscribe(w http.ResponseWriter, r *http.Request) {
 	address := r.URL.Query().Get("address")
 	if address == "" {
-		http.Error(w, "address is required", http.StatusBadRequest)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address is required")
 		return
 	}
 
-	res := hh.parser.Subscribe(address)
-	if !res {
-		http.Error(w, "failed to subscribe", http.StatusInternalServerError)
-		w.WriteHeader(http.StatusInternalServerError)
+	if !hh.validAddress(address) {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address must be a 0x-prefixed 20-byte hex string")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("subscribed"))
+	if err := hh.parser.SubscribeCtx(r.Context(), address); err != nil {
+		writeParserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscribeResponse{Subscribed: true})
+}
+
+func (hh *httpHandler) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address is required")
+		return
+	}
+
+	if !hh.validAddress(address) {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address must be a 0x-prefixed 20-byte hex string")
+		return
+	}
+
+	if !hh.parser.Unsubscribe(address) {
+		writeError(w, http.StatusNotFound, codeNotSubscribed, "address not subscribed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscribeResponse{Subscribed: false})
 }
 
 func (hh *httpHandler) handleGetCurrentBlock(w http.ResponseWriter, r *http.Request) {
-	int := hh.parser.GetCurrentBlock()
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(fmt.Sprintf("%v", int)))
+	blockNumber, err := hh.parser.GetCurrentBlockCtx(r.Context())
+	if err != nil {
+		writeParserError(w, err)
+		return
+	}
+
+	writeResponse(w, r, response{
+		records: []interface{}{currentBlockResponse{CurrentBlock: blockNumber}},
+		single:  true,
+		header:  []string{"currentBlock"},
+		rows:    [][]string{{strconv.Itoa(blockNumber)}},
+		text:    fmt.Sprintf("%v", blockNumber),
+	})
+}
+
+// handleGetChainID answers the chain id of the node, as 1 for mainnet
+func (hh *httpHandler) handleGetChainID(w http.ResponseWriter, r *http.Request) {
+	chainID, err := hh.parser.ChainIDCtx(r.Context())
+	if err != nil {
+		writeParserError(w, err)
+		return
+	}
+
+	writeResponse(w, r, response{
+		records: []interface{}{chainIDResponse{ChainID: chainID}},
+		single:  true,
+		header:  []string{"chainId"},
+		rows:    [][]string{{strconv.FormatInt(chainID, 10)}},
+		text:    strconv.FormatInt(chainID, 10),
+	})
+}
+
+// handleHealthz answers 200 while the node answers and 503 otherwise, for
+// load balancers to take the server out of rotation
+func (hh *httpHandler) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+
+	health := healthResponse{OK: true}
+	err := hh.parser.Ping(ctx)
+	if err == nil {
+		// the block number Ping fetched is reused
+		health.Block, err = hh.parser.GetCurrentBlockCtx(ctx)
+	}
+	if err != nil {
+		health = healthResponse{Error: err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !health.OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(health)
+}
+
+func (hh *httpHandler) handleGetBalance(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address is required")
+		return
+	}
+
+	if !hh.validAddress(address) {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address must be a 0x-prefixed 20-byte hex string")
+		return
+	}
+
+	balance, err := hh.parser.GetBalanceCtx(r.Context(), address)
+	if err != nil {
+		writeParserError(w, err)
+		return
+	}
+
+	writeResponse(w, r, response{
+		records: []interface{}{balanceResponse{Address: address, Balance: balance.String()}},
+		single:  true,
+		header:  []string{"address", "balance"},
+		rows:    [][]string{{address, balance.String()}},
+		text:    balance.String(),
+	})
+}
+
+func (hh *httpHandler) handleGetSummary(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address is required")
+		return
+	}
+
+	if !hh.validAddress(address) {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address must be a 0x-prefixed 20-byte hex string")
+		return
+	}
+
+	count, totalIn, totalOut, err := hh.parser.GetTransactionSummaryCtx(r.Context(), address)
+	if err != nil {
+		writeParserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summaryResponse{
+		Address:  address,
+		Count:    count,
+		TotalIn:  totalIn.String(),
+		TotalOut: totalOut.String(),
+	})
+}
+
+func (hh *httpHandler) handleGetSync(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address is required")
+		return
+	}
+
+	if !hh.validAddress(address) {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address must be a 0x-prefixed 20-byte hex string")
+		return
+	}
+
+	processedBlock, headBlock, behind, err := hh.parser.SyncStatus(address)
+	if err != nil {
+		writeParserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(syncResponse{
+		Address:        address,
+		ProcessedBlock: processedBlock,
+		HeadBlock:      headBlock,
+		Behind:         behind,
+	})
+}
+
+// handleGetStatus reports the error the last scan for the transactions of a
+// subscribed address failed with
+func (hh *httpHandler) handleGetStatus(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address is required")
+		return
+	}
+
+	if !hh.validAddress(address) {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address must be a 0x-prefixed 20-byte hex string")
+		return
+	}
+
+	lastErr := hh.parser.LastError(address)
+	if errors.Is(lastErr, parser.ErrNotSubscribed) {
+		writeParserError(w, lastErr)
+		return
+	}
+
+	status := statusResponse{Address: address, Healthy: lastErr == nil}
+	if lastErr != nil {
+		status.LastError = lastErr.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleRefresh scans the blocks from the from query parameter to the to one
+// again for the transactions of a subscribed address
+func (hh *httpHandler) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address is required")
+		return
+	}
+
+	if !hh.validAddress(address) {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address must be a 0x-prefixed 20-byte hex string")
+		return
+	}
+
+	if r.URL.Query().Get("from") == "" || r.URL.Query().Get("to") == "" {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "from and to are required")
+		return
+	}
+
+	var from, to int
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"from", &from}, {"to", &to}} {
+		var err error
+		if *param.value, err = queryInt(r, param.name); err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidRequest, param.name+" must be a non-negative integer")
+			return
+		}
+	}
+
+	if from > to {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "from cannot be above to")
+		return
+	}
+
+	err := hh.parser.RefreshRangeCtx(r.Context(), address, from, to)
+	if err != nil {
+		writeParserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(refreshResponse{Address: address, From: from, To: to})
+}
+
+func (hh *httpHandler) handleGetBlock(w http.ResponseWriter, r *http.Request) {
+	number := r.URL.Query().Get("number")
+	if number == "" {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "number is required")
+		return
+	}
+
+	blockNumber, err := parseBlockNumber(number)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "number must be a decimal or 0x-prefixed hex block number")
+		return
+	}
+
+	block, err := hh.parser.GetBlockCtx(r.Context(), blockNumber)
+	if err != nil {
+		writeParserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(block)
+}
+
+func (hh *httpHandler) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
+	hash := r.URL.Query().Get("hash")
+	if hash == "" {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "hash is required")
+		return
+	}
+
+	if !models.IsValidHash(hash) {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "hash must be a 0x-prefixed 32-byte hex string")
+		return
+	}
+
+	tx, err := hh.parser.GetTransactionByHashCtx(r.Context(), hash)
+	if err != nil {
+		writeParserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tx)
+}
+
+// parseBlockNumber parses a block number given in decimal or 0x-prefixed hex
+func parseBlockNumber(s string) (int, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		// a bare prefix is zero from a node, but a typo from a user
+		if len(s) == len("0x") {
+			return 0, fmt.Errorf("missing block number digits: %q", s)
+		}
+		return models.ParseHexInt(s)
+	}
+
+	blockNumber, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if blockNumber < 0 {
+		return 0, fmt.Errorf("negative block number: %d", blockNumber)
+	}
+
+	return blockNumber, nil
+}
+
+func (hh *httpHandler) handleGetGasPrice(w http.ResponseWriter, r *http.Request) {
+	unit := r.URL.Query().Get("unit")
+	if unit == "" {
+		unit = "gwei"
+	}
+
+	if unit != "gwei" && unit != "wei" {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "unit must be gwei or wei")
+		return
+	}
+
+	gasPrice, err := hh.parser.GasPriceCtx(r.Context())
+	if err != nil {
+		writeParserError(w, err)
+		return
+	}
+
+	value := gasPrice.String()
+	if unit == "gwei" {
+		gwei := new(big.Float).Quo(new(big.Float).SetInt(gasPrice), big.NewFloat(1e9))
+		value = gwei.Text('f', -1)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(gasPriceResponse{Value: value, Unit: unit})
+}
+
+func (hh *httpHandler) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	stats := statsResponse{
+		Addresses: make(map[string]addressStats),
+		RPC:       hh.parser.Stats(),
+	}
+
+	for _, address := range hh.parser.SubscribedAddresses() {
+		synced, err := hh.parser.IsSynced(address)
+		if err != nil {
+			writeParserError(w, err)
+			return
+		}
+
+		stats.Addresses[address] = addressStats{Synced: synced}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (hh *httpHandler) handleGetCacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hh.parser.CacheStats())
+}
+
+// handleGetSubscriptions lists the subscribed addresses mapped by the block
+// number they were subscribed at
+func (hh *httpHandler) handleGetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hh.parser.Subscriptions())
+}
+
+// handleExport streams the cached transactions of every subscribed address
+// as NDJSON, one transaction per line, so clients can read it as it comes.
+// The transactions are read and written an address at a time, for the
+// memory used to be bounded by the largest address rather than the total.
+func (hh *httpHandler) handleExport(w http.ResponseWriter, r *http.Request) {
+	flusher, _ := w.(http.Flusher)
+
+	w.Header().Set("Content-Type", contentTypeNDJSON)
+	enc := json.NewEncoder(w)
+	for _, address := range hh.parser.SubscribedAddresses() {
+		if r.Context().Err() != nil {
+			return
+		}
+
+		for _, tx := range hh.parser.ExportAddress(address) {
+			// the client went away
+			if err := enc.Encode(exportRecord{Address: address, Transaction: tx}); err != nil {
+				return
+			}
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// handleGetActivity lists the most recently scanned blocks, the most recent
+// first
+func (hh *httpHandler) handleGetActivity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hh.parser.RecentActivity())
 }
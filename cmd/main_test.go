@@ -0,0 +1,867 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/cache"
+	"ethparser/internal/models"
+	"ethparser/internal/parser"
+)
+
+// newTestHandler returns an httpHandler backed by a parser talking to a fake
+// JSON-RPC node that answers each method with the given result
+func newTestHandler(t *testing.T, results map[string]interface{}, opts ...parser.EthParserOpt) *httpHandler {
+	t.Helper()
+
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req parser.JsonRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      req.ID,
+			"jsonrpc": "2.0",
+			"result":  results[req.Method],
+		})
+	}))
+	t.Cleanup(node.Close)
+
+	p, err := parser.NewEthParser(append([]parser.EthParserOpt{parser.WithNodeUrl(node.URL)}, opts...)...)
+	require.NoError(t, err)
+
+	return &httpHandler{parser: p, shutdown: make(chan struct{})}
+}
+
+func TestHandleGetGasPrice(t *testing.T) {
+	handler := newTestHandler(t, map[string]interface{}{
+		// 23.5 gwei
+		"eth_gasPrice": "0x578b58b00",
+	})
+
+	tests := []struct {
+		query string
+		want  gasPriceResponse
+	}{
+		{query: "", want: gasPriceResponse{Value: "23.5", Unit: "gwei"}},
+		{query: "?unit=gwei", want: gasPriceResponse{Value: "23.5", Unit: "gwei"}},
+		{query: "?unit=wei", want: gasPriceResponse{Value: "23500000000", Unit: "wei"}},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.handleGetGasPrice(rec, httptest.NewRequest(http.MethodGet, "/gasPrice"+tt.query, nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var got gasPriceResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		require.Equal(t, tt.want, got)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.handleGetGasPrice(rec, httptest.NewRequest(http.MethodGet, "/gasPrice?unit=ether", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandleGetTransactionsNotSubscribed(t *testing.T) {
+	handler := newTestHandler(t, map[string]interface{}{"eth_blockNumber": "0x10"})
+
+	rec := httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address=0xcb81fa1fc2a94461f49d9106dcb7772a29288efe", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Contains(t, rec.Body.String(), "address not found in the observer")
+}
+
+func TestHandleGetTransactionsEtherscanFormat(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:      "0xb16",
+			Number:    "0x10",
+			Timestamp: "0x6553f100",
+			Transactions: []models.Transaction{
+				{
+					Hash: "0x01", From: address, To: "0x02", Value: "0x1bc16d674ec80000", BlockHash: "0xb16", BlockNumber: "0x10",
+					TransactionIndex: "0x3", Nonce: "0x15", Gas: "0x5208", GasPrice: "0x578b58b00",
+				},
+			},
+		},
+	})
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	rec := httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?format=etherscan&address="+address, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got etherscanResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	require.Equal(t, "1", got.Status)
+	require.Equal(t, "OK", got.Message)
+	require.Equal(t, []etherscanTransaction{{
+		BlockNumber:      "16",
+		TimeStamp:        "1700000000",
+		Hash:             "0x01",
+		Nonce:            "21",
+		BlockHash:        "0xb16",
+		TransactionIndex: "3",
+		From:             address,
+		To:               "0x02",
+		Value:            "2000000000000000000",
+		Gas:              "21000",
+		GasPrice:         "23500000000",
+	}}, got.Result)
+}
+
+func TestHandleGetTransactionsDirection(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:   "0xb16",
+			Number: "0x10",
+			Transactions: []models.Transaction{
+				{Hash: "0x01", From: address, To: "0x02", BlockHash: "0xb16", BlockNumber: "0x10"},
+				{Hash: "0x02", From: "0x02", To: address, BlockHash: "0xb16", BlockNumber: "0x10"},
+			},
+		},
+	})
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	tests := []struct {
+		direction string
+		want      []string
+	}{
+		{direction: "", want: []string{"0x01", "0x02"}},
+		{direction: "all", want: []string{"0x01", "0x02"}},
+		{direction: "in", want: []string{"0x02"}},
+		{direction: "out", want: []string{"0x01"}},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address+"&direction="+tt.direction, nil))
+		require.Equal(t, http.StatusOK, rec.Code, tt.direction)
+
+		var transactions []models.Transaction
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&transactions))
+
+		hashes := make([]string, 0, len(transactions))
+		for _, tx := range transactions {
+			hashes = append(hashes, tx.Hash)
+		}
+		require.ElementsMatch(t, tt.want, hashes, tt.direction)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address+"&direction=sideways", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandleGetTransactionsDecoded(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:   "0xb16",
+			Number: "0x10",
+			Transactions: []models.Transaction{
+				{Hash: "0x01", From: address, To: "0x02", Value: "0x1bc16d674ec80000", BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x0"},
+				{Hash: "0x02", From: "0x02", To: address, Value: "0x6f05b59d3b20000", BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x1"},
+				{Hash: "0x03", From: address, To: address, BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x2"},
+			},
+		},
+	})
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	rec := httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address+"&decode=true", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got []struct {
+		Hash       string `json:"hash"`
+		Value      string `json:"value"`
+		ValueWei   string `json:"valueWei"`
+		ValueEther string `json:"valueEther"`
+		Direction  string `json:"direction"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	require.Len(t, got, 3)
+
+	require.Equal(t, "0x01", got[0].Hash)
+	require.Equal(t, "0x1bc16d674ec80000", got[0].Value)
+	require.Equal(t, "2000000000000000000", got[0].ValueWei)
+	require.Equal(t, "2", got[0].ValueEther)
+	require.Equal(t, "out", got[0].Direction)
+
+	require.Equal(t, "0.5", got[1].ValueEther)
+	require.Equal(t, "in", got[1].Direction)
+
+	require.Equal(t, "0", got[2].ValueWei)
+	require.Equal(t, "self", got[2].Direction)
+
+	// the raw transactions are listed by default
+	rec = httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotContains(t, rec.Body.String(), "valueWei")
+}
+
+func TestHandleGetTransactionsTruncated(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	p, err := parser.NewEthParser(parser.WithNodeUrl(newTestNodeURL(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:   "0xb16",
+			Number: "0x10",
+			Transactions: []models.Transaction{
+				{Hash: "0x01", From: address, BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x0"},
+				{Hash: "0x02", To: address, BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x1"},
+				{Hash: "0x03", From: address, BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x2"},
+			},
+		},
+	})), parser.WithMaxResultSize(2))
+	require.NoError(t, err)
+	handler := &httpHandler{parser: p, shutdown: make(chan struct{})}
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	rec := httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "true", rec.Header().Get("X-Result-Truncated"))
+
+	var got []models.Transaction
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	require.Len(t, got, 2)
+	require.Equal(t, "0x02", got[0].Hash)
+	require.Equal(t, "0x03", got[1].Hash)
+	// the total counts every transaction, not only the listed ones
+	require.Equal(t, "3", rec.Header().Get("X-Total-Count"))
+
+	// the transactions before the most recent ones are reached by paging
+	rec = httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address+"&limit=1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, rec.Header().Get("X-Result-Truncated"))
+
+	got = nil
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	require.Len(t, got, 1)
+	require.Equal(t, "0x01", got[0].Hash)
+}
+
+func TestHandleGetTransactionsBlockNotYetAvailable(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	// the node answers null for the head it reports
+	p, err := parser.NewEthParser(parser.WithNodeUrl(newTestNodeURL(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+	})), parser.WithConfirmations(0))
+	require.NoError(t, err)
+	handler := &httpHandler{parser: p, shutdown: make(chan struct{})}
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	rec := httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address, nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, limitRetryAfter, rec.Header().Get("Retry-After"))
+}
+
+func TestHandleGetTransactionsPaged(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:   "0xb16",
+			Number: "0x10",
+			Transactions: []models.Transaction{
+				{Hash: "0x03", From: address, BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x2"},
+				{Hash: "0x01", From: address, BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x0"},
+				{Hash: "0x02", To: address, BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x1"},
+			},
+		},
+	})
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	rec := httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address+"&limit=2&offset=1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "3", rec.Header().Get("X-Total-Count"))
+	require.Equal(t, "16", rec.Header().Get("X-From-Block"))
+	require.Equal(t, "16", rec.Header().Get("X-To-Block"))
+
+	var transactions []models.Transaction
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&transactions))
+	require.Len(t, transactions, 2)
+	require.Equal(t, "0x02", transactions[0].Hash)
+	require.Equal(t, "0x03", transactions[1].Hash)
+
+	rec = httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address+"&fromBlock=17", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "0", rec.Header().Get("X-Total-Count"))
+
+	for _, query := range []string{"limit=-1", "offset=x", "fromBlock=20&toBlock=10"} {
+		rec = httptest.NewRecorder()
+		handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address+"&"+query, nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestHandleGetStats(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	block := models.BlockWithDetails{Hash: "0xb16", Number: "0x10"}
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber":      "0x10",
+		"eth_getBlockByNumber": block,
+		"eth_getBlockByHash":   block,
+	})
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	getStats := func() statsResponse {
+		rec := httptest.NewRecorder()
+		handler.handleGetStats(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var got statsResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		return got
+	}
+
+	require.Equal(t, map[string]addressStats{address: {Synced: false}}, getStats().Addresses)
+
+	_, err := handler.parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	stats := getStats()
+	require.Equal(t, map[string]addressStats{address: {Synced: true}}, stats.Addresses)
+	require.Positive(t, stats.RPC.Calls["eth_blockNumber"])
+	require.Equal(t, int64(1), stats.RPC.Calls["eth_getBlockByNumber"])
+}
+
+func TestHandleGetCacheStats(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:         "0xb16",
+			Number:       "0x10",
+			Transactions: []models.Transaction{{Hash: "0x01", From: address, BlockHash: "0xb16", BlockNumber: "0x10"}},
+		},
+	})
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	_, err := handler.parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	handler.handleGetCacheStats(rec, httptest.NewRequest(http.MethodGet, "/cache/stats", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var stats cache.CacheStats
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+	require.Equal(t, 1, stats.Addresses)
+	require.Equal(t, 1, stats.Transactions)
+}
+
+func TestHandleGetSubscriptions(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{"eth_blockNumber": "0x10"})
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	rec := httptest.NewRecorder()
+	handler.handleGetSubscriptions(rec, httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var subscriptions map[string]int
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&subscriptions))
+	require.Equal(t, map[string]int{address: 16}, subscriptions)
+}
+
+func TestHandleHealthz(t *testing.T) {
+	handler := newTestHandler(t, map[string]interface{}{"eth_blockNumber": "0x10"})
+
+	rec := httptest.NewRecorder()
+	handler.handleHealthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var health healthResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&health))
+	require.Equal(t, healthResponse{OK: true, Block: 16}, health)
+
+	// the node is down
+	node := httptest.NewServer(http.NotFoundHandler())
+	node.Close()
+
+	p, err := parser.NewEthParser(parser.WithNodeUrl(node.URL), parser.WithRetry(1, time.Millisecond))
+	require.NoError(t, err)
+	handler = &httpHandler{parser: p, shutdown: make(chan struct{})}
+
+	rec = httptest.NewRecorder()
+	handler.handleHealthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	health = healthResponse{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&health))
+	require.False(t, health.OK)
+	require.NotEmpty(t, health.Error)
+}
+
+func TestHandleGetActivity(t *testing.T) {
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:   "0xb16",
+			Number: "0x10",
+		},
+	})
+	require.NoError(t, handler.parser.Subscribe("0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"))
+	_, err := handler.parser.GetTransactions("0xcb81fa1fc2a94461f49d9106dcb7772a29288efe")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	handler.handleGetActivity(rec, httptest.NewRequest(http.MethodGet, "/debug/activity", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var activity []parser.BlockActivity
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&activity))
+	require.Len(t, activity, 1)
+	require.Equal(t, 16, activity[0].Number)
+	require.Equal(t, "0xb16", activity[0].Hash)
+}
+
+func TestHandleSubscribeInvalidAddress(t *testing.T) {
+	handler := newTestHandler(t, map[string]interface{}{"eth_blockNumber": "0x10"})
+
+	for _, address := range []string{"0x01", "cb81fa1fc2a94461f49d9106dcb7772a29288efe", "0xzz81fa1fc2a94461f49d9106dcb7772a29288efe"} {
+		rec := httptest.NewRecorder()
+		handler.handleSubscribe(rec, httptest.NewRequest(http.MethodGet, "/subscribe?address="+address, nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code, address)
+		require.Contains(t, rec.Body.String(), "address must be")
+	}
+
+	rec := httptest.NewRecorder()
+	handler.handleSubscribe(rec, httptest.NewRequest(http.MethodGet, "/subscribe?address=0xCB81Fa1fc2a94461f49d9106dcb7772a29288efe", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandleENSNames(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	// the registry and the resolver both answer address
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_call":        "0x000000000000000000000000" + address[2:],
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:         "0xb16",
+			Number:       "0x10",
+			Transactions: []models.Transaction{{Hash: "0x01", From: address, To: "0x02", Value: "0x1", BlockHash: "0xb16", BlockNumber: "0x10"}},
+		},
+	}, parser.WithENSResolution("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"))
+
+	// names are only accepted once the parser resolves them
+	rec := httptest.NewRecorder()
+	handler.handleSubscribe(rec, httptest.NewRequest(http.MethodGet, "/subscribe?address=vitalik.eth", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	handler.ensNames = true
+	rec = httptest.NewRecorder()
+	handler.handleSubscribe(rec, httptest.NewRequest(http.MethodGet, "/subscribe?address=vitalik.eth", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, []string{address}, handler.parser.SubscribedAddresses())
+
+	rec = httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address=vitalik.eth", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.handleUnsubscribe(rec, httptest.NewRequest(http.MethodGet, "/unsubscribe?address=vitalik.eth", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, handler.parser.SubscribedAddresses())
+}
+
+func TestHandlersRespondWithJSON(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	tx := models.Transaction{Hash: "0x01", From: address, To: "0x02", Value: "0x1", BlockHash: "0xb16", BlockNumber: "0x10"}
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:         "0xb16",
+			Number:       "0x10",
+			Transactions: []models.Transaction{tx},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	handler.handleSubscribe(rec, httptest.NewRequest(http.MethodGet, "/subscribe?address="+address, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"subscribed": true}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.handleGetTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions?address="+address, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var transactions []models.Transaction
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&transactions))
+	require.Equal(t, []models.Transaction{tx}, transactions)
+
+	rec = httptest.NewRecorder()
+	handler.handleGetCurrentBlock(rec, httptest.NewRequest(http.MethodGet, "/currentBlock", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"currentBlock": 16}`, rec.Body.String())
+}
+
+func TestHandleGetChainID(t *testing.T) {
+	handler := newTestHandler(t, map[string]interface{}{"eth_chainId": "0x1"})
+
+	rec := httptest.NewRecorder()
+	handler.handleGetChainID(rec, httptest.NewRequest(http.MethodGet, "/chainid", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"chainId": 1}`, rec.Body.String())
+
+	// a node not answering a chain id fails
+	handler = newTestHandler(t, map[string]interface{}{})
+	rec = httptest.NewRecorder()
+	handler.handleGetChainID(rec, httptest.NewRequest(http.MethodGet, "/chainid", nil))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestHandleGetBlock(t *testing.T) {
+	block := models.BlockWithDetails{Hash: "0xb16", ParentHash: "0xb15", Number: "0x10"}
+	handler := newTestHandler(t, map[string]interface{}{"eth_getBlockByNumber": block})
+
+	for _, number := range []string{"16", "0x10"} {
+		rec := httptest.NewRecorder()
+		handler.handleGetBlock(rec, httptest.NewRequest(http.MethodGet, "/block?number="+number, nil))
+		require.Equal(t, http.StatusOK, rec.Code, number)
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var got models.BlockWithDetails
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		require.Equal(t, block, got)
+	}
+
+	for _, number := range []string{"", "-1", "0x", "0xzz", "ten"} {
+		rec := httptest.NewRecorder()
+		handler.handleGetBlock(rec, httptest.NewRequest(http.MethodGet, "/block?number="+number, nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code, number)
+	}
+
+	// the node answers null for blocks it doesn't have
+	handler = newTestHandler(t, map[string]interface{}{})
+	rec := httptest.NewRecorder()
+	handler.handleGetBlock(rec, httptest.NewRequest(http.MethodGet, "/block?number=99999999", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandleGetSummary(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:   "0xb16",
+			Number: "0x10",
+			Transactions: []models.Transaction{
+				{Hash: "0x01", From: address, To: "0x02", Value: "0x1bc16d674ec80000", BlockHash: "0xb16", BlockNumber: "0x10"},
+				{Hash: "0x02", From: "0x02", To: address, Value: "0xde0b6b3a7640000", BlockHash: "0xb16", BlockNumber: "0x10"},
+				{Hash: "0x03", From: "0x02", To: address, Value: "0x1", BlockHash: "0xb16", BlockNumber: "0x10"},
+			},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	handler.handleGetSummary(rec, httptest.NewRequest(http.MethodGet, "/summary?address="+address, nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	rec = httptest.NewRecorder()
+	handler.handleGetSummary(rec, httptest.NewRequest(http.MethodGet, "/summary?address="+address, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var got summaryResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	require.Equal(t, summaryResponse{
+		Address:  address,
+		Count:    3,
+		TotalIn:  "1000000000000000001",
+		TotalOut: "2000000000000000000",
+	}, got)
+
+	for _, query := range []string{"", "?address=0x01"} {
+		rec := httptest.NewRecorder()
+		handler.handleGetSummary(rec, httptest.NewRequest(http.MethodGet, "/summary"+query, nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestHandleExport(t *testing.T) {
+	const (
+		address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+		other   = "0x28c6c06298d514db089934071355e5743bf21d60"
+	)
+
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:   "0xb16",
+			Number: "0x10",
+			Transactions: []models.Transaction{
+				{Hash: "0x01", From: address, To: "0x02", BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x0"},
+				{Hash: "0x02", From: other, To: address, BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x1"},
+				{Hash: "0x03", From: "0x02", To: "0x03", BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x2"},
+			},
+		},
+	})
+	for _, a := range []string{address, other} {
+		require.NoError(t, handler.parser.Subscribe(a))
+		_, err := handler.parser.GetTransactions(a)
+		require.NoError(t, err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.handleExport(rec, httptest.NewRequest(http.MethodGet, "/export", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, contentTypeNDJSON, rec.Header().Get("Content-Type"))
+
+	var got []exportRecord
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var record exportRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		got = append(got, record)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, got, 3)
+	require.Equal(t, other, got[0].Address)
+	require.Equal(t, "0x02", got[0].Transaction.Hash)
+	require.Equal(t, address, got[1].Address)
+	require.Equal(t, "0x01", got[1].Transaction.Hash)
+	require.Equal(t, address, got[2].Address)
+	require.Equal(t, "0x02", got[2].Transaction.Hash)
+}
+
+func TestHandleGetTransaction(t *testing.T) {
+	const hash = "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+
+	tx := models.Transaction{Hash: hash, From: "0x01", To: "0x02", Value: "0x1", BlockNumber: "0x10"}
+	handler := newTestHandler(t, map[string]interface{}{"eth_getTransactionByHash": tx})
+
+	rec := httptest.NewRecorder()
+	handler.handleGetTransaction(rec, httptest.NewRequest(http.MethodGet, "/transaction?hash="+hash, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var got models.Transaction
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	require.Equal(t, tx, got)
+
+	for _, query := range []string{"", "?hash=0x01", "?hash=" + hash[2:]} {
+		rec := httptest.NewRecorder()
+		handler.handleGetTransaction(rec, httptest.NewRequest(http.MethodGet, "/transaction"+query, nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+
+	// the node answers null for transactions it doesn't have
+	handler = newTestHandler(t, map[string]interface{}{})
+	rec = httptest.NewRecorder()
+	handler.handleGetTransaction(rec, httptest.NewRequest(http.MethodGet, "/transaction?hash="+hash, nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// headerCountingRecorder counts the calls to WriteHeader
+type headerCountingRecorder struct {
+	*httptest.ResponseRecorder
+	writeHeaderCalls int
+}
+
+func (hr *headerCountingRecorder) WriteHeader(code int) {
+	hr.writeHeaderCalls++
+	hr.ResponseRecorder.WriteHeader(code)
+}
+
+func TestHandlersWriteHeaderOnce(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_gasPrice":    "0x578b58b00",
+	})
+
+	tests := []struct {
+		handler http.HandlerFunc
+		target  string
+		code    int
+	}{
+		{handler: handler.handleGetTransactions, target: "/transactions", code: http.StatusBadRequest},
+		{handler: handler.handleGetTransactions, target: "/transactions?address=0x01", code: http.StatusBadRequest},
+		{handler: handler.handleGetTransactions, target: "/transactions?address=" + address, code: http.StatusNotFound},
+		{handler: handler.handleSubscribe, target: "/subscribe", code: http.StatusBadRequest},
+		{handler: handler.handleSubscribe, target: "/subscribe?address=" + address, code: http.StatusOK},
+		{handler: handler.handleSubscribe, target: "/subscribe?address=" + address, code: http.StatusInternalServerError},
+		{handler: handler.handleUnsubscribe, target: "/unsubscribe?address=" + address, code: http.StatusOK},
+		{handler: handler.handleUnsubscribe, target: "/unsubscribe?address=" + address, code: http.StatusNotFound},
+		{handler: handler.handleGetCurrentBlock, target: "/currentBlock", code: http.StatusOK},
+		{handler: handler.handleGetBlock, target: "/block?number=ten", code: http.StatusBadRequest},
+		{handler: handler.handleGetBlock, target: "/block?number=16", code: http.StatusNotFound},
+		{handler: handler.handleGetGasPrice, target: "/gasPrice?unit=ether", code: http.StatusBadRequest},
+		{handler: handler.handleGetGasPrice, target: "/gasPrice", code: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rec := &headerCountingRecorder{ResponseRecorder: httptest.NewRecorder()}
+		tt.handler(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		require.Equal(t, tt.code, rec.Code, tt.target)
+		require.LessOrEqual(t, rec.writeHeaderCalls, 1, tt.target)
+	}
+}
+
+func TestNewEtherscanResponseEmpty(t *testing.T) {
+	got := newEtherscanResponse(nil)
+	require.Equal(t, "0", got.Status)
+	require.Empty(t, got.Result)
+}
+
+func TestHandleRefresh(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	block := models.BlockWithDetails{
+		Hash:   "0xb16",
+		Number: "0x10",
+		Transactions: []models.Transaction{
+			{Hash: "0x01", From: address, To: "0x02", BlockHash: "0xb16", BlockNumber: "0x10"},
+		},
+	}
+	results := map[string]interface{}{"eth_blockNumber": "0x10", "eth_getBlockByNumber": block}
+	handler := newTestHandler(t, results)
+
+	rec := httptest.NewRecorder()
+	handler.handleRefresh(rec, httptest.NewRequest(http.MethodPost, "/refresh?address="+address+"&from=16&to=16", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.NoError(t, handler.parser.Subscribe(address))
+	txs, err := handler.parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	// the node now returns a transaction the first scan missed
+	block.Transactions = append(block.Transactions, models.Transaction{Hash: "0x02", From: "0x02", To: address, BlockHash: "0xb16", BlockNumber: "0x10"})
+	results["eth_getBlockByNumber"] = block
+
+	rec = httptest.NewRecorder()
+	handler.handleRefresh(rec, httptest.NewRequest(http.MethodPost, "/refresh?address="+address+"&from=16&to=16", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got refreshResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	require.Equal(t, refreshResponse{Address: address, From: 16, To: 16}, got)
+
+	txs, err = handler.parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+
+	for _, query := range []string{"", "?address=0x01", "?address=" + address, "?address=" + address + "&from=2&to=1", "?address=" + address + "&from=-1&to=1"} {
+		rec := httptest.NewRecorder()
+		handler.handleRefresh(rec, httptest.NewRequest(http.MethodPost, "/refresh"+query, nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestHandleGetStatus(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	// the node answers blocks that cannot be decoded, so scanning for
+	// transactions fails
+	handler := newTestHandler(t, map[string]interface{}{"eth_blockNumber": "0x10", "eth_getBlockByNumber": "0x10"})
+
+	rec := httptest.NewRecorder()
+	handler.handleGetStatus(rec, httptest.NewRequest(http.MethodGet, "/status?address="+address, nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	rec = httptest.NewRecorder()
+	handler.handleGetStatus(rec, httptest.NewRequest(http.MethodGet, "/status?address="+address, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got statusResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	require.Equal(t, statusResponse{Address: address, Healthy: true}, got)
+
+	_, err := handler.parser.GetTransactions(address)
+	require.Error(t, err)
+
+	rec = httptest.NewRecorder()
+	handler.handleGetStatus(rec, httptest.NewRequest(http.MethodGet, "/status?address="+address, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	got = statusResponse{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	require.False(t, got.Healthy)
+	require.Equal(t, err.Error(), got.LastError)
+
+	for _, query := range []string{"", "?address=0x01"} {
+		rec := httptest.NewRecorder()
+		handler.handleGetStatus(rec, httptest.NewRequest(http.MethodGet, "/status"+query, nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestHandleGetSync(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{"eth_blockNumber": "0x10"})
+
+	rec := httptest.NewRecorder()
+	handler.handleGetSync(rec, httptest.NewRequest(http.MethodGet, "/sync?address="+address, nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	rec = httptest.NewRecorder()
+	handler.handleGetSync(rec, httptest.NewRequest(http.MethodGet, "/sync?address="+address, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var got syncResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	require.Equal(t, syncResponse{Address: address, ProcessedBlock: 15, HeadBlock: 16, Behind: 1}, got)
+
+	for _, query := range []string{"", "?address=0x01"} {
+		rec := httptest.NewRecorder()
+		handler.handleGetSync(rec, httptest.NewRequest(http.MethodGet, "/sync"+query, nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"ethparser/internal/parser"
+)
+
+// promMetrics exports the calls sent to the node as Prometheus metrics
+type promMetrics struct {
+	calls    *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+}
+
+var _ parser.Metrics = &promMetrics{}
+
+func newPromMetrics(reg prometheus.Registerer) *promMetrics {
+	m := &promMetrics{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethparser_rpc_calls_total",
+			Help: "JSON-RPC calls sent to the node by method and status.",
+		}, []string{"method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ethparser_rpc_duration_seconds",
+			Help:    "Duration of the JSON-RPC calls sent to the node, retries included.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethparser_rpc_retries_total",
+			Help: "Failed JSON-RPC call attempts retried by method.",
+		}, []string{"method"}),
+	}
+	reg.MustRegister(m.calls, m.duration, m.retries)
+
+	return m
+}
+
+func (m *promMetrics) ObserveCall(method string, status string, duration time.Duration) {
+	m.calls.WithLabelValues(method, status).Inc()
+	m.duration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+func (m *promMetrics) ObserveRetry(method string) {
+	m.retries.WithLabelValues(method).Inc()
+}
+
+// registerCacheMetrics exports the hits and misses of the transaction cache
+// of p, read from its stats on every scrape
+func registerCacheMetrics(reg prometheus.Registerer, p parser.Parser) {
+	reg.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "ethparser_cache_hits_total",
+			Help: "Transaction cache lookups finding the address.",
+		}, func() float64 { return float64(p.CacheStats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "ethparser_cache_misses_total",
+			Help: "Transaction cache lookups missing the address.",
+		}, func() float64 { return float64(p.CacheStats().Misses) }),
+	)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newPromMetrics(reg)
+
+	m.ObserveCall("eth_blockNumber", "ok", 10*time.Millisecond)
+	m.ObserveCall("eth_blockNumber", "ok", 20*time.Millisecond)
+	m.ObserveCall("eth_getBalance", "rpc_error", time.Millisecond)
+	m.ObserveRetry("eth_blockNumber")
+
+	require.Equal(t, 2.0, testutil.ToFloat64(m.calls.WithLabelValues("eth_blockNumber", "ok")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.calls.WithLabelValues("eth_getBalance", "rpc_error")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.retries.WithLabelValues("eth_blockNumber")))
+	require.Equal(t, 2, testutil.CollectAndCount(m.duration))
+}
+
+func TestRegisterCacheMetrics(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{"eth_blockNumber": "0x10"})
+	require.NoError(t, handler.parser.Subscribe(address))
+	handler.parser.GetTransactions(address)
+
+	reg := prometheus.NewRegistry()
+	registerCacheMetrics(reg, handler.parser)
+
+	stats := handler.parser.CacheStats()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		values[family.GetName()] = family.GetMetric()[0].GetCounter().GetValue()
+	}
+	require.Equal(t, map[string]float64{
+		"ethparser_cache_hits_total":   float64(stats.Hits),
+		"ethparser_cache_misses_total": float64(stats.Misses),
+	}, values)
+}
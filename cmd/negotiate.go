@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	contentTypeJSON   = "application/json"
+	contentTypeCSV    = "text/csv"
+	contentTypeNDJSON = "application/x-ndjson"
+	contentTypeText   = "text/plain; charset=utf-8"
+)
+
+// response is the body of an endpoint in every format it can be served in
+type response struct {
+	// records are encoded as a JSON array, or one per line in NDJSON,
+	// unless single is set, in which case the only record is encoded alone
+	records []interface{}
+	single  bool
+	// header and rows are the CSV form of records
+	header []string
+	rows   [][]string
+	// text is the plain text form of records
+	text string
+}
+
+// negotiate picks the content type to serve from the Accept header,
+// defaulting to JSON
+func negotiate(r *http.Request) string {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+
+		switch mediaType {
+		case contentTypeJSON, contentTypeCSV, contentTypeNDJSON:
+			return mediaType
+		case "text/plain":
+			return contentTypeText
+		}
+	}
+
+	return contentTypeJSON
+}
+
+// writeResponse writes resp in the format negotiated for r
+func writeResponse(w http.ResponseWriter, r *http.Request, resp response) {
+	contentType := negotiate(r)
+	w.Header().Set("Content-Type", contentType)
+
+	switch contentType {
+	case contentTypeJSON:
+		if resp.single {
+			json.NewEncoder(w).Encode(resp.records[0])
+			return
+		}
+		json.NewEncoder(w).Encode(resp.records)
+	case contentTypeNDJSON:
+		enc := json.NewEncoder(w)
+		for _, record := range resp.records {
+			enc.Encode(record)
+		}
+	case contentTypeCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(resp.header)
+		cw.WriteAll(resp.rows)
+	default:
+		w.Write([]byte(resp.text))
+	}
+}
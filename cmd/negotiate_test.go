@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: contentTypeJSON},
+		{accept: "*/*", want: contentTypeJSON},
+		{accept: "text/plain", want: contentTypeText},
+		{accept: "application/json", want: contentTypeJSON},
+		{accept: "text/csv", want: contentTypeCSV},
+		{accept: "application/x-ndjson", want: contentTypeNDJSON},
+		{accept: "text/html, application/json;q=0.9", want: contentTypeJSON},
+		{accept: "text/csv;q=0, application/x-ndjson", want: contentTypeNDJSON},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tt.accept)
+		require.Equal(t, tt.want, negotiate(r), tt.accept)
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBalance":  "0x14d1120d7b160000",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:   "0xb16",
+			Number: "0x10",
+			Transactions: []models.Transaction{
+				{Hash: "0x01", From: address, To: "0x02", Value: "0x1", BlockHash: "0xb16", BlockNumber: "0x10"},
+				{Hash: "0x02", From: "0x02", To: address, Value: "0x2", BlockHash: "0xb16", BlockNumber: "0x10"},
+			},
+		},
+	})
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	serve := func(h http.HandlerFunc, target string, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.Header.Set("Accept", accept)
+
+		rec := httptest.NewRecorder()
+		h(rec, r)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec
+	}
+
+	endpoints := []struct {
+		handler http.HandlerFunc
+		target  string
+		records int
+		// text lists the lines of the text form, in any order as cached
+		// transactions are unordered
+		text []string
+	}{
+		{handler: handler.handleGetTransactions, target: "/transactions?address=" + address, records: 2, text: []string{"0x01", "0x02"}},
+		{handler: handler.handleGetCurrentBlock, target: "/currentBlock", records: 1, text: []string{"16"}},
+		{handler: handler.handleGetBalance, target: "/balance?address=" + address, records: 1, text: []string{"1500000000000000000"}},
+	}
+
+	for _, ep := range endpoints {
+		rec := serve(ep.handler, ep.target, "application/json")
+		require.Equal(t, contentTypeJSON, rec.Header().Get("Content-Type"))
+		if ep.records == 1 {
+			var got map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got), ep.target)
+		} else {
+			var got []map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got), ep.target)
+			require.Len(t, got, ep.records)
+		}
+
+		rec = serve(ep.handler, ep.target, "application/x-ndjson")
+		require.Equal(t, contentTypeNDJSON, rec.Header().Get("Content-Type"))
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		require.Len(t, lines, ep.records, ep.target)
+		for _, line := range lines {
+			var got map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(line), &got))
+		}
+
+		rec = serve(ep.handler, ep.target, "text/csv")
+		require.Equal(t, contentTypeCSV, rec.Header().Get("Content-Type"))
+		rows, err := csv.NewReader(rec.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, ep.records+1, ep.target)
+
+		rec = serve(ep.handler, ep.target, "text/plain")
+		require.Equal(t, contentTypeText, rec.Header().Get("Content-Type"))
+		require.ElementsMatch(t, ep.text, strings.Fields(rec.Body.String()))
+	}
+}
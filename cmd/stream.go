@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// handleStream pushes the new transactions of an address as Server-Sent
+// Events until the client disconnects or the server shuts down
+func (hh *httpHandler) handleStream(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address is required")
+		return
+	}
+
+	if !hh.validAddress(address) {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "address must be a 0x-prefixed 20-byte hex string")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, codeInternal, "streaming unsupported")
+		return
+	}
+
+	notifications, cancel := hh.parser.Notifications(address)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-hh.shutdown:
+			return
+		case tx, ok := <-notifications:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(tx)
+			if err != nil {
+				return
+			}
+
+			fmt.Fprintf(w, "event: transaction\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
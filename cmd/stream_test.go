@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestHandleStream(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:   "0xb16",
+			Number: "0x10",
+			Transactions: []models.Transaction{
+				{Hash: "0x01", From: address, To: "0x02", BlockHash: "0xb16", BlockNumber: "0x10"},
+			},
+		},
+	})
+	require.NoError(t, handler.parser.Subscribe(address))
+
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		handler.handleStream(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stream?address="+address, nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// the poller fetching the block sends its transaction to the stream
+	_, err = handler.parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(resp.Body)
+	event, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "event: transaction\n", event)
+
+	data, err := reader.ReadString('\n')
+	require.NoError(t, err)
+
+	var tx models.Transaction
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(data, "data: ")), &tx))
+	require.Equal(t, "0x01", tx.Hash)
+
+	// closing the connection stops the handler
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream handler did not return after the client disconnected")
+	}
+}
+
+func TestHandleStreamInvalidAddress(t *testing.T) {
+	handler := newTestHandler(t, nil)
+
+	rec := httptest.NewRecorder()
+	handler.handleStream(rec, httptest.NewRequest(http.MethodGet, "/stream?address=0x01", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandleStreamEndsOnShutdown(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, nil)
+
+	srv := httptest.NewUnstartedServer(handler.routes())
+	srv.Config.RegisterOnShutdown(func() { close(handler.shutdown) })
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/stream?address=" + address)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, srv.Config.Shutdown(ctx))
+}
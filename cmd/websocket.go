@@ -0,0 +1,227 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"ethparser/internal/models"
+)
+
+const (
+	// wsWriteWait bounds the time to write a message to the client
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is the time the client has to answer a ping
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod is how often the client is pinged, within wsPongWait
+	wsPingPeriod = wsPongWait * 9 / 10
+	// wsMaxMessageSize bounds the size of a client message
+	wsMaxMessageSize = 1024
+)
+
+var upgrader = websocket.Upgrader{
+	// browser dashboards are served from other origins
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsRequest is a message sent by the client
+type wsRequest struct {
+	// Action is either subscribe or unsubscribe
+	Action  string `json:"action"`
+	Address string `json:"address"`
+}
+
+// wsMessage is a message sent to the client
+type wsMessage struct {
+	// Type is subscribed, unsubscribed, transaction or error
+	Type        string              `json:"type"`
+	Address     string              `json:"address,omitempty"`
+	Transaction *models.Transaction `json:"transaction,omitempty"`
+	Error       string              `json:"error,omitempty"`
+}
+
+// handleWebSocket streams the new transactions of the addresses a client
+// subscribes to over a WebSocket connection until either side closes it
+func (hh *httpHandler) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already answered the client
+		return
+	}
+
+	wc := &wsConn{
+		hh:            hh,
+		conn:          conn,
+		out:           make(chan wsMessage, 16),
+		done:          make(chan struct{}),
+		writerDone:    make(chan struct{}),
+		subscriptions: make(map[string]func()),
+	}
+
+	if address := r.URL.Query().Get("address"); address != "" {
+		wc.subscribe(address)
+	}
+
+	go func() {
+		defer close(wc.writerDone)
+		wc.writeLoop()
+	}()
+
+	wc.readLoop()
+
+	wc.close()
+	<-wc.writerDone
+	conn.Close()
+}
+
+// wsConn is a client connection, its messages being written by writeLoop
+// only
+type wsConn struct {
+	hh   *httpHandler
+	conn *websocket.Conn
+	out  chan wsMessage
+	// done is closed once the connection stopped being read
+	done chan struct{}
+	// writerDone is closed once writeLoop returned
+	writerDone chan struct{}
+
+	m sync.Mutex
+	// subscriptions are the funcs ending the notifications of each address
+	subscriptions map[string]func()
+}
+
+// readLoop handles the client messages until the connection is closed
+func (wc *wsConn) readLoop() {
+	wc.conn.SetReadLimit(wsMaxMessageSize)
+	wc.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	wc.conn.SetPongHandler(func(string) error {
+		return wc.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var req wsRequest
+		if err := wc.conn.ReadJSON(&req); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Println("websocket read failed:", err)
+			}
+			return
+		}
+
+		switch req.Action {
+		case "subscribe":
+			wc.subscribe(req.Address)
+		case "unsubscribe":
+			wc.unsubscribe(req.Address)
+		default:
+			wc.send(wsMessage{Type: "error", Error: "action must be subscribe or unsubscribe"})
+		}
+	}
+}
+
+// writeLoop writes the queued messages and pings the client until the
+// connection is closed
+func (wc *wsConn) writeLoop() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-wc.out:
+			wc.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := wc.conn.WriteJSON(msg); err != nil {
+				wc.conn.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := wc.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				wc.conn.Close()
+				return
+			}
+		case <-wc.hh.shutdown:
+			wc.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+				time.Now().Add(wsWriteWait))
+			wc.conn.Close()
+			return
+		case <-wc.done:
+			wc.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+				time.Now().Add(wsWriteWait))
+			return
+		}
+	}
+}
+
+// subscribe forwards the new transactions of address to the client,
+// subscribing the parser to it if needed
+func (wc *wsConn) subscribe(address string) {
+	if !wc.hh.validAddress(address) {
+		wc.send(wsMessage{Type: "error", Address: address, Error: "address must be a 0x-prefixed 20-byte hex string"})
+		return
+	}
+
+	wc.m.Lock()
+	defer wc.m.Unlock()
+
+	if _, ok := wc.subscriptions[address]; ok {
+		wc.send(wsMessage{Type: "subscribed", Address: address})
+		return
+	}
+
+	if !slices.Contains(wc.hh.parser.SubscribedAddresses(), address) {
+		if err := wc.hh.parser.Subscribe(address); err != nil {
+			wc.send(wsMessage{Type: "error", Address: address, Error: err.Error()})
+			return
+		}
+	}
+
+	notifications, cancel := wc.hh.parser.Notifications(address)
+	wc.subscriptions[address] = cancel
+
+	go func() {
+		for tx := range notifications {
+			wc.send(wsMessage{Type: "transaction", Address: address, Transaction: tx})
+		}
+	}()
+
+	wc.send(wsMessage{Type: "subscribed", Address: address})
+}
+
+// unsubscribe stops forwarding the transactions of address to the client,
+// leaving the parser subscribed
+func (wc *wsConn) unsubscribe(address string) {
+	wc.m.Lock()
+	defer wc.m.Unlock()
+
+	if cancel, ok := wc.subscriptions[address]; ok {
+		cancel()
+		delete(wc.subscriptions, address)
+	}
+
+	wc.send(wsMessage{Type: "unsubscribed", Address: address})
+}
+
+// send queues msg for writeLoop, dropping it once the connection is closed
+func (wc *wsConn) send(msg wsMessage) {
+	select {
+	case wc.out <- msg:
+	case <-wc.done:
+	case <-wc.writerDone:
+	}
+}
+
+// close ends every subscription of the connection
+func (wc *wsConn) close() {
+	wc.m.Lock()
+	defer wc.m.Unlock()
+
+	for address, cancel := range wc.subscriptions {
+		cancel()
+		delete(wc.subscriptions, address)
+	}
+	close(wc.done)
+}
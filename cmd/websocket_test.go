@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestHandleWebSocket(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	handler := newTestHandler(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:   "0xb16",
+			Number: "0x10",
+			Transactions: []models.Transaction{
+				{Hash: "0x01", From: address, To: "0x02", BlockHash: "0xb16", BlockNumber: "0x10"},
+			},
+		},
+	})
+
+	srv := httptest.NewServer(handler.routes())
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	require.NoError(t, conn.WriteJSON(wsRequest{Action: "subscribe", Address: "0x01"}))
+
+	var msg wsMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	require.Equal(t, "error", msg.Type)
+
+	require.NoError(t, conn.WriteJSON(wsRequest{Action: "subscribe", Address: address}))
+	msg = wsMessage{}
+	require.NoError(t, conn.ReadJSON(&msg))
+	require.Equal(t, wsMessage{Type: "subscribed", Address: address}, msg)
+	require.Equal(t, []string{address}, handler.parser.SubscribedAddresses())
+
+	// the poller fetching the block sends its transaction to the client
+	_, err = handler.parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	msg = wsMessage{}
+	require.NoError(t, conn.ReadJSON(&msg))
+	require.Equal(t, "transaction", msg.Type)
+	require.Equal(t, address, msg.Address)
+	require.Equal(t, "0x01", msg.Transaction.Hash)
+
+	require.NoError(t, conn.WriteJSON(wsRequest{Action: "unsubscribe", Address: address}))
+	msg = wsMessage{}
+	require.NoError(t, conn.ReadJSON(&msg))
+	require.Equal(t, wsMessage{Type: "unsubscribed", Address: address}, msg)
+
+	// the client closing cleanly gets the close acknowledged
+	require.NoError(t, conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")))
+	_, _, err = conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+}
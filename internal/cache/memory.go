@@ -1,36 +1,146 @@
 package cache
 
 import (
+	"container/list"
+	"slices"
 	"sync"
+	"time"
 
 	"ethparser/internal/models"
 )
 
+const (
+	// unlimitedAddresses disables eviction in memCache
+	unlimitedAddresses = 0
+	// noExpiry keeps the entries of memCache until evicted
+	noExpiry = 0
+	// unlimitedRetention keeps every transaction of an address in memCache
+	unlimitedRetention = 0
+)
+
+// Clock tells the time, so that the expiry of entries can be controlled.
+// The Clock of the parser satisfies it.
+type Clock interface {
+	Now() time.Time
+}
+
+// wallClock is the clock of the time package
+type wallClock struct{}
+
+func (wallClock) Now() time.Time {
+	return time.Now()
+}
+
 type Cache interface {
 	AddTransactions(address string, transactions []*models.Transaction, blockNumber int)
 	GetTransactions(address string) ([]*models.Transaction, int)
+	// Peek is GetTransactions without counting a hit or a miss, for the
+	// reads of the cache that aren't lookups, as snapshots
+	Peek(address string) ([]*models.Transaction, int)
+	ClearAddress(address string)
+	// Clear drops the transactions of every address
+	Clear()
+	// Rewind drops the transactions of an address mined after blockNumber,
+	// which then becomes the block number the address is cached up to
+	Rewind(address string, blockNumber int)
+	// Stats gets the size of the cache and how often it was hit
+	Stats() CacheStats
+}
+
+// CacheStats are the size of a cache and the GetTransactions hits and misses
+// since it was created
+type CacheStats struct {
+	Addresses    int   `json:"addresses"`
+	Transactions int   `json:"transactions"`
+	Hits         int64 `json:"hits"`
+	Misses       int64 `json:"misses"`
 }
 
 type block struct {
+	address     string
 	blockNumber int
 
 	// transactions is a list of transactions by hash
 	transactions map[string]*models.Transaction
+	// sorted holds the values of transactions in chain order, kept up to
+	// date as they change so that reads neither iterate the map nor sort
+	sorted []*models.Transaction
+	// updatedAt is when transactions were last added, the block expiring
+	// ttl after it
+	updatedAt time.Time
 }
 
 type memCache struct {
-	m sync.RWMutex
+	m sync.Mutex
 
+	// maxAddresses is the number of addresses tracked before the least
+	// recently accessed one is evicted
+	maxAddresses int
+	// recency lists blocks from the most to the least recently accessed
+	recency *list.List
 	// blockTransactions is a map of blocks by addresses
-	blockTransactions map[string]block
+	blockTransactions map[string]*list.Element
+
+	// ttl is how long the transactions of an address are kept after they
+	// were last added to
+	ttl   time.Duration
+	clock Clock
+	// sweptAt is when the expired blocks were last dropped
+	sweptAt time.Time
+
+	// retainBlocks is the number of blocks, up to the block an address is
+	// cached up to, the transactions of the address are kept for
+	retainBlocks int
+
+	hits   int64
+	misses int64
 }
 
 var _ Cache = &memCache{}
 
 func NewMemCache() Cache {
+	return NewMemCacheWithCapacity(unlimitedAddresses)
+}
+
+// NewMemCacheWithCapacity creates a memory cache tracking at most
+// maxAddresses addresses, evicting the least recently accessed one when full.
+// A maxAddresses of 0 or less tracks any number of addresses.
+func NewMemCacheWithCapacity(maxAddresses int) Cache {
+	return newMemCache(max(maxAddresses, unlimitedAddresses), noExpiry)
+}
+
+// NewMemCacheWithTTL creates a memory cache dropping the transactions of an
+// address ttl after they were last added to, an expired address being a
+// miss so that it is fetched again. The time is told by clock, the wall
+// clock when nil.
+func NewMemCacheWithTTL(ttl time.Duration, clock Clock) Cache {
+	mc := newMemCache(unlimitedAddresses, ttl)
+	if clock != nil {
+		mc.clock = clock
+	}
+	return mc
+}
+
+// NewMemCacheWithRetention creates a memory cache keeping, for each address,
+// only the transactions mined in the last blocks blocks up to the block it is
+// cached up to, the older ones being dropped as transactions are added.
+// The parser still counts the dropped blocks as scanned, so the older history
+// of an address is no longer listed; getting it back requires a rescan into a
+// cache retaining it.
+func NewMemCacheWithRetention(blocks int) Cache {
+	mc := newMemCache(unlimitedAddresses, noExpiry)
+	mc.retainBlocks = max(blocks, unlimitedRetention)
+	return mc
+}
+
+func newMemCache(maxAddresses int, ttl time.Duration) *memCache {
 	return &memCache{
-		blockTransactions: make(map[string]block),
-		m:                 sync.RWMutex{},
+		maxAddresses:      maxAddresses,
+		recency:           list.New(),
+		blockTransactions: make(map[string]*list.Element),
+		ttl:               ttl,
+		clock:             wallClock{},
+		m:                 sync.Mutex{},
 	}
 }
 
@@ -38,44 +148,234 @@ func (mc *memCache) AddTransactions(address string, transactions []*models.Trans
 	mc.m.Lock()
 	defer mc.m.Unlock()
 
-	b, ok := mc.blockTransactions[address]
-	if !ok {
-		txMap := make(map[string]*models.Transaction)
-		for _, tx := range transactions {
-			txMap[tx.Hash] = tx
-		}
+	mc.sweep()
 
-		mc.blockTransactions[address] = block{
+	now := mc.clock.Now()
+	el, ok := mc.blockTransactions[address]
+	if !ok {
+		b := &block{
+			address:      address,
 			blockNumber:  blockNumber,
-			transactions: txMap,
+			transactions: make(map[string]*models.Transaction, len(transactions)),
+			updatedAt:    now,
 		}
+		b.put(transactions)
+		mc.retain(b)
+		mc.blockTransactions[address] = mc.recency.PushFront(b)
+		mc.evict()
 		return
 	}
 
-	if b.blockNumber == blockNumber {
+	mc.recency.MoveToFront(el)
+
+	b := el.Value.(*block)
+	b.put(transactions)
+	b.blockNumber = blockNumber
+	b.updatedAt = now
+	mc.retain(b)
+}
+
+func (mc *memCache) GetTransactions(address string) ([]*models.Transaction, int) {
+	mc.m.Lock()
+	defer mc.m.Unlock()
+
+	el, ok := mc.blockTransactions[address]
+	if ok && mc.expired(el.Value.(*block)) {
+		mc.remove(el)
+		ok = false
+	}
+	if !ok {
+		mc.misses++
+		return nil, 0
+	}
+	mc.hits++
+
+	mc.recency.MoveToFront(el)
+
+	b := el.Value.(*block)
+	return b.copies(), b.blockNumber
+}
+
+// Peek doesn't count as an access either, leaving the address where it is
+// in the eviction order
+func (mc *memCache) Peek(address string) ([]*models.Transaction, int) {
+	mc.m.Lock()
+	defer mc.m.Unlock()
+
+	el, ok := mc.blockTransactions[address]
+	if !ok || mc.expired(el.Value.(*block)) {
+		return nil, 0
+	}
+
+	b := el.Value.(*block)
+	return b.copies(), b.blockNumber
+}
+
+func (mc *memCache) ClearAddress(address string) {
+	mc.m.Lock()
+	defer mc.m.Unlock()
+
+	el, ok := mc.blockTransactions[address]
+	if !ok {
+		return
+	}
+
+	mc.remove(el)
+}
+
+func (mc *memCache) Clear() {
+	mc.m.Lock()
+	defer mc.m.Unlock()
+
+	mc.recency.Init()
+	clear(mc.blockTransactions)
+}
+
+func (mc *memCache) Rewind(address string, blockNumber int) {
+	mc.m.Lock()
+	defer mc.m.Unlock()
+
+	el, ok := mc.blockTransactions[address]
+	if !ok {
 		return
 	}
 
+	b := el.Value.(*block)
+	b.drop(func(tx *models.Transaction) bool {
+		return txBlockNumber(tx) > blockNumber
+	})
+
+	b.blockNumber = min(b.blockNumber, blockNumber)
+}
+
+func (mc *memCache) Stats() CacheStats {
+	mc.m.Lock()
+	defer mc.m.Unlock()
+
+	mc.sweep()
+
+	stats := CacheStats{
+		Addresses: len(mc.blockTransactions),
+		Hits:      mc.hits,
+		Misses:    mc.misses,
+	}
+	for _, el := range mc.blockTransactions {
+		stats.Transactions += len(el.Value.(*block).transactions)
+	}
+
+	return stats
+}
+
+// copies lists copies of the transactions of b in chain order, so modifying
+// them leaves the cache unchanged, allocated at once rather than one by one
+func (b *block) copies() []*models.Transaction {
+	copies := make([]models.Transaction, len(b.sorted))
+	transactions := make([]*models.Transaction, len(b.sorted))
+	for i, tx := range b.sorted {
+		copies[i] = *tx
+		transactions[i] = &copies[i]
+	}
+
+	return transactions
+}
+
+// put adds copies of transactions to b, replacing the ones with the same
+// hash. A replaced transaction still at the same position in chain order is
+// updated where it is, the others are inserted into b.sorted at their
+// position, so that adding already cached transactions doesn't sort again.
+func (b *block) put(transactions []*models.Transaction) {
 	for _, tx := range transactions {
-		b.transactions[tx.Hash] = tx
+		cached, ok := b.transactions[tx.Hash]
+		if ok && models.CompareTransactions(cached, tx) == 0 {
+			*cached = *tx
+			continue
+		}
+
+		// a reorg moved the transaction
+		if ok {
+			if i, found := slices.BinarySearchFunc(b.sorted, cached, models.CompareTransactions); found {
+				b.sorted = slices.Delete(b.sorted, i, i+1)
+			}
+		}
+
+		txCopy := *tx
+		b.transactions[tx.Hash] = &txCopy
+		i, _ := slices.BinarySearchFunc(b.sorted, &txCopy, models.CompareTransactions)
+		b.sorted = slices.Insert(b.sorted, i, &txCopy)
 	}
+}
 
-	b.blockNumber = blockNumber
+// drop removes the transactions of b matching del, keeping b.sorted in order
+func (b *block) drop(del func(tx *models.Transaction) bool) {
+	b.sorted = slices.DeleteFunc(b.sorted, func(tx *models.Transaction) bool {
+		if del(tx) {
+			delete(b.transactions, tx.Hash)
+			return true
+		}
+		return false
+	})
 }
 
-func (mc *memCache) GetTransactions(address string) ([]*models.Transaction, int) {
-	mc.m.RLock()
-	defer mc.m.RUnlock()
+// evict drops the least recently accessed addresses above maxAddresses
+func (mc *memCache) evict() {
+	if mc.maxAddresses == unlimitedAddresses {
+		return
+	}
 
-	b, ok := mc.blockTransactions[address]
-	if !ok {
-		return nil, 0
+	for mc.recency.Len() > mc.maxAddresses {
+		mc.remove(mc.recency.Back())
+	}
+}
+
+// retain drops the transactions of b mined more than retainBlocks blocks
+// before the block it is cached up to
+func (mc *memCache) retain(b *block) {
+	if mc.retainBlocks == unlimitedRetention {
+		return
+	}
+
+	b.drop(func(tx *models.Transaction) bool {
+		return txBlockNumber(tx) <= b.blockNumber-mc.retainBlocks
+	})
+}
+
+// expired reports whether b was last added to more than ttl ago
+func (mc *memCache) expired(b *block) bool {
+	return mc.ttl != noExpiry && mc.clock.Now().Sub(b.updatedAt) >= mc.ttl
+}
+
+// sweep drops the expired blocks, at most once per ttl so that adding
+// transactions doesn't walk every address each time
+func (mc *memCache) sweep() {
+	if mc.ttl == noExpiry {
+		return
+	}
+
+	now := mc.clock.Now()
+	if now.Sub(mc.sweptAt) < mc.ttl {
+		return
+	}
+	mc.sweptAt = now
+
+	for _, el := range mc.blockTransactions {
+		if mc.expired(el.Value.(*block)) {
+			mc.remove(el)
+		}
 	}
+}
+
+// remove drops the block of el
+func (mc *memCache) remove(el *list.Element) {
+	mc.recency.Remove(el)
+	delete(mc.blockTransactions, el.Value.(*block).address)
+}
 
-	transactions := make([]*models.Transaction, 0, len(b.transactions))
-	for _, tx := range b.transactions {
-		transactions = append(transactions, tx)
+// txBlockNumber parses the hex block number of a transaction
+func txBlockNumber(tx *models.Transaction) int {
+	blockNumber, err := models.ParseHexInt(tx.BlockNumber)
+	if err != nil {
+		return 0
 	}
 
-	return transactions, b.blockNumber
+	return blockNumber
 }
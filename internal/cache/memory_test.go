@@ -0,0 +1,360 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestMemCacheAddTransactions(t *testing.T) {
+	c := NewMemCache()
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}}, 10)
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}, {Hash: "0xb"}}, 11)
+
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Len(t, txs, 2)
+	require.Equal(t, 11, blockNumber)
+
+	txs, blockNumber = c.GetTransactions("0x02")
+	require.Nil(t, txs)
+	require.Zero(t, blockNumber)
+}
+
+func TestMemCacheGetTransactionsCopies(t *testing.T) {
+	c := NewMemCache()
+
+	added := &models.Transaction{Hash: "0xa", Value: "0x1"}
+	c.AddTransactions("0x01", []*models.Transaction{added}, 10)
+	added.Value = "0x2"
+
+	txs, _ := c.GetTransactions("0x01")
+	require.Equal(t, "0x1", txs[0].Value)
+	txs[0].Value = "0x3"
+
+	txs, _ = c.GetTransactions("0x01")
+	require.Equal(t, "0x1", txs[0].Value)
+}
+
+func TestMemCacheGetTransactionsSorted(t *testing.T) {
+	c := NewMemCache()
+
+	// "0x9" sorts after "0x10" as a string
+	c.AddTransactions("0x01", []*models.Transaction{
+		{Hash: "0xd", BlockNumber: "0x10", TransactionIndex: "0x1"},
+		{Hash: "0xc", BlockNumber: "0x10", TransactionIndex: "0x0"},
+		{Hash: "0xb", BlockNumber: "0x9"},
+		{Hash: "0xa", BlockNumber: "0x11"},
+	}, 17)
+
+	for i := 0; i < 10; i++ {
+		txs, _ := c.GetTransactions("0x01")
+
+		hashes := make([]string, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash)
+		}
+		require.Equal(t, []string{"0xb", "0xc", "0xd", "0xa"}, hashes)
+	}
+}
+
+func TestMemCacheGetTransactionsSortedAfterUpdates(t *testing.T) {
+	c := NewMemCache()
+
+	c.AddTransactions("0x01", []*models.Transaction{
+		{Hash: "0xa", BlockNumber: "0x10"},
+		{Hash: "0xb", BlockNumber: "0x12"},
+	}, 18)
+	// an older transaction added later, and one moved by a reorg
+	c.AddTransactions("0x01", []*models.Transaction{
+		{Hash: "0xc", BlockNumber: "0x11"},
+		{Hash: "0xa", BlockNumber: "0x13"},
+	}, 19)
+
+	txs, _ := c.GetTransactions("0x01")
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	require.Equal(t, []string{"0xc", "0xb", "0xa"}, hashes)
+	require.Equal(t, "0x13", txs[2].BlockNumber)
+
+	c.Rewind("0x01", 0x12)
+	txs, _ = c.GetTransactions("0x01")
+	require.Len(t, txs, 2)
+	require.Equal(t, "0xc", txs[0].Hash)
+	require.Equal(t, "0xb", txs[1].Hash)
+	require.Equal(t, 2, c.Stats().Transactions)
+}
+
+func TestMemCacheCapacityEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewMemCacheWithCapacity(2)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}}, 1)
+	c.AddTransactions("0x02", []*models.Transaction{{Hash: "0xb"}}, 1)
+
+	// reading 0x01 makes 0x02 the least recently used
+	_, blockNumber := c.GetTransactions("0x01")
+	require.Equal(t, 1, blockNumber)
+
+	c.AddTransactions("0x03", []*models.Transaction{{Hash: "0xc"}}, 1)
+
+	_, blockNumber = c.GetTransactions("0x02")
+	require.Zero(t, blockNumber)
+
+	_, blockNumber = c.GetTransactions("0x01")
+	require.Equal(t, 1, blockNumber)
+
+	// updating 0x03 makes 0x01 the least recently used
+	c.AddTransactions("0x03", []*models.Transaction{{Hash: "0xd"}}, 2)
+	c.AddTransactions("0x04", []*models.Transaction{{Hash: "0xe"}}, 1)
+
+	_, blockNumber = c.GetTransactions("0x01")
+	require.Zero(t, blockNumber)
+
+	txs, blockNumber := c.GetTransactions("0x03")
+	require.Equal(t, 2, blockNumber)
+	require.Len(t, txs, 2)
+}
+
+func TestMemCacheNegativeCapacityIsUnlimited(t *testing.T) {
+	c := NewMemCacheWithCapacity(-1)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}}, 1)
+	c.AddTransactions("0x02", []*models.Transaction{{Hash: "0xb"}}, 1)
+
+	_, blockNumber := c.GetTransactions("0x01")
+	require.Equal(t, 1, blockNumber)
+	require.Equal(t, 2, c.Stats().Addresses)
+}
+
+func TestMemCacheClearAddress(t *testing.T) {
+	c := NewMemCacheWithCapacity(1)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}}, 1)
+	c.ClearAddress("0x01")
+	c.ClearAddress("0x02")
+
+	_, blockNumber := c.GetTransactions("0x01")
+	require.Zero(t, blockNumber)
+
+	c.AddTransactions("0x02", []*models.Transaction{{Hash: "0xb"}}, 1)
+	_, blockNumber = c.GetTransactions("0x02")
+	require.Equal(t, 1, blockNumber)
+}
+
+func TestMemCacheClear(t *testing.T) {
+	c := NewMemCacheWithCapacity(2)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}}, 10)
+	c.AddTransactions("0x02", []*models.Transaction{{Hash: "0xb"}}, 10)
+	c.Clear()
+
+	for _, address := range []string{"0x01", "0x02"} {
+		txs, blockNumber := c.GetTransactions(address)
+		require.Nil(t, txs)
+		require.Zero(t, blockNumber)
+	}
+
+	// the cache is usable again up to its capacity
+	c.AddTransactions("0x03", []*models.Transaction{{Hash: "0xc"}}, 11)
+	c.AddTransactions("0x04", []*models.Transaction{{Hash: "0xd"}}, 11)
+	_, blockNumber := c.GetTransactions("0x03")
+	require.Equal(t, 11, blockNumber)
+}
+
+func TestMemCacheStats(t *testing.T) {
+	c := NewMemCache()
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}, {Hash: "0xb"}}, 10)
+	c.AddTransactions("0x02", []*models.Transaction{{Hash: "0xc"}}, 10)
+	c.GetTransactions("0x01")
+	c.GetTransactions("0x01")
+	c.GetTransactions("0x03")
+
+	require.Equal(t, CacheStats{Addresses: 2, Transactions: 3, Hits: 2, Misses: 1}, c.Stats())
+
+	// peeking is not counted
+	txs, blockNumber := c.Peek("0x01")
+	require.Len(t, txs, 2)
+	require.Equal(t, 10, blockNumber)
+	txs, blockNumber = c.Peek("0x03")
+	require.Nil(t, txs)
+	require.Zero(t, blockNumber)
+
+	require.Equal(t, CacheStats{Addresses: 2, Transactions: 3, Hits: 2, Misses: 1}, c.Stats())
+}
+
+func TestMemCacheRewind(t *testing.T) {
+	c := NewMemCache()
+
+	c.AddTransactions("0x01", []*models.Transaction{
+		{Hash: "0xa", BlockNumber: "0x9"},
+		{Hash: "0xb", BlockNumber: "0xa"},
+		{Hash: "0xc", BlockNumber: "0xb"},
+	}, 12)
+	c.Rewind("0x01", 10)
+	c.Rewind("0x02", 10)
+
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Equal(t, 10, blockNumber)
+	require.ElementsMatch(t, []string{"0xa", "0xb"}, []string{txs[0].Hash, txs[1].Hash})
+}
+
+func TestMemCacheRetention(t *testing.T) {
+	c := NewMemCacheWithRetention(10)
+
+	c.AddTransactions("0x01", []*models.Transaction{
+		{Hash: "0xa", BlockNumber: "0x5"},
+		{Hash: "0xb", BlockNumber: "0xa"},
+	}, 10)
+	txs, _ := c.GetTransactions("0x01")
+	require.Len(t, txs, 2)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xc", BlockNumber: "0x12"}}, 18)
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Equal(t, 18, blockNumber)
+	require.Equal(t, []string{"0xb", "0xc"}, []string{txs[0].Hash, txs[1].Hash})
+
+	// the window moves with the block the address is cached up to
+	c.AddTransactions("0x01", nil, 25)
+	txs, _ = c.GetTransactions("0x01")
+	require.Len(t, txs, 1)
+	require.Equal(t, "0xc", txs[0].Hash)
+
+	// transactions older than the window are dropped as they are added
+	c.AddTransactions("0x02", []*models.Transaction{
+		{Hash: "0xd", BlockNumber: "0x1"},
+		{Hash: "0xe", BlockNumber: "0x19"},
+	}, 25)
+	txs, _ = c.GetTransactions("0x02")
+	require.Len(t, txs, 1)
+	require.Equal(t, "0xe", txs[0].Hash)
+
+	require.Equal(t, 2, c.Stats().Transactions)
+}
+
+// fakeClock is a clock only moving when told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (fc *fakeClock) Now() time.Time {
+	return fc.now
+}
+
+func TestMemCacheTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	c := NewMemCacheWithTTL(time.Minute, clock)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}}, 10)
+	c.AddTransactions("0x02", []*models.Transaction{{Hash: "0xb"}}, 10)
+
+	clock.now = clock.now.Add(40 * time.Second)
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Len(t, txs, 1)
+	require.Equal(t, 10, blockNumber)
+
+	// adding transactions keeps the entry another ttl
+	c.AddTransactions("0x01", nil, 11)
+
+	clock.now = clock.now.Add(40 * time.Second)
+	txs, blockNumber = c.GetTransactions("0x02")
+	require.Nil(t, txs)
+	require.Zero(t, blockNumber)
+
+	_, blockNumber = c.GetTransactions("0x01")
+	require.Equal(t, 11, blockNumber)
+
+	clock.now = clock.now.Add(time.Minute)
+	_, blockNumber = c.GetTransactions("0x01")
+	require.Zero(t, blockNumber)
+
+	stats := c.Stats()
+	require.Zero(t, stats.Addresses)
+	require.Equal(t, int64(2), stats.Misses)
+}
+
+func TestMemCacheTTLSweepsUnreadAddresses(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	c := NewMemCacheWithTTL(time.Minute, clock)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}}, 10)
+
+	// 0x01 is never read again, yet dropped once expired
+	clock.now = clock.now.Add(2 * time.Minute)
+	c.AddTransactions("0x02", []*models.Transaction{{Hash: "0xb"}}, 10)
+
+	require.Equal(t, 1, c.Stats().Addresses)
+}
+
+// getTransactionsByMapIteration lists the transactions of b the way memCache
+// did before keeping them sorted, for BenchmarkMemCacheGetTransactions
+func getTransactionsByMapIteration(b *block) []*models.Transaction {
+	transactions := make([]*models.Transaction, 0, len(b.transactions))
+	for _, tx := range b.transactions {
+		txCopy := *tx
+		transactions = append(transactions, &txCopy)
+	}
+	models.SortTransactions(transactions)
+
+	return transactions
+}
+
+func BenchmarkMemCacheGetTransactions(b *testing.B) {
+	for _, n := range []int{1000, 5000} {
+		c := NewMemCache().(*memCache)
+		transactions := make([]*models.Transaction, n)
+		for i := range transactions {
+			transactions[i] = &models.Transaction{
+				Hash:             fmt.Sprintf("0x%064x", i),
+				BlockNumber:      fmt.Sprintf("0x%x", i/10),
+				TransactionIndex: fmt.Sprintf("0x%x", i%10),
+			}
+		}
+		c.AddTransactions("0x01", transactions, n/10)
+		blk := c.blockTransactions["0x01"].Value.(*block)
+
+		b.Run(fmt.Sprintf("map iteration/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				getTransactionsByMapIteration(blk)
+			}
+		})
+
+		b.Run(fmt.Sprintf("maintained slice/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				c.GetTransactions("0x01")
+			}
+		})
+	}
+}
+
+// BenchmarkMemCacheAddCachedTransactions adds transactions already cached,
+// as every refresh of an address does
+func BenchmarkMemCacheAddCachedTransactions(b *testing.B) {
+	for _, n := range []int{1000, 5000} {
+		c := NewMemCache()
+		transactions := make([]*models.Transaction, n)
+		for i := range transactions {
+			transactions[i] = &models.Transaction{
+				Hash:             fmt.Sprintf("0x%064x", i),
+				BlockNumber:      fmt.Sprintf("0x%x", i/10),
+				TransactionIndex: fmt.Sprintf("0x%x", i%10),
+			}
+		}
+		c.AddTransactions("0x01", transactions, n/10)
+
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				c.AddTransactions("0x01", transactions, n/10)
+			}
+		})
+	}
+}
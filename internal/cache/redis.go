@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+
+	"ethparser/internal/models"
+)
+
+type redisCache struct {
+	client *redis.Client
+
+	// keyPrefix namespaces the keys written by this cache
+	keyPrefix string
+
+	// hits and misses are counted by this process only
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+var _ Cache = &redisCache{}
+
+// NewRedisCache creates a cache storing, for each address, a hash of
+// transactions by transaction hash and the block number they are up to
+func NewRedisCache(client *redis.Client, keyPrefix string) Cache {
+	return &redisCache{
+		client:    client,
+		keyPrefix: keyPrefix,
+	}
+}
+
+func (rc *redisCache) AddTransactions(address string, transactions []*models.Transaction, blockNumber int) {
+	ctx := context.Background()
+
+	txFields := make(map[string]interface{}, len(transactions))
+	for _, tx := range transactions {
+		txJson, err := json.Marshal(tx)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		txFields[tx.Hash] = txJson
+	}
+
+	_, err := rc.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(txFields) > 0 {
+			pipe.HSet(ctx, rc.transactionsKey(address), txFields)
+		}
+		pipe.Set(ctx, rc.blockNumberKey(address), blockNumber, 0)
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (rc *redisCache) GetTransactions(address string) ([]*models.Transaction, int) {
+	return rc.getTransactions(address, true)
+}
+
+func (rc *redisCache) Peek(address string) ([]*models.Transaction, int) {
+	return rc.getTransactions(address, false)
+}
+
+// getTransactions is GetTransactions, counting the hit or miss when count is
+// set
+func (rc *redisCache) getTransactions(address string, count bool) ([]*models.Transaction, int) {
+	ctx := context.Background()
+
+	blockNumber, err := rc.client.Get(ctx, rc.blockNumberKey(address)).Int()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			if count {
+				rc.misses.Add(1)
+			}
+		} else {
+			log.Println(err)
+		}
+		return nil, 0
+	}
+	if count {
+		rc.hits.Add(1)
+	}
+
+	txFields, err := rc.client.HGetAll(ctx, rc.transactionsKey(address)).Result()
+	if err != nil {
+		log.Println(err)
+		return nil, 0
+	}
+
+	transactions := make([]*models.Transaction, 0, len(txFields))
+	for _, txJson := range txFields {
+		var tx models.Transaction
+		if err := json.Unmarshal([]byte(txJson), &tx); err != nil {
+			log.Println(err)
+			return nil, 0
+		}
+		transactions = append(transactions, &tx)
+	}
+	models.SortTransactions(transactions)
+
+	return transactions, blockNumber
+}
+
+func (rc *redisCache) ClearAddress(address string) {
+	err := rc.client.Del(context.Background(), rc.blockNumberKey(address), rc.transactionsKey(address)).Err()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Clear deletes every key under the prefix of the cache
+func (rc *redisCache) Clear() {
+	ctx := context.Background()
+
+	iter := rc.client.Scan(ctx, 0, rc.keyPrefix+"*", 0).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		log.Println(err)
+		return
+	}
+
+	if len(keys) == 0 {
+		return
+	}
+
+	if err := rc.client.Del(ctx, keys...).Err(); err != nil {
+		log.Println(err)
+	}
+}
+
+func (rc *redisCache) Rewind(address string, blockNumber int) {
+	ctx := context.Background()
+
+	storedBlockNumber, err := rc.client.Get(ctx, rc.blockNumberKey(address)).Int()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Println(err)
+		}
+		return
+	}
+
+	txFields, err := rc.client.HGetAll(ctx, rc.transactionsKey(address)).Result()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	var staleHashes []string
+	for hash, txJson := range txFields {
+		var tx models.Transaction
+		if err := json.Unmarshal([]byte(txJson), &tx); err != nil {
+			log.Println(err)
+			return
+		}
+
+		if txBlockNumber(&tx) > blockNumber {
+			staleHashes = append(staleHashes, hash)
+		}
+	}
+
+	_, err = rc.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(staleHashes) > 0 {
+			pipe.HDel(ctx, rc.transactionsKey(address), staleHashes...)
+		}
+		pipe.Set(ctx, rc.blockNumberKey(address), min(storedBlockNumber, blockNumber), 0)
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Stats counts the addresses and transactions stored under the prefix of
+// the cache
+func (rc *redisCache) Stats() CacheStats {
+	ctx := context.Background()
+
+	stats := CacheStats{
+		Hits:   rc.hits.Load(),
+		Misses: rc.misses.Load(),
+	}
+
+	iter := rc.client.Scan(ctx, 0, rc.blockNumberKey("*"), 0).Iterator()
+	for iter.Next(ctx) {
+		address := strings.TrimSuffix(strings.TrimPrefix(iter.Val(), rc.keyPrefix), ":blockNumber")
+
+		n, err := rc.client.HLen(ctx, rc.transactionsKey(address)).Result()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		stats.Addresses++
+		stats.Transactions += int(n)
+	}
+	if err := iter.Err(); err != nil {
+		log.Println(err)
+	}
+
+	return stats
+}
+
+// blockNumberKey is the key of the block number an address is cached up to
+func (rc *redisCache) blockNumberKey(address string) string {
+	return rc.keyPrefix + address + ":blockNumber"
+}
+
+// transactionsKey is the key of the hash of an address transactions
+func (rc *redisCache) transactionsKey(address string) string {
+	return rc.keyPrefix + address + ":transactions"
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func newTestRedisCache(t *testing.T) (Cache, *miniredis.Miniredis) {
+	t.Helper()
+
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisCache(client, "ethparser:"), srv
+}
+
+func TestRedisCacheAddTransactions(t *testing.T) {
+	c, srv := newTestRedisCache(t)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa", Value: "0x1"}}, 10)
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa", Value: "0x1"}, {Hash: "0xb"}}, 11)
+
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Len(t, txs, 2)
+	require.Equal(t, 11, blockNumber)
+	require.ElementsMatch(t, []string{"0xa", "0xb"}, []string{txs[0].Hash, txs[1].Hash})
+
+	require.True(t, srv.Exists("ethparser:0x01:blockNumber"))
+	require.True(t, srv.Exists("ethparser:0x01:transactions"))
+}
+
+func TestRedisCacheMissingKey(t *testing.T) {
+	c, _ := newTestRedisCache(t)
+
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Nil(t, txs)
+	require.Zero(t, blockNumber)
+}
+
+func TestRedisCacheClearAddress(t *testing.T) {
+	c, srv := newTestRedisCache(t)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}}, 10)
+	c.ClearAddress("0x01")
+
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Nil(t, txs)
+	require.Zero(t, blockNumber)
+	require.Empty(t, srv.Keys())
+}
+
+func TestRedisCacheClear(t *testing.T) {
+	c, srv := newTestRedisCache(t)
+	srv.Set("other:key", "kept")
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}}, 10)
+	c.AddTransactions("0x02", []*models.Transaction{{Hash: "0xb"}}, 10)
+	c.Clear()
+
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Nil(t, txs)
+	require.Zero(t, blockNumber)
+	require.Equal(t, []string{"other:key"}, srv.Keys())
+}
+
+func TestRedisCacheStats(t *testing.T) {
+	c, _ := newTestRedisCache(t)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}, {Hash: "0xb"}}, 10)
+	c.AddTransactions("0x02", []*models.Transaction{{Hash: "0xc"}}, 10)
+	c.GetTransactions("0x01")
+	c.GetTransactions("0x01")
+	c.GetTransactions("0x03")
+
+	require.Equal(t, CacheStats{Addresses: 2, Transactions: 3, Hits: 2, Misses: 1}, c.Stats())
+}
+
+func TestRedisCacheRewind(t *testing.T) {
+	c, _ := newTestRedisCache(t)
+
+	c.AddTransactions("0x01", []*models.Transaction{
+		{Hash: "0xa", BlockNumber: "0x9"},
+		{Hash: "0xb", BlockNumber: "0xa"},
+		{Hash: "0xc", BlockNumber: "0xb"},
+	}, 12)
+	c.Rewind("0x01", 10)
+	c.Rewind("0x02", 10)
+
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Equal(t, 10, blockNumber)
+	require.ElementsMatch(t, []string{"0xa", "0xb"}, []string{txs[0].Hash, txs[1].Hash})
+
+	_, blockNumber = c.GetTransactions("0x02")
+	require.Zero(t, blockNumber)
+}
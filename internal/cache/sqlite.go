@@ -0,0 +1,254 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	_ "modernc.org/sqlite"
+
+	"ethparser/internal/models"
+)
+
+// sqliteMigrations are the schema changes applied in order when opening the
+// database, the number applied being kept in its user_version
+var sqliteMigrations = []string{
+	`CREATE TABLE transactions (
+		address      TEXT    NOT NULL,
+		hash         TEXT    NOT NULL,
+		block_number INTEGER NOT NULL,
+		data         TEXT    NOT NULL,
+		PRIMARY KEY (address, hash)
+	);
+	CREATE TABLE blocks (
+		address      TEXT    PRIMARY KEY,
+		block_number INTEGER NOT NULL
+	);`,
+}
+
+type sqliteCache struct {
+	db *sql.DB
+
+	// hits and misses are counted by this process only
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+var _ Cache = &sqliteCache{}
+
+// NewSQLiteCache opens, creating it if needed, a SQLite database at path
+// storing the transactions of each address along with the block number
+// they are up to, so they survive restarts
+func NewSQLiteCache(path string) (Cache, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// writes are serialized by SQLite anyway, a single connection avoids
+	// failing on a locked database
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(context.Background(), db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqliteCache{db: db}, nil
+}
+
+// migrate applies the migrations not applied to db yet
+func migrate(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for ; version < len(sqliteMigrations); version++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, sqliteMigrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close closes the database
+func (sc *sqliteCache) Close() error {
+	return sc.db.Close()
+}
+
+func (sc *sqliteCache) AddTransactions(address string, transactions []*models.Transaction, blockNumber int) {
+	err := sc.inTx(func(tx *sql.Tx) error {
+		for _, transaction := range transactions {
+			txJson, err := json.Marshal(transaction)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(`INSERT INTO transactions (address, hash, block_number, data) VALUES (?, ?, ?, ?)
+				ON CONFLICT (address, hash) DO UPDATE SET block_number = excluded.block_number, data = excluded.data`,
+				address, transaction.Hash, txBlockNumber(transaction), txJson)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec(`INSERT INTO blocks (address, block_number) VALUES (?, ?)
+			ON CONFLICT (address) DO UPDATE SET block_number = excluded.block_number`,
+			address, blockNumber)
+		return err
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (sc *sqliteCache) GetTransactions(address string) ([]*models.Transaction, int) {
+	return sc.getTransactions(address, true)
+}
+
+func (sc *sqliteCache) Peek(address string) ([]*models.Transaction, int) {
+	return sc.getTransactions(address, false)
+}
+
+// getTransactions is GetTransactions, counting the hit or miss when count is
+// set
+func (sc *sqliteCache) getTransactions(address string, count bool) ([]*models.Transaction, int) {
+	var blockNumber int
+	err := sc.db.QueryRow("SELECT block_number FROM blocks WHERE address = ?", address).Scan(&blockNumber)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if count {
+				sc.misses.Add(1)
+			}
+		} else {
+			log.Println(err)
+		}
+		return nil, 0
+	}
+	if count {
+		sc.hits.Add(1)
+	}
+
+	rows, err := sc.db.Query("SELECT data FROM transactions WHERE address = ?", address)
+	if err != nil {
+		log.Println(err)
+		return nil, 0
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		var txJson []byte
+		if err := rows.Scan(&txJson); err != nil {
+			log.Println(err)
+			return nil, 0
+		}
+
+		var tx models.Transaction
+		if err := json.Unmarshal(txJson, &tx); err != nil {
+			log.Println(err)
+			return nil, 0
+		}
+		transactions = append(transactions, &tx)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println(err)
+		return nil, 0
+	}
+	models.SortTransactions(transactions)
+
+	return transactions, blockNumber
+}
+
+func (sc *sqliteCache) ClearAddress(address string) {
+	err := sc.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM transactions WHERE address = ?", address); err != nil {
+			return err
+		}
+		_, err := tx.Exec("DELETE FROM blocks WHERE address = ?", address)
+		return err
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (sc *sqliteCache) Clear() {
+	err := sc.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM transactions"); err != nil {
+			return err
+		}
+		_, err := tx.Exec("DELETE FROM blocks")
+		return err
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (sc *sqliteCache) Rewind(address string, blockNumber int) {
+	err := sc.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec("DELETE FROM transactions WHERE address = ? AND block_number > ?", address, blockNumber)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE blocks SET block_number = MIN(block_number, ?) WHERE address = ?", blockNumber, address)
+		return err
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Stats counts the addresses and transactions stored in the database
+func (sc *sqliteCache) Stats() CacheStats {
+	stats := CacheStats{
+		Hits:   sc.hits.Load(),
+		Misses: sc.misses.Load(),
+	}
+
+	err := sc.db.QueryRow(`SELECT
+		(SELECT COUNT(*) FROM blocks),
+		(SELECT COUNT(*) FROM transactions WHERE address IN (SELECT address FROM blocks))`,
+	).Scan(&stats.Addresses, &stats.Transactions)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return stats
+}
+
+// inTx runs f in a database transaction, committed when f succeeds
+func (sc *sqliteCache) inTx(f func(tx *sql.Tx) error) error {
+	tx, err := sc.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
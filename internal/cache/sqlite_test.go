@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"database/sql"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func newTestSQLiteCache(t *testing.T, path string) Cache {
+	t.Helper()
+
+	c, err := NewSQLiteCache(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.(io.Closer).Close() })
+
+	return c
+}
+
+func TestSQLiteCacheAddTransactions(t *testing.T) {
+	c := newTestSQLiteCache(t, filepath.Join(t.TempDir(), "cache.db"))
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa", Value: "0x1", BlockNumber: "0xa"}}, 10)
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa", Value: "0x2", BlockNumber: "0xa"}, {Hash: "0xb", BlockNumber: "0xb"}}, 11)
+
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Equal(t, 11, blockNumber)
+	require.Len(t, txs, 2)
+	require.Equal(t, "0xa", txs[0].Hash)
+	require.Equal(t, "0x2", txs[0].Value)
+	require.Equal(t, "0xb", txs[1].Hash)
+
+	txs, blockNumber = c.GetTransactions("0x02")
+	require.Nil(t, txs)
+	require.Zero(t, blockNumber)
+
+	require.Equal(t, CacheStats{Addresses: 1, Transactions: 2, Hits: 1, Misses: 1}, c.Stats())
+
+	// peeking is not counted
+	txs, _ = c.Peek("0x01")
+	require.Len(t, txs, 2)
+	c.Peek("0x02")
+	require.Equal(t, CacheStats{Addresses: 1, Transactions: 2, Hits: 1, Misses: 1}, c.Stats())
+}
+
+func TestSQLiteCacheSurvivesReopening(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	c := newTestSQLiteCache(t, path)
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa", BlockNumber: "0xa"}}, 10)
+	require.NoError(t, c.(io.Closer).Close())
+
+	c = newTestSQLiteCache(t, path)
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Len(t, txs, 1)
+	require.Equal(t, 10, blockNumber)
+}
+
+func TestSQLiteCacheMigrates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	newTestSQLiteCache(t, path)
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var version int
+	require.NoError(t, db.QueryRow("PRAGMA user_version").Scan(&version))
+	require.Equal(t, len(sqliteMigrations), version)
+}
+
+func TestSQLiteCacheClearAddress(t *testing.T) {
+	c := newTestSQLiteCache(t, filepath.Join(t.TempDir(), "cache.db"))
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa"}}, 10)
+	c.AddTransactions("0x02", []*models.Transaction{{Hash: "0xb"}}, 10)
+	c.ClearAddress("0x01")
+
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Nil(t, txs)
+	require.Zero(t, blockNumber)
+
+	txs, _ = c.GetTransactions("0x02")
+	require.Len(t, txs, 1)
+
+	c.Clear()
+	txs, _ = c.GetTransactions("0x02")
+	require.Nil(t, txs)
+}
+
+func TestSQLiteCacheRewind(t *testing.T) {
+	c := newTestSQLiteCache(t, filepath.Join(t.TempDir(), "cache.db"))
+
+	c.AddTransactions("0x01", []*models.Transaction{
+		{Hash: "0xa", BlockNumber: "0xa"},
+		{Hash: "0xc", BlockNumber: "0xc"},
+	}, 12)
+	c.Rewind("0x01", 11)
+
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Len(t, txs, 1)
+	require.Equal(t, "0xa", txs[0].Hash)
+	require.Equal(t, 11, blockNumber)
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+
+	"ethparser/internal/models"
+)
+
+// tieredCache keeps a fast cache, usually in memory, in front of a
+// persistent one, reading through the front and writing through to both
+type tieredCache struct {
+	front Cache
+	back  Cache
+
+	// hits and misses count the addresses found in either tier or in neither
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+var _ Cache = &tieredCache{}
+
+// NewTieredCache creates a cache reading from front first and falling back
+// to back, an address found in back only being copied to front. Transactions
+// are added to both, so that back holds every address even once front
+// evicted it.
+func NewTieredCache(front, back Cache) Cache {
+	return &tieredCache{
+		front: front,
+		back:  back,
+	}
+}
+
+func (tc *tieredCache) AddTransactions(address string, transactions []*models.Transaction, blockNumber int) {
+	tc.back.AddTransactions(address, transactions, blockNumber)
+	tc.front.AddTransactions(address, transactions, blockNumber)
+}
+
+func (tc *tieredCache) GetTransactions(address string) ([]*models.Transaction, int) {
+	transactions, blockNumber := tc.front.GetTransactions(address)
+	if !missed(transactions, blockNumber) {
+		tc.hits.Add(1)
+		return transactions, blockNumber
+	}
+
+	transactions, blockNumber = tc.back.GetTransactions(address)
+	if missed(transactions, blockNumber) {
+		tc.misses.Add(1)
+		return nil, 0
+	}
+	tc.hits.Add(1)
+
+	tc.front.AddTransactions(address, transactions, blockNumber)
+	return transactions, blockNumber
+}
+
+// Peek reads front then back, without filling front with what is only
+// found in back
+func (tc *tieredCache) Peek(address string) ([]*models.Transaction, int) {
+	transactions, blockNumber := tc.front.Peek(address)
+	if !missed(transactions, blockNumber) {
+		return transactions, blockNumber
+	}
+
+	return tc.back.Peek(address)
+}
+
+func (tc *tieredCache) ClearAddress(address string) {
+	tc.back.ClearAddress(address)
+	tc.front.ClearAddress(address)
+}
+
+func (tc *tieredCache) Clear() {
+	tc.back.Clear()
+	tc.front.Clear()
+}
+
+func (tc *tieredCache) Rewind(address string, blockNumber int) {
+	tc.back.Rewind(address, blockNumber)
+	tc.front.Rewind(address, blockNumber)
+}
+
+// Stats gets the size of back, which holds every address, and the hits and
+// misses of the tiered cache as a whole
+func (tc *tieredCache) Stats() CacheStats {
+	stats := tc.back.Stats()
+	stats.Hits = tc.hits.Load()
+	stats.Misses = tc.misses.Load()
+	return stats
+}
+
+// Close closes the tiers that can be closed
+func (tc *tieredCache) Close() error {
+	var errs []error
+	for _, c := range []Cache{tc.front, tc.back} {
+		if closer, ok := c.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// missed reports whether GetTransactions returned the result of an address
+// that isn't cached
+func missed(transactions []*models.Transaction, blockNumber int) bool {
+	return transactions == nil && blockNumber == 0
+}
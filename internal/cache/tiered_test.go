@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestTieredCacheWritesThrough(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	front := NewMemCache()
+	back := newTestSQLiteCache(t, path)
+	c := NewTieredCache(front, back)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa", Value: "0x1", BlockNumber: "0xa"}}, 10)
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa", Value: "0x2", BlockNumber: "0xa"}, {Hash: "0xb", BlockNumber: "0xb"}}, 11)
+
+	for _, tier := range []Cache{front, back} {
+		txs, blockNumber := tier.GetTransactions("0x01")
+		require.Equal(t, 11, blockNumber)
+		require.Len(t, txs, 2)
+		require.Equal(t, "0x2", txs[0].Value)
+	}
+
+	// the transactions outlive the memory tier
+	require.NoError(t, c.(io.Closer).Close())
+	reopened := NewTieredCache(NewMemCache(), newTestSQLiteCache(t, path))
+	txs, blockNumber := reopened.GetTransactions("0x01")
+	require.Equal(t, 11, blockNumber)
+	require.Len(t, txs, 2)
+}
+
+func TestTieredCacheReadsThrough(t *testing.T) {
+	front := NewMemCache()
+	back := NewMemCache()
+	c := NewTieredCache(front, back)
+
+	back.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa", BlockNumber: "0xa"}}, 10)
+
+	txs, blockNumber := front.GetTransactions("0x01")
+	require.Nil(t, txs)
+	require.Zero(t, blockNumber)
+
+	txs, blockNumber = c.GetTransactions("0x01")
+	require.Equal(t, 10, blockNumber)
+	require.Len(t, txs, 1)
+
+	// the hit in back populated front
+	txs, blockNumber = front.GetTransactions("0x01")
+	require.Equal(t, 10, blockNumber)
+	require.Len(t, txs, 1)
+
+	txs, blockNumber = c.GetTransactions("0x02")
+	require.Nil(t, txs)
+	require.Zero(t, blockNumber)
+
+	require.Equal(t, CacheStats{Addresses: 1, Transactions: 1, Hits: 1, Misses: 1}, c.Stats())
+}
+
+func TestTieredCacheFrontEvicted(t *testing.T) {
+	front := NewMemCacheWithCapacity(1)
+	c := NewTieredCache(front, NewMemCache())
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa", BlockNumber: "0xa"}}, 10)
+	c.AddTransactions("0x02", []*models.Transaction{{Hash: "0xb", BlockNumber: "0xb"}}, 11)
+
+	txs, blockNumber := c.GetTransactions("0x01")
+	require.Equal(t, 10, blockNumber)
+	require.Len(t, txs, 1)
+	require.Equal(t, 1, front.Stats().Addresses)
+}
+
+func TestTieredCacheRewindAndClear(t *testing.T) {
+	front := NewMemCache()
+	back := NewMemCache()
+	c := NewTieredCache(front, back)
+
+	c.AddTransactions("0x01", []*models.Transaction{{Hash: "0xa", BlockNumber: "0xa"}, {Hash: "0xb", BlockNumber: "0xb"}}, 11)
+	c.Rewind("0x01", 10)
+	for _, tier := range []Cache{front, back} {
+		txs, blockNumber := tier.GetTransactions("0x01")
+		require.Equal(t, 10, blockNumber)
+		require.Len(t, txs, 1)
+	}
+
+	c.ClearAddress("0x01")
+	for _, tier := range []Cache{front, back, c} {
+		txs, _ := tier.GetTransactions("0x01")
+		require.Nil(t, txs)
+	}
+}
@@ -0,0 +1,134 @@
+// Package grpc serves the parser over gRPC, along the HTTP API of cmd
+package grpc
+
+//go:generate protoc -I parserpb --go_out=parserpb --go_opt=paths=source_relative --go-grpc_out=parserpb --go-grpc_opt=paths=source_relative parserpb/parser.proto
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"ethparser/internal/grpc/parserpb"
+	"ethparser/internal/models"
+	"ethparser/internal/parser"
+)
+
+// server implements the Parser service by delegating to a parser
+type server struct {
+	parserpb.UnimplementedParserServer
+
+	parser parser.Parser
+}
+
+// NewServer creates a gRPC server serving the Parser service backed by p
+func NewServer(p parser.Parser, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	parserpb.RegisterParserServer(s, &server{parser: p})
+
+	return s
+}
+
+func (s *server) GetCurrentBlock(ctx context.Context, _ *parserpb.GetCurrentBlockRequest) (*parserpb.GetCurrentBlockResponse, error) {
+	blockNumber, err := s.parser.GetCurrentBlockCtx(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &parserpb.GetCurrentBlockResponse{BlockNumber: int64(blockNumber)}, nil
+}
+
+func (s *server) Subscribe(ctx context.Context, req *parserpb.SubscribeRequest) (*parserpb.SubscribeResponse, error) {
+	if err := checkAddress(req.GetAddress()); err != nil {
+		return nil, err
+	}
+
+	if err := s.parser.SubscribeCtx(ctx, req.GetAddress()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &parserpb.SubscribeResponse{}, nil
+}
+
+func (s *server) Unsubscribe(_ context.Context, req *parserpb.UnsubscribeRequest) (*parserpb.UnsubscribeResponse, error) {
+	if err := checkAddress(req.GetAddress()); err != nil {
+		return nil, err
+	}
+
+	return &parserpb.UnsubscribeResponse{Unsubscribed: s.parser.Unsubscribe(req.GetAddress())}, nil
+}
+
+func (s *server) GetTransactions(req *parserpb.GetTransactionsRequest, stream parserpb.Parser_GetTransactionsServer) error {
+	if err := checkAddress(req.GetAddress()); err != nil {
+		return err
+	}
+
+	transactions, err := s.parser.GetTransactionsCtx(stream.Context(), req.GetAddress())
+	if errors.Is(err, parser.ErrResultTruncated) {
+		// the most recent transactions are streamed, the client being told
+		// in the trailer that there are more
+		stream.SetTrailer(metadata.Pairs("x-result-truncated", "true"))
+		err = nil
+	}
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for _, tx := range transactions {
+		if err := stream.Send(toProto(tx)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkAddress rejects addresses that are not 0x-prefixed 20-byte hex
+func checkAddress(address string) error {
+	if !models.IsValidAddress(address) {
+		return status.Error(codes.InvalidArgument, "address must be a 0x-prefixed 20-byte hex string")
+	}
+
+	return nil
+}
+
+// toStatus maps a parser error to the gRPC status clients expect for it
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, parser.ErrNotSubscribed):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, parser.ErrRangeTooLarge):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, parser.ErrCircuitOpen), errors.Is(err, parser.ErrBlockNotYetAvailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// toProto converts a transaction to its message
+func toProto(tx *models.Transaction) *parserpb.Transaction {
+	return &parserpb.Transaction{
+		Hash:                 tx.Hash,
+		From:                 tx.From,
+		To:                   tx.To,
+		Value:                tx.Value,
+		BlockHash:            tx.BlockHash,
+		BlockNumber:          tx.BlockNumber,
+		TransactionIndex:     tx.TransactionIndex,
+		Input:                tx.Input,
+		Nonce:                tx.Nonce,
+		Gas:                  tx.Gas,
+		GasPrice:             tx.GasPrice,
+		MaxFeePerGas:         tx.MaxFeePerGas,
+		MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas,
+		BlockTimestamp:       tx.BlockTimestamp,
+	}
+}
@@ -0,0 +1,140 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"ethparser/internal/grpc/parserpb"
+	"ethparser/internal/models"
+	"ethparser/internal/parser"
+)
+
+const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+
+// newTestClient serves a parser talking to a fake JSON-RPC node answering
+// each method with the given result, returning a client connected to it
+func newTestClient(t *testing.T, results map[string]interface{}) parserpb.ParserClient {
+	t.Helper()
+
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req parser.JsonRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      req.ID,
+			"jsonrpc": "2.0",
+			"result":  results[req.Method],
+		})
+	}))
+	t.Cleanup(node.Close)
+
+	p, err := parser.NewEthParser(parser.WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	srv := NewServer(p)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return parserpb.NewParserClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	client := newTestClient(t, map[string]interface{}{
+		"eth_blockNumber": "0x10",
+		"eth_getBlockByNumber": models.BlockWithDetails{
+			Hash:   "0xb16",
+			Number: "0x10",
+			Transactions: []models.Transaction{
+				{Hash: "0x01", From: address, To: "0x02", Value: "0x1", BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x0"},
+				{Hash: "0x02", From: "0x02", To: address, Value: "0x2", BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x1"},
+				{Hash: "0x03", From: "0x02", To: "0x03", BlockHash: "0xb16", BlockNumber: "0x10", TransactionIndex: "0x2"},
+			},
+		},
+	})
+	ctx := context.Background()
+
+	block, err := client.GetCurrentBlock(ctx, &parserpb.GetCurrentBlockRequest{})
+	require.NoError(t, err)
+	require.Equal(t, int64(16), block.GetBlockNumber())
+
+	_, err = client.Subscribe(ctx, &parserpb.SubscribeRequest{Address: address})
+	require.NoError(t, err)
+
+	stream, err := client.GetTransactions(ctx, &parserpb.GetTransactionsRequest{Address: address})
+	require.NoError(t, err)
+
+	var hashes []string
+	for {
+		tx, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		hashes = append(hashes, tx.GetHash())
+	}
+	require.Equal(t, []string{"0x01", "0x02"}, hashes)
+
+	unsubscribed, err := client.Unsubscribe(ctx, &parserpb.UnsubscribeRequest{Address: address})
+	require.NoError(t, err)
+	require.True(t, unsubscribed.GetUnsubscribed())
+
+	unsubscribed, err = client.Unsubscribe(ctx, &parserpb.UnsubscribeRequest{Address: address})
+	require.NoError(t, err)
+	require.False(t, unsubscribed.GetUnsubscribed())
+}
+
+func TestServerErrors(t *testing.T) {
+	client := newTestClient(t, map[string]interface{}{"eth_blockNumber": "0x10"})
+	ctx := context.Background()
+
+	_, err := client.Subscribe(ctx, &parserpb.SubscribeRequest{Address: "0x01"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// the error of a server stream comes with its first message
+	stream, err := client.GetTransactions(ctx, &parserpb.GetTransactionsRequest{Address: address})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestToProto(t *testing.T) {
+	tx := &models.Transaction{
+		Hash: "0x01", From: "0x02", To: "0x03", Value: "0x4", BlockHash: "0x05", BlockNumber: "0x6",
+		TransactionIndex: "0x7", Input: "0x08", Nonce: "0x9", Gas: "0xa", GasPrice: "0xb",
+		MaxFeePerGas: "0xc", MaxPriorityFeePerGas: "0xd", BlockTimestamp: "0x5f5e100",
+	}
+
+	want := &parserpb.Transaction{
+		Hash: "0x01", From: "0x02", To: "0x03", Value: "0x4", BlockHash: "0x05", BlockNumber: "0x6",
+		TransactionIndex: "0x7", Input: "0x08", Nonce: "0x9", Gas: "0xa", GasPrice: "0xb",
+		MaxFeePerGas: "0xc", MaxPriorityFeePerGas: "0xd", BlockTimestamp: "0x5f5e100",
+	}
+	got := toProto(tx)
+	require.Equal(t, want.String(), got.String())
+}
@@ -0,0 +1,12 @@
+package models
+
+import "regexp"
+
+// addressRegexp matches a 0x-prefixed 20-byte hex address
+var addressRegexp = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// IsValidAddress reports whether s is a 0x-prefixed 20-byte hex address, in
+// any letter case
+func IsValidAddress(s string) bool {
+	return addressRegexp.MatchString(s)
+}
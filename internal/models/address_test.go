@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{address: "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe", want: true},
+		{address: "0xCB81Fa1fc2a94461f49d9106dcb7772a29288efe", want: true},
+		{address: "0xCB81FA1FC2A94461F49D9106DCB7772A29288EFE", want: true},
+		{address: "", want: false},
+		{address: "0x", want: false},
+		{address: "0xcb81fa1fc2a94461f49d9106dcb7772a29288ef", want: false},
+		{address: "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe0", want: false},
+		{address: "cb81fa1fc2a94461f49d9106dcb7772a29288efe", want: false},
+		{address: "0Xcb81fa1fc2a94461f49d9106dcb7772a29288efe", want: false},
+		{address: "0xgb81fa1fc2a94461f49d9106dcb7772a29288efe", want: false},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, IsValidAddress(tt.address), tt.address)
+	}
+}
@@ -0,0 +1,12 @@
+package models
+
+import "regexp"
+
+// hashRegexp matches a 0x-prefixed 32-byte hex hash
+var hashRegexp = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+// IsValidHash reports whether s is a 0x-prefixed 32-byte hex hash, as block
+// and transaction hashes are, in any letter case
+func IsValidHash(s string) bool {
+	return hashRegexp.MatchString(s)
+}
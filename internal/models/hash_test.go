@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidHash(t *testing.T) {
+	tests := []struct {
+		hash string
+		want bool
+	}{
+		{hash: "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b", want: true},
+		{hash: "0x88DF016429689C079F3B2F6AD39FA052532C56795B733DA78A91EBE6A713944B", want: true},
+		{hash: "", want: false},
+		{hash: "0x", want: false},
+		{hash: "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944", want: false},
+		{hash: "88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b", want: false},
+		{hash: "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe", want: false},
+		{hash: "0xg8df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b", want: false},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, IsValidHash(tt.hash), tt.hash)
+	}
+}
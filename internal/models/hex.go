@@ -0,0 +1,43 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseHexInt parses a hex encoded quantity, with or without the 0x prefix
+// some providers leave out. Surrounding whitespace is ignored and a bare
+// "0x", which some nodes answer for zero, is zero.
+func ParseHexInt(s string) (int, error) {
+	n, err := parseHexUint(s, strconv.IntSize-1)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// ParseHexUint64 is ParseHexInt for quantities up to math.MaxUint64
+func ParseHexUint64(s string) (uint64, error) {
+	return parseHexUint(s, 64)
+}
+
+func parseHexUint(s string, bitSize int) (uint64, error) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "0x" || trimmed == "0X" {
+		return 0, nil
+	}
+
+	digits := strings.TrimPrefix(strings.TrimPrefix(trimmed, "0x"), "0X")
+	if digits == "" {
+		return 0, fmt.Errorf("invalid hex quantity: %q", s)
+	}
+
+	n, err := strconv.ParseUint(digits, 16, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex quantity %q: %w", s, err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHexInt(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    int
+		wantErr bool
+	}{
+		{s: "0x13ecaeb", want: 20892395},
+		{s: "0X13ECAEB", want: 20892395},
+		{s: "13ecaeb", want: 20892395},
+		{s: "0x0", want: 0},
+		{s: "0", want: 0},
+		{s: "0x", want: 0},
+		{s: " 0x10\n", want: 16},
+		{s: "", wantErr: true},
+		{s: "  ", wantErr: true},
+		{s: "0x 10", wantErr: true},
+		{s: "0x-1", wantErr: true},
+		{s: "0xzz", wantErr: true},
+		{s: "0x8000000000000000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseHexInt(tt.s)
+		if tt.wantErr {
+			require.Error(t, err, tt.s)
+			continue
+		}
+		require.NoError(t, err, tt.s)
+		require.Equal(t, tt.want, got, tt.s)
+	}
+}
+
+func TestParseHexUint64(t *testing.T) {
+	got, err := ParseHexUint64("0xffffffffffffffff")
+	require.NoError(t, err)
+	require.Equal(t, uint64(math.MaxUint64), got)
+
+	_, err = ParseHexUint64("0x10000000000000000")
+	require.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// TransferEventTopic is the keccak256 hash of Transfer(address,address,uint256)
+const TransferEventTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+type Log struct {
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	BlockNumber     string   `json:"blockNumber"`
+	TransactionHash string   `json:"transactionHash"`
+	LogIndex        string   `json:"logIndex"`
+}
+
+// TokenTransfer is an ERC-20 Transfer event
+type TokenTransfer struct {
+	Token       string   `json:"token"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Value       *big.Int `json:"value"`
+	TxHash      string   `json:"txHash"`
+	BlockNumber string   `json:"blockNumber"`
+	LogIndex    string   `json:"logIndex"`
+}
+
+// ParseTokenTransfer decodes an ERC-20 Transfer event log
+func ParseTokenTransfer(l *Log) (*TokenTransfer, error) {
+	if len(l.Topics) != 3 || l.Topics[0] != TransferEventTopic {
+		return nil, fmt.Errorf("not an ERC-20 transfer log: %s", l.TransactionHash)
+	}
+
+	from, err := topicToAddress(l.Topics[1])
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := topicToAddress(l.Topics[2])
+	if err != nil {
+		return nil, err
+	}
+
+	value := new(big.Int)
+	if data := strings.TrimPrefix(l.Data, "0x"); data != "" {
+		if _, ok := value.SetString(data, 16); !ok {
+			return nil, fmt.Errorf("invalid transfer value: %q", l.Data)
+		}
+	}
+
+	return &TokenTransfer{
+		Token:       strings.ToLower(l.Address),
+		From:        from,
+		To:          to,
+		Value:       value,
+		TxHash:      l.TransactionHash,
+		BlockNumber: l.BlockNumber,
+		LogIndex:    l.LogIndex,
+	}, nil
+}
+
+// AddressToTopic left pads a 20-byte address into a 32-byte topic
+func AddressToTopic(address string) string {
+	return "0x" + strings.Repeat("0", 24) + strings.ToLower(strings.TrimPrefix(address, "0x"))
+}
+
+// topicToAddress extracts the 20-byte address from a 32-byte topic
+func topicToAddress(topic string) (string, error) {
+	digits := strings.TrimPrefix(topic, "0x")
+	if len(digits) != 64 {
+		return "", fmt.Errorf("invalid address topic: %q", topic)
+	}
+
+	return "0x" + strings.ToLower(digits[24:]), nil
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTokenTransfer(t *testing.T) {
+	transfer, err := ParseTokenTransfer(&Log{
+		Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		Topics: []string{
+			TransferEventTopic,
+			"0x000000000000000000000000cb81fa1fc2a94461f49d9106dcb7772a29288efe",
+			"0x00000000000000000000000028c6c06298d514db089934071355e5743bf21d60",
+		},
+		Data:            "0x00000000000000000000000000000000000000000000000000000000000f4240",
+		BlockNumber:     "0x10",
+		TransactionHash: "0x01",
+		LogIndex:        "0x2",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", transfer.Token)
+	require.Equal(t, "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe", transfer.From)
+	require.Equal(t, "0x28c6c06298d514db089934071355e5743bf21d60", transfer.To)
+	require.Equal(t, "1000000", transfer.Value.String())
+	require.Equal(t, "0x01", transfer.TxHash)
+}
+
+func TestParseTokenTransferInvalid(t *testing.T) {
+	_, err := ParseTokenTransfer(&Log{Topics: []string{"0x01"}})
+	require.Error(t, err)
+
+	_, err = ParseTokenTransfer(&Log{Topics: []string{TransferEventTopic, "0x01", "0x02"}})
+	require.Error(t, err)
+}
+
+func TestAddressToTopic(t *testing.T) {
+	require.Equal(t,
+		"0x000000000000000000000000cb81fa1fc2a94461f49d9106dcb7772a29288efe",
+		AddressToTopic("0xCB81Fa1fc2a94461f49d9106dcb7772a29288efe"),
+	)
+}
@@ -0,0 +1,27 @@
+package models
+
+// Receipt is the outcome of a mined transaction
+type Receipt struct {
+	TransactionHash string `json:"transactionHash"`
+	BlockHash       string `json:"blockHash"`
+	BlockNumber     string `json:"blockNumber"`
+	// StatusCode is "0x1" for transactions that succeeded and "0x0" for
+	// the ones that reverted. It is empty before the Byzantium fork.
+	StatusCode string `json:"status"`
+	GasUsed    string `json:"gasUsed"`
+	// ContractAddress is the address of the contract a contract creation
+	// deployed, and empty for other transactions
+	ContractAddress string `json:"contractAddress,omitempty"`
+	Logs            []Log  `json:"logs"`
+}
+
+// Status reports whether the transaction succeeded, a mined transaction
+// that reverted having no effect but its fee
+func (r *Receipt) Status() bool {
+	return r.StatusCode == "0x1"
+}
+
+// GasUsedUint64 parses the hex encoded GasUsed
+func (r *Receipt) GasUsedUint64() (uint64, error) {
+	return ParseHexUint64(r.GasUsed)
+}
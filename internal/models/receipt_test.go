@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{status: "0x1", want: true},
+		{status: "0x0", want: false},
+		{status: "", want: false},
+	}
+
+	for _, tt := range tests {
+		r := &Receipt{StatusCode: tt.status}
+		require.Equal(t, tt.want, r.Status(), tt.status)
+	}
+}
+
+func TestReceiptUnmarshal(t *testing.T) {
+	var r Receipt
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"transactionHash": "0x01",
+		"status": "0x1",
+		"gasUsed": "0x5208",
+		"contractAddress": null,
+		"logs": [{"address": "0x02", "topics": ["0x03"], "data": "0x"}]
+	}`), &r))
+
+	require.True(t, r.Status())
+	require.Empty(t, r.ContractAddress)
+	require.Len(t, r.Logs, 1)
+	require.Equal(t, "0x02", r.Logs[0].Address)
+
+	gasUsed, err := r.GasUsedUint64()
+	require.NoError(t, err)
+	require.Equal(t, uint64(21000), gasUsed)
+}
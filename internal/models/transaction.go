@@ -1,5 +1,19 @@
 package models
 
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// weiPerEther is the number of wei in one ether
+var weiPerEther = new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+
 type Transaction struct {
 	Hash        string `json:"hash"`
 	From        string `json:"from"`
@@ -7,11 +21,224 @@ type Transaction struct {
 	Value       string `json:"value"`
 	BlockHash   string `json:"blockHash"`
 	BlockNumber string `json:"blockNumber"`
+	// TransactionIndex is the hex encoded position of the transaction in
+	// its block
+	TransactionIndex string `json:"transactionIndex"`
+	// BlockTimestamp is the hex encoded unix time in seconds of the block
+	// the transaction was mined in, set by the parser from the block
+	BlockTimestamp string `json:"blockTimestamp,omitempty"`
+	Input          string `json:"input"`
+	Nonce          string `json:"nonce"`
+	Gas            string `json:"gas"`
+	// GasPrice is the effective gas price, set for EIP-1559 transactions too
+	GasPrice string `json:"gasPrice"`
+	// MaxFeePerGas and MaxPriorityFeePerGas are only set for EIP-1559
+	// transactions
+	MaxFeePerGas         string `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas,omitempty"`
+}
+
+// IndexInt parses the hex encoded TransactionIndex
+func (t *Transaction) IndexInt() (int, error) {
+	return ParseHexInt(t.TransactionIndex)
+}
+
+// BlockTime converts the hex encoded BlockTimestamp, returning the zero time
+// when it is not set or does not parse
+func (t *Transaction) BlockTime() time.Time {
+	return hexTime(t.BlockTimestamp)
+}
+
+// NonceUint64 parses the hex encoded Nonce
+func (t *Transaction) NonceUint64() (uint64, error) {
+	return ParseHexUint64(t.Nonce)
+}
+
+// GasUint64 parses the hex encoded Gas limit
+func (t *Transaction) GasUint64() (uint64, error) {
+	return ParseHexUint64(t.Gas)
+}
+
+// GasPriceWei parses the hex encoded GasPrice into wei
+func (t *Transaction) GasPriceWei() (*big.Int, error) {
+	return parseWei("gas price", t.GasPrice)
+}
+
+// MaxFeePerGasWei parses the hex encoded MaxFeePerGas into wei, which is
+// zero for legacy transactions
+func (t *Transaction) MaxFeePerGasWei() (*big.Int, error) {
+	return parseWei("max fee per gas", t.MaxFeePerGas)
+}
+
+// MaxPriorityFeePerGasWei parses the hex encoded MaxPriorityFeePerGas into
+// wei, which is zero for legacy transactions
+func (t *Transaction) MaxPriorityFeePerGasWei() (*big.Int, error) {
+	return parseWei("max priority fee per gas", t.MaxPriorityFeePerGas)
+}
+
+// IsContractCreation reports whether the transaction deploys a contract,
+// which nodes return with a null To
+func (t *Transaction) IsContractCreation() bool {
+	return t.To == ""
+}
+
+// Direction returns "in" for a transaction to address, "out" for one from it
+// and "self" for one from address to itself, or an empty string when address
+// is neither. Addresses are compared case-insensitively.
+func (t *Transaction) Direction(address string) string {
+	from := address != "" && strings.EqualFold(t.From, address)
+	to := address != "" && strings.EqualFold(t.To, address)
+
+	switch {
+	case from && to:
+		return "self"
+	case to:
+		return "in"
+	case from:
+		return "out"
+	default:
+		return ""
+	}
+}
+
+// MethodSelector returns the 0x-prefixed 4-byte method selector the
+// transaction Input starts with, or an empty string for plain transfers
+func (t *Transaction) MethodSelector() string {
+	if len(t.Input) < 10 || !strings.HasPrefix(t.Input, "0x") {
+		return ""
+	}
+
+	return strings.ToLower(t.Input[:10])
+}
+
+// ValueWei parses the hex encoded Value into wei. An empty value or a bare
+// "0x" is treated as zero.
+func (t *Transaction) ValueWei() (*big.Int, error) {
+	return parseWei("transaction value", t.Value)
+}
+
+// parseWei parses a hex encoded amount of wei, treating an empty amount or a
+// bare "0x" as zero
+func parseWei(name string, s string) (*big.Int, error) {
+	digits := strings.TrimPrefix(s, "0x")
+	if digits == "" {
+		return new(big.Int), nil
+	}
+
+	value, ok := new(big.Int).SetString(digits, 16)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s: %q", name, s)
+	}
+
+	return value, nil
+}
+
+// ValueEther converts Value to ether for display
+func (t *Transaction) ValueEther() (*big.Float, error) {
+	wei, err := t.ValueWei()
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), weiPerEther), nil
+}
+
+// SortTransactions sorts transactions in chain order, by block number then
+// position in the block, breaking ties by hash. Numbers that don't parse sort
+// first.
+func SortTransactions(transactions []*Transaction) {
+	type position struct {
+		blockNumber int
+		index       int
+	}
+
+	positions := make(map[*Transaction]position, len(transactions))
+	for _, tx := range transactions {
+		blockNumber, _ := ParseHexInt(tx.BlockNumber)
+		index, _ := tx.IndexInt()
+		positions[tx] = position{blockNumber: blockNumber, index: index}
+	}
+
+	slices.SortFunc(transactions, func(a, b *Transaction) int {
+		return cmp.Or(
+			cmp.Compare(positions[a].blockNumber, positions[b].blockNumber),
+			cmp.Compare(positions[a].index, positions[b].index),
+			cmp.Compare(a.Hash, b.Hash),
+		)
+	})
+}
+
+// CompareTransactions compares a and b in the chain order SortTransactions
+// sorts them in
+func CompareTransactions(a, b *Transaction) int {
+	aBlockNumber, _ := ParseHexInt(a.BlockNumber)
+	bBlockNumber, _ := ParseHexInt(b.BlockNumber)
+	aIndex, _ := a.IndexInt()
+	bIndex, _ := b.IndexInt()
+
+	return cmp.Or(
+		cmp.Compare(aBlockNumber, bBlockNumber),
+		cmp.Compare(aIndex, bIndex),
+		cmp.Compare(a.Hash, b.Hash),
+	)
 }
 
 type BlockWithDetails struct {
-	Hash         string        `json:"hash"`
-	ParentHash   string        `json:"parentHash"`
-	Number       string        `json:"number"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parentHash"`
+	Number     string `json:"number"`
+	// Timestamp is the hex encoded unix time in seconds the block was
+	// mined at
+	Timestamp    string        `json:"timestamp"`
 	Transactions []Transaction `json:"transactions"`
 }
+
+// Time converts the hex encoded Timestamp, returning the zero time when it
+// is not set or does not parse
+func (b *BlockWithDetails) Time() time.Time {
+	return hexTime(b.Timestamp)
+}
+
+// hexTime converts hex encoded unix seconds to a UTC time
+func hexTime(s string) time.Time {
+	seconds, err := ParseHexInt(s)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(int64(seconds), 0).UTC()
+}
+
+// UnmarshalJSON decodes a block fetched with or without its full
+// transactions, which nodes return as the hashes of the transactions when
+// they're not full. Only the Hash of those transactions is set.
+func (b *BlockWithDetails) UnmarshalJSON(data []byte) error {
+	// block has the fields of BlockWithDetails without its methods
+	type block BlockWithDetails
+	var raw struct {
+		block
+		Transactions []json.RawMessage `json:"transactions"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*b = BlockWithDetails(raw.block)
+	if raw.Transactions != nil {
+		b.Transactions = make([]Transaction, len(raw.Transactions))
+	}
+	for i, rawTx := range raw.Transactions {
+		if bytes.HasPrefix(bytes.TrimSpace(rawTx), []byte(`"`)) {
+			if err := json.Unmarshal(rawTx, &b.Transactions[i].Hash); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := json.Unmarshal(rawTx, &b.Transactions[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionValueWei(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "0x1bc16d674ec80000", want: "2000000000000000000"},
+		// larger than math.MaxInt64
+		{value: "0x10000000000000000", want: "18446744073709551616"},
+		{value: "0x0", want: "0"},
+		{value: "0x", want: "0"},
+		{value: "", want: "0"},
+	}
+
+	for _, tt := range tests {
+		tx := &Transaction{Value: tt.value}
+
+		wei, err := tx.ValueWei()
+		require.NoError(t, err, tt.value)
+		require.Equal(t, tt.want, wei.String(), tt.value)
+	}
+}
+
+func TestTransactionValueWeiMalformed(t *testing.T) {
+	for _, value := range []string{"0xzz", "not hex", "0x-1"} {
+		tx := &Transaction{Value: value}
+
+		_, err := tx.ValueWei()
+		require.Error(t, err, value)
+
+		_, err = tx.ValueEther()
+		require.Error(t, err, value)
+	}
+}
+
+func TestTransactionValueEther(t *testing.T) {
+	tx := &Transaction{Value: "0x1bc16d674ec80000"}
+
+	ether, err := tx.ValueEther()
+	require.NoError(t, err)
+	require.Equal(t, "2", ether.Text('f', -1))
+
+	tx = &Transaction{Value: "0x6f05b59d3b20000"}
+
+	ether, err = tx.ValueEther()
+	require.NoError(t, err)
+	require.Equal(t, "0.5", ether.Text('f', -1))
+}
+
+func TestTransactionMethodSelector(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "0x095EA7B3000000000000000000000000", want: "0x095ea7b3"},
+		{input: "0xa9059cbb", want: "0xa9059cbb"},
+		{input: "0x", want: ""},
+		{input: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		tx := &Transaction{Input: tt.input}
+		require.Equal(t, tt.want, tx.MethodSelector(), tt.input)
+	}
+}
+
+func TestTransactionIsContractCreation(t *testing.T) {
+	var tx Transaction
+	require.NoError(t, json.Unmarshal([]byte(`{"hash":"0x01","from":"0x02","to":null}`), &tx))
+	require.True(t, tx.IsContractCreation())
+
+	tx.To = "0x03"
+	require.False(t, tx.IsContractCreation())
+}
+
+func TestTransactionDirection(t *testing.T) {
+	const address = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
+	const mixedCase = "0xCB81fa1fc2a94461F49d9106dcb7772a29288EFE"
+
+	tx := &Transaction{From: address, To: "0x02"}
+	require.Equal(t, "out", tx.Direction(address))
+	require.Equal(t, "out", tx.Direction(mixedCase))
+	require.Equal(t, "", tx.Direction("0x03"))
+
+	tx = &Transaction{From: "0x02", To: mixedCase}
+	require.Equal(t, "in", tx.Direction(address))
+
+	tx = &Transaction{From: mixedCase, To: address}
+	require.Equal(t, "self", tx.Direction(address))
+
+	// a contract creation has no To to match an empty address
+	tx = &Transaction{From: address}
+	require.Equal(t, "", tx.Direction(""))
+}
+
+func TestTransactionIndexInt(t *testing.T) {
+	index, err := (&Transaction{TransactionIndex: "0x1a"}).IndexInt()
+	require.NoError(t, err)
+	require.Equal(t, 26, index)
+
+	_, err = (&Transaction{}).IndexInt()
+	require.Error(t, err)
+}
+
+func TestTransactionGasAndNonce(t *testing.T) {
+	var tx Transaction
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"hash": "0x01",
+		"nonce": "0x15",
+		"gas": "0x5208",
+		"gasPrice": "0x578b58b00",
+		"maxFeePerGas": "0x6fc23ac00",
+		"maxPriorityFeePerGas": "0x3b9aca00",
+		"transactionIndex": "0x2"
+	}`), &tx))
+
+	nonce, err := tx.NonceUint64()
+	require.NoError(t, err)
+	require.Equal(t, uint64(21), nonce)
+
+	gas, err := tx.GasUint64()
+	require.NoError(t, err)
+	require.Equal(t, uint64(21000), gas)
+
+	gasPrice, err := tx.GasPriceWei()
+	require.NoError(t, err)
+	require.Equal(t, "23500000000", gasPrice.String())
+
+	maxFee, err := tx.MaxFeePerGasWei()
+	require.NoError(t, err)
+	require.Equal(t, "30000000000", maxFee.String())
+
+	maxPriorityFee, err := tx.MaxPriorityFeePerGasWei()
+	require.NoError(t, err)
+	require.Equal(t, "1000000000", maxPriorityFee.String())
+
+	// legacy transactions have no EIP-1559 fees
+	legacy := &Transaction{GasPrice: "0x1"}
+	maxFee, err = legacy.MaxFeePerGasWei()
+	require.NoError(t, err)
+	require.Zero(t, maxFee.Sign())
+
+	_, err = (&Transaction{Gas: "0xzz"}).GasUint64()
+	require.Error(t, err)
+}
+
+func TestBlockWithDetailsTime(t *testing.T) {
+	block := &BlockWithDetails{Timestamp: "0x65a0c8f3"}
+	require.Equal(t, time.Date(2024, time.January, 12, 5, 6, 59, 0, time.UTC), block.Time())
+
+	tx := &Transaction{BlockTimestamp: block.Timestamp}
+	require.Equal(t, block.Time(), tx.BlockTime())
+
+	require.True(t, (&BlockWithDetails{}).Time().IsZero())
+	require.True(t, (&Transaction{BlockTimestamp: "0xzz"}).BlockTime().IsZero())
+}
+
+func TestSortTransactions(t *testing.T) {
+	transactions := []*Transaction{
+		{Hash: "0xd", BlockNumber: "0x10", TransactionIndex: "0x1"},
+		{Hash: "0xa", BlockNumber: "0x11"},
+		{Hash: "0xc", BlockNumber: "0x10", TransactionIndex: "0x0"},
+		{Hash: "0xe", BlockNumber: "0x9"},
+		{Hash: "0xb", BlockNumber: "0x9"},
+	}
+
+	SortTransactions(transactions)
+
+	hashes := make([]string, 0, len(transactions))
+	for _, tx := range transactions {
+		hashes = append(hashes, tx.Hash)
+	}
+	require.Equal(t, []string{"0xb", "0xe", "0xc", "0xd", "0xa"}, hashes)
+
+	// sorted by the same order CompareTransactions compares in
+	require.True(t, slices.IsSortedFunc(transactions, CompareTransactions))
+	require.Zero(t, CompareTransactions(transactions[0], &Transaction{Hash: "0xb", BlockNumber: "0x9", Value: "0x1"}))
+}
+
+func TestBlockWithDetailsUnmarshalJSON(t *testing.T) {
+	var full BlockWithDetails
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"hash": "0xb1",
+		"parentHash": "0xb0",
+		"number": "0x1",
+		"transactions": [{"hash": "0x01", "from": "0x02", "to": "0x03", "value": "0x4"}]
+	}`), &full))
+	require.Equal(t, BlockWithDetails{
+		Hash:         "0xb1",
+		ParentHash:   "0xb0",
+		Number:       "0x1",
+		Transactions: []Transaction{{Hash: "0x01", From: "0x02", To: "0x03", Value: "0x4"}},
+	}, full)
+
+	// a block fetched without its full transactions lists their hashes
+	var hashes BlockWithDetails
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"hash": "0xb1",
+		"number": "0x1",
+		"transactions": ["0x01", "0x02"]
+	}`), &hashes))
+	require.Equal(t, []Transaction{{Hash: "0x01"}, {Hash: "0x02"}}, hashes.Transactions)
+	require.Equal(t, "0xb1", hashes.Hash)
+
+	var empty BlockWithDetails
+	require.NoError(t, json.Unmarshal([]byte(`{"hash": "0xb1", "transactions": []}`), &empty))
+	require.Empty(t, empty.Transactions)
+
+	require.Error(t, json.Unmarshal([]byte(`{"transactions": [1]}`), &empty))
+}
@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"ethparser/internal/models"
+)
+
+const (
+	defaultActivityBufferSize = 256
+)
+
+// BlockActivity is a block the parser scanned, or failed to fetch
+type BlockActivity struct {
+	// Number is the block number, unknown for blocks fetched by hash that
+	// could not be
+	Number int    `json:"number"`
+	Hash   string `json:"hash,omitempty"`
+	// Matches is the number of transactions collected from the block
+	Matches int `json:"matches"`
+	// Error is why the block could not be fetched, if it couldn't
+	Error string    `json:"error,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+// activityLog keeps the most recent block activities, overwriting the
+// oldest one when full
+type activityLog struct {
+	m          sync.Mutex
+	activities []BlockActivity
+	// next is the slot the next activity is written to
+	next int
+	full bool
+}
+
+func newActivityLog(size int) *activityLog {
+	return &activityLog{
+		activities: make([]BlockActivity, size),
+	}
+}
+
+// WithActivityBufferSize keeps the last n block activities for
+// RecentActivity
+func WithActivityBufferSize(n int) EthParserOpt {
+	return func(p *ethParser) error {
+		if n < 1 {
+			return errors.New("activity buffer size must be positive")
+		}
+		p.activity = newActivityLog(n)
+		return nil
+	}
+}
+
+func (al *activityLog) add(activity BlockActivity) {
+	al.m.Lock()
+	defer al.m.Unlock()
+
+	al.activities[al.next] = activity
+	al.next = (al.next + 1) % len(al.activities)
+	if al.next == 0 {
+		al.full = true
+	}
+}
+
+// recent lists the activities from the most recent one
+func (al *activityLog) recent() []BlockActivity {
+	al.m.Lock()
+	defer al.m.Unlock()
+
+	n := al.next
+	if al.full {
+		n = len(al.activities)
+	}
+
+	recent := make([]BlockActivity, 0, n)
+	for i := 1; i <= n; i++ {
+		recent = append(recent, al.activities[(al.next-i+len(al.activities))%len(al.activities)])
+	}
+
+	return recent
+}
+
+// RecentActivity lists the most recently scanned blocks, the most recent
+// first, with the number of transactions collected from them or why they
+// could not be fetched
+func (e *ethParser) RecentActivity() []BlockActivity {
+	return e.activity.recent()
+}
+
+// recordScan records that block was scanned, matches of its transactions
+// being collected
+func (e *ethParser) recordScan(block *models.BlockWithDetails, matches int) {
+	blockNumber, err := models.ParseHexInt(block.Number)
+	if err != nil {
+		return
+	}
+
+	e.activity.add(BlockActivity{
+		Number:  blockNumber,
+		Hash:    block.Hash,
+		Matches: matches,
+		At:      e.clock.Now(),
+	})
+}
+
+// recordFetchError records that the block with blockNumber, or with hash
+// when not known, could not be fetched
+func (e *ethParser) recordFetchError(blockNumber int, hash string, err error) {
+	e.activity.add(BlockActivity{
+		Number: blockNumber,
+		Hash:   hash,
+		Error:  err.Error(),
+		At:     e.clock.Now(),
+	})
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestActivityLogKeepsMostRecentBlocks(t *testing.T) {
+	activity := newActivityLog(3)
+	require.Empty(t, activity.recent())
+
+	activity.add(BlockActivity{Number: 1})
+	require.Equal(t, []BlockActivity{{Number: 1}}, activity.recent())
+
+	for number := 2; number <= 5; number++ {
+		activity.add(BlockActivity{Number: number})
+	}
+	require.Equal(t, []BlockActivity{{Number: 5}, {Number: 4}, {Number: 3}}, activity.recent())
+}
+
+func TestParserRecentActivity(t *testing.T) {
+	_, node := newTestChain(t, 102, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address, To: hot}, {Hash: "0x1011", To: cold}},
+	})
+
+	clock := newFakeClock()
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithClock(clock))
+	require.NoError(t, err)
+
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	activity := parser.RecentActivity()
+	require.Len(t, activity, 3)
+	for _, number := range []int{100, 101, 102} {
+		require.Contains(t, activity, BlockActivity{
+			Number:  number,
+			Hash:    blockHash(number),
+			Matches: map[int]int{101: 1}[number],
+			At:      clock.Now(),
+		})
+	}
+}
+
+func TestParserRecentActivityFetchError(t *testing.T) {
+	node, _ := newFlakyNode(t, 100, http.StatusInternalServerError)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRetry(1, time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = parser.GetBlock(7)
+	require.Error(t, err)
+
+	activity := parser.RecentActivity()
+	require.Len(t, activity, 1)
+	require.Equal(t, 7, activity[0].Number)
+	require.NotEmpty(t, activity[0].Error)
+}
+
+func TestWithActivityBufferSizeInvalid(t *testing.T) {
+	_, err := NewEthParser(WithActivityBufferSize(0))
+	require.ErrorContains(t, err, "activity buffer size must be positive")
+}
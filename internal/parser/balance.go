@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type JsonRPCResponseBalance struct {
+	Result string `json:"result"`
+}
+
+func (e *ethParser) GetBalance(address string) (*big.Int, error) {
+	return e.GetBalanceCtx(context.Background(), address)
+}
+
+func (e *ethParser) GetBalanceCtx(ctx context.Context, address string) (*big.Int, error) {
+	address, err := e.parseAddress(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.getBalance(ctx, address, "latest")
+}
+
+// BalanceDelta gets the change in wei of the balance of address from the end
+// of fromBlock to the end of toBlock. Balances at past blocks are only kept by
+// archive nodes, full nodes pruning the state of all but the latest ~128
+// blocks.
+func (e *ethParser) BalanceDelta(address string, fromBlock, toBlock int) (*big.Int, error) {
+	address, err := e.parseAddress(context.Background(), address)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromBlock < 0 || fromBlock > toBlock {
+		return nil, fmt.Errorf("invalid block range: %d to %d", fromBlock, toBlock)
+	}
+
+	ctx := context.Background()
+
+	fromHex, err := intToHex(fromBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	toHex, err := intToHex(toBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	fromBalance, err := e.getBalance(ctx, address, fromHex)
+	if err != nil {
+		return nil, historicalStateError(fromBlock, err)
+	}
+
+	toBalance, err := e.getBalance(ctx, address, toHex)
+	if err != nil {
+		return nil, historicalStateError(toBlock, err)
+	}
+
+	return new(big.Int).Sub(toBalance, fromBalance), nil
+}
+
+// historicalStateError explains that a node failing to answer with the state
+// at a past block is likely not an archive node
+func historicalStateError(blockNumber int, err error) error {
+	var rpcErr *JsonRPCError
+	if errors.As(err, &rpcErr) {
+		return fmt.Errorf("state at block %d unavailable, an archive node is required: %w", blockNumber, err)
+	}
+
+	return err
+}
+
+// getBalance gets the balance in wei of address at block, either a block
+// number or a tag such as "latest"
+func (e *ethParser) getBalance(ctx context.Context, address string, block string) (*big.Int, error) {
+	rpcRequest := JsonRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_getBalance",
+		Params:  []interface{}{address, block},
+	}
+
+	rpcResponse, err := do[JsonRPCResponseBalance](ctx, e, rpcRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, ok := new(big.Int).SetString(strings.TrimPrefix(rpcResponse.Result, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid balance: %q", rpcResponse.Result)
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserGetBalance(t *testing.T) {
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		require.Equal(t, "eth_getBalance", method)
+		require.Equal(t, []interface{}{address, "latest"}, params)
+
+		// 1.5 ether
+		return "0x14d1120d7b160000"
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	balance, err := parser.GetBalance(address)
+	require.NoError(t, err)
+	require.Equal(t, "1500000000000000000", balance.String())
+}
+
+func TestParserBalanceDelta(t *testing.T) {
+	balances := map[string]string{
+		// 1.5 ether
+		"0x64": "0x14d1120d7b160000",
+		// 1 ether
+		"0x6e": "0xde0b6b3a7640000",
+	}
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		require.Equal(t, "eth_getBalance", method)
+		require.Equal(t, address, params[0])
+		return balances[params[1].(string)]
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	delta, err := parser.BalanceDelta(address, 100, 110)
+	require.NoError(t, err)
+	require.Equal(t, "-500000000000000000", delta.String())
+
+	_, err = parser.BalanceDelta(address, 110, 100)
+	require.Error(t, err)
+}
+
+func TestParserBalanceDeltaRequiresArchiveNode(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      requestID(t, r),
+			"jsonrpc": "2.0",
+			"error":   map[string]interface{}{"code": -32000, "message": "missing trie node"},
+		})
+	}))
+	t.Cleanup(node.Close)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	_, err = parser.BalanceDelta(address, 100, 110)
+	require.ErrorContains(t, err, "archive node")
+
+	var rpcErr *JsonRPCError
+	require.True(t, errors.As(err, &rpcErr))
+	require.Equal(t, "missing trie node", rpcErr.Message)
+}
@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"ethparser/internal/models"
+)
+
+// getTransactionsInBatches gets transactions from headBlockNumber down to
+// endingBlockNumber, fetching e.batchSize blocks per JSON-RPC batch request.
+// currentBlockNumber is the head of the chain, at or above headBlockNumber.
+func (e *ethParser) getTransactionsInBatches(ctx context.Context, endingBlockNumber, headBlockNumber, currentBlockNumber int, match matchFunc) ([]*models.Transaction, error) {
+	var allTransactions []*models.Transaction
+
+	for windowHead := headBlockNumber; windowHead >= endingBlockNumber; windowHead -= e.batchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		windowEnd := max(windowHead-e.batchSize+1, endingBlockNumber)
+
+		// only the blocks missing from the block cache are requested
+		blocks := make([]*models.BlockWithDetails, 0, windowHead-windowEnd+1)
+		var requests []JsonRPCRequest
+		var missing []int
+		for blockNumber := windowHead; blockNumber >= windowEnd; blockNumber-- {
+			block, ok := e.blocks.get(blockNumber, "")
+			if !ok {
+				hexNumber, err := intToHex(blockNumber)
+				if err != nil {
+					return nil, err
+				}
+
+				requests = append(requests, JsonRPCRequest{
+					Jsonrpc: "2.0",
+					Method:  "eth_getBlockByNumber",
+					Params:  []interface{}{hexNumber, true},
+				})
+				missing = append(missing, len(blocks))
+			}
+			blocks = append(blocks, block)
+		}
+
+		e.logger.Debug("fetching transactions", "fromBlock", windowEnd, "toBlock", windowHead)
+
+		rpcResponses, err := batchDo[JsonRPCResponseBlock](ctx, e, requests)
+		if err != nil {
+			for _, i := range missing {
+				e.recordFetchError(windowHead-i, "", err)
+			}
+			return nil, err
+		}
+
+		for i, rpcResponse := range rpcResponses {
+			if rpcResponse.Result.Number == "" {
+				err := nullBlockError(windowHead-missing[i], currentBlockNumber)
+				e.recordFetchError(windowHead-missing[i], "", err)
+				return nil, err
+			}
+
+			e.blocks.add(&rpcResponse.Result)
+			blocks[missing[i]] = &rpcResponse.Result
+		}
+
+		for _, block := range blocks {
+			transactions, err := e.getTransactionsFromBlock(block, match)
+			if err != nil {
+				return nil, err
+			}
+			allTransactions = append(allTransactions, transactions...)
+		}
+	}
+
+	return allTransactions, nil
+}
+
+// batchDo sends JSON RPC requests to the node of e in a single batch and
+// returns their responses in the order of the requests, matched by id. The
+// batch is measured as a single call of the method of its first request.
+func batchDo[T any](ctx context.Context, e *ethParser, rpcRequests []JsonRPCRequest) (rpcResponses []*T, err error) {
+	if len(rpcRequests) == 0 {
+		return nil, nil
+	}
+
+	for i := range rpcRequests {
+		rpcRequests[i].ID = e.nextRequestID()
+	}
+
+	e.rpcStats.record(rpcRequests...)
+	method := rpcRequests[0].Method
+	start := e.clock.Now()
+	defer func() { e.observeCall(method, start, err) }()
+
+	responseBody, err := post(ctx, e, method, rpcRequests)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawResponses []json.RawMessage
+	if err := json.Unmarshal(responseBody, &rawResponses); err != nil {
+		return nil, err
+	}
+
+	responsesByID := make(map[int]json.RawMessage, len(rawResponses))
+	for _, rawResponse := range rawResponses {
+		var header struct {
+			ID int `json:"id"`
+		}
+		if err := json.Unmarshal(rawResponse, &header); err != nil {
+			return nil, err
+		}
+		responsesByID[header.ID] = rawResponse
+	}
+
+	rpcResponses = make([]*T, 0, len(rpcRequests))
+	for _, rpcRequest := range rpcRequests {
+		rawResponse, ok := responsesByID[rpcRequest.ID]
+		if !ok {
+			return nil, fmt.Errorf("missing response for request id: %d", rpcRequest.ID)
+		}
+
+		rpcResponse, err := decodeResponse[T](rawResponse, rpcRequest.ID)
+		if err != nil {
+			return nil, err
+		}
+		rpcResponses = append(rpcResponses, rpcResponse)
+	}
+
+	return rpcResponses, nil
+}
@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"ethparser/internal/models"
+)
+
+// defaultBlockCacheSize is the number of blocks kept by default, enough for
+// the windows walked around the head by several addresses
+const defaultBlockCacheSize = 128
+
+// blockCache keeps the most recently used blocks by number and by hash, so
+// addresses walking the same blocks fetch them once. Blocks cached by number
+// are dropped when a reorg replaces them.
+type blockCache struct {
+	m    sync.Mutex
+	size int
+	// recency lists blocks from the most to the least recently used
+	recency  *list.List
+	byNumber map[int]*list.Element
+	byHash   map[string]*list.Element
+}
+
+// cachedBlock is a block along with its parsed number
+type cachedBlock struct {
+	number int
+	block  *models.BlockWithDetails
+}
+
+func newBlockCache(size int) *blockCache {
+	return &blockCache{
+		size:     size,
+		recency:  list.New(),
+		byNumber: make(map[int]*list.Element),
+		byHash:   make(map[string]*list.Element),
+	}
+}
+
+// WithBlockCacheSize keeps the n most recently used blocks in memory. A size
+// of 0 disables the cache.
+func WithBlockCacheSize(n int) EthParserOpt {
+	return func(p *ethParser) error {
+		if n < 0 {
+			return errors.New("block cache size cannot be negative")
+		}
+		p.blocks = newBlockCache(n)
+		return nil
+	}
+}
+
+// get gets a copy of the cached block at blockNumber, or with
+// hash when blockNumber is negative
+func (bc *blockCache) get(blockNumber int, hash string) (*models.BlockWithDetails, bool) {
+	bc.m.Lock()
+	defer bc.m.Unlock()
+
+	el, ok := bc.byNumber[blockNumber]
+	if blockNumber < 0 {
+		el, ok = bc.byHash[hash]
+	}
+	if !ok {
+		return nil, false
+	}
+
+	bc.recency.MoveToFront(el)
+	return copyBlock(el.Value.(*cachedBlock).block), true
+}
+
+// add caches a copy of block, evicting the least recently used blocks
+// above the size of the cache
+func (bc *blockCache) add(block *models.BlockWithDetails) {
+	if bc.size == 0 || block.Number == "" {
+		return
+	}
+
+	blockNumber, err := models.ParseHexInt(block.Number)
+	if err != nil {
+		return
+	}
+
+	bc.m.Lock()
+	defer bc.m.Unlock()
+
+	if el, ok := bc.byNumber[blockNumber]; ok {
+		bc.remove(el)
+	}
+
+	el := bc.recency.PushFront(&cachedBlock{number: blockNumber, block: copyBlock(block)})
+	bc.byNumber[blockNumber] = el
+	bc.byHash[block.Hash] = el
+
+	for bc.recency.Len() > bc.size {
+		bc.remove(bc.recency.Back())
+	}
+}
+
+// dropAfter forgets the blocks above blockNumber, which a reorg replaced
+func (bc *blockCache) dropAfter(blockNumber int) {
+	bc.m.Lock()
+	defer bc.m.Unlock()
+
+	for number, el := range bc.byNumber {
+		if number > blockNumber {
+			bc.remove(el)
+		}
+	}
+}
+
+// dropRange forgets the blocks from fromBlock to toBlock, so they are
+// fetched again
+func (bc *blockCache) dropRange(fromBlock, toBlock int) {
+	bc.m.Lock()
+	defer bc.m.Unlock()
+
+	for number, el := range bc.byNumber {
+		if fromBlock <= number && number <= toBlock {
+			bc.remove(el)
+		}
+	}
+}
+
+// remove drops a cached block.
+// bc.m must be held by the caller.
+func (bc *blockCache) remove(el *list.Element) {
+	cached := bc.recency.Remove(el).(*cachedBlock)
+	delete(bc.byNumber, cached.number)
+	delete(bc.byHash, cached.block.Hash)
+}
+
+// copyBlock copies a block along with its transactions, which callers
+// collect pointers to
+func copyBlock(block *models.BlockWithDetails) *models.BlockWithDetails {
+	blockCopy := *block
+	blockCopy.Transactions = slices.Clone(block.Transactions)
+	return &blockCopy
+}
+
+// getBlockFromHash gets block by block hash
+func (e *ethParser) getBlockFromHash(ctx context.Context, blockHash string) (*models.BlockWithDetails, error) {
+	if block, ok := e.blocks.get(-1, blockHash); ok {
+		return block, nil
+	}
+
+	rpcRequest := JsonRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_getBlockByHash",
+		Params:  []interface{}{blockHash, true},
+	}
+
+	rpcResponse, err := do[JsonRPCResponseBlock](ctx, e, rpcRequest)
+	if err != nil {
+		e.recordFetchError(-1, blockHash, err)
+		return nil, err
+	}
+
+	e.blocks.add(&rpcResponse.Result)
+	return &rpcResponse.Result, nil
+}
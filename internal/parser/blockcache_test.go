@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func testBlock(number int) *models.BlockWithDetails {
+	return &models.BlockWithDetails{
+		Hash:         blockHash(number),
+		ParentHash:   blockHash(number - 1),
+		Number:       hexNumber(number),
+		Transactions: []models.Transaction{{Hash: "0x1", BlockNumber: hexNumber(number)}},
+	}
+}
+
+func TestBlockCache(t *testing.T) {
+	bc := newBlockCache(2)
+	bc.add(testBlock(100))
+	bc.add(testBlock(101))
+
+	// 100 becomes the most recently used, so 101 is evicted
+	_, ok := bc.get(100, "")
+	require.True(t, ok)
+	bc.add(testBlock(102))
+
+	_, ok = bc.get(101, "")
+	require.False(t, ok)
+	_, ok = bc.get(-1, blockHash(101))
+	require.False(t, ok)
+
+	block, ok := bc.get(-1, blockHash(102))
+	require.True(t, ok)
+	require.Equal(t, testBlock(102), block)
+
+	// callers get their own copy
+	block.Transactions[0].Hash = "0x2"
+	block, _ = bc.get(102, "")
+	require.Equal(t, "0x1", block.Transactions[0].Hash)
+
+	bc.dropAfter(100)
+	_, ok = bc.get(102, "")
+	require.False(t, ok)
+	_, ok = bc.get(100, "")
+	require.True(t, ok)
+}
+
+func TestBlockCacheDisabled(t *testing.T) {
+	bc := newBlockCache(0)
+	bc.add(testBlock(100))
+
+	_, ok := bc.get(100, "")
+	require.False(t, ok)
+
+	_, err := NewEthParser(WithBlockCacheSize(-1))
+	require.Error(t, err)
+}
+
+func TestParserBlockCacheSharedByAddresses(t *testing.T) {
+	for _, batchSize := range []int{0, 3} {
+		chain, node := newTestChain(t, 105, map[int][]models.Transaction{
+			102: {{Hash: "0x102", From: address, To: other}},
+		})
+
+		opts := []EthParserOpt{WithNodeUrl(node.URL)}
+		if batchSize > 0 {
+			opts = append(opts, WithBatchSize(batchSize))
+		}
+		parser, err := NewEthParser(opts...)
+		require.NoError(t, err)
+		parser.addresses[address] = subscription{blockNumber: 100}
+		parser.addresses[other] = subscription{blockNumber: 100}
+
+		txs, err := parser.GetTransactions(address)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		require.Len(t, chain.fetchedBlocks(), 6)
+
+		// only the head is fetched again, to check for reorgs
+		txs, err = parser.GetTransactions(other)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		require.Equal(t, []int{105}, chain.fetchedBlocks()[6:])
+	}
+}
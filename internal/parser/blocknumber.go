@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// defaultBlockNumberTTL is well under the ~12s between blocks
+const defaultBlockNumberTTL = 2 * time.Second
+
+// blockNumberCache memoizes the current block number for ttl, a single
+// caller fetching it again once it expires. Its fields are atomics so that
+// reading a cached number takes no lock.
+type blockNumberCache struct {
+	ttl   time.Duration
+	group sharedCalls
+
+	number atomic.Int64
+	// fetchedAt is when number was set in Unix nanoseconds, 0 before it is.
+	// It is stored after number, so a caller loading it first gets a number
+	// at least as recent.
+	fetchedAt atomic.Int64
+	// following is set while new heads are pushed by the node, number
+	// being then kept up to date without asking the node
+	following atomic.Bool
+}
+
+// set records number as fetched at now
+func (bc *blockNumberCache) set(number int, now time.Time) {
+	bc.number.Store(int64(number))
+	bc.fetchedAt.Store(now.UnixNano())
+}
+
+// fresh gets the cached number when it was set less than ttl before now
+func (bc *blockNumberCache) fresh(now time.Time) (int, bool) {
+	fetchedAt := bc.fetchedAt.Load()
+	if fetchedAt == 0 || now.Sub(time.Unix(0, fetchedAt)) >= bc.ttl {
+		return 0, false
+	}
+
+	return int(bc.number.Load()), true
+}
+
+// push records a block number pushed by the node
+func (bc *blockNumberCache) push(number int, now time.Time) {
+	bc.set(number, now)
+	bc.following.Store(true)
+}
+
+// unfollow falls back to asking the node once the pushed heads stop
+func (bc *blockNumberCache) unfollow() {
+	bc.following.Store(false)
+}
+
+// WithBlockNumberTTL sets how long the current block number is reused
+// before asking the node again, zero asking the node on every call
+func WithBlockNumberTTL(ttl time.Duration) EthParserOpt {
+	return func(p *ethParser) error {
+		if ttl < 0 {
+			return errors.New("block number ttl cannot be negative")
+		}
+		p.blockNumber.ttl = ttl
+		return nil
+	}
+}
+
+// getCurrentBlockNumber gets the current block number, fetched at most once
+// per ttl unless the node pushes new heads
+func (e *ethParser) getCurrentBlockNumber(ctx context.Context) (int, error) {
+	bc := e.blockNumber
+
+	if bc.following.Load() {
+		return int(bc.number.Load()), nil
+	}
+
+	if bc.ttl == 0 {
+		return e.fetchCurrentBlockNumber(ctx)
+	}
+
+	if number, ok := bc.fresh(e.clock.Now()); ok {
+		return number, nil
+	}
+
+	// the fetch is shared, so a caller giving up must not fail the others,
+	// and is canceled once they all give up
+	number, err := bc.group.do(ctx, e.closed, "", func(ctx context.Context) (interface{}, error) {
+		number, err := e.fetchCurrentBlockNumber(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		bc.set(number, e.clock.Now())
+
+		return number, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return number.(int), nil
+}
+
+// Ping checks that the node answers, reusing the current block number for
+// its ttl so it is cheap enough for frequent health checks
+func (e *ethParser) Ping(ctx context.Context) error {
+	_, err := e.getCurrentBlockNumber(ctx)
+	return err
+}
@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserBlockNumberTTL(t *testing.T) {
+	node, requests := newFlakyNode(t, 0, 0)
+
+	clock := newFakeClock()
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(2*time.Second), WithClock(clock))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			blockNumber, err := parser.GetCurrentBlock()
+			require.NoError(t, err)
+			require.Equal(t, 0x13ecaeb, blockNumber)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), requests.Load())
+
+	clock.Advance(time.Second)
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, int32(1), requests.Load())
+
+	clock.Advance(time.Second)
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, int32(2), requests.Load())
+}
+
+func TestParserBlockNumberCallerCancelled(t *testing.T) {
+	blocked, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		once.Do(func() {
+			close(blocked)
+			<-release
+		})
+		return "0x10"
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	type result struct {
+		blockNumber int
+		err         error
+	}
+	results := make(chan result, 2)
+	get := func(ctx context.Context) {
+		blockNumber, err := parser.GetCurrentBlockCtx(ctx)
+		results <- result{blockNumber: blockNumber, err: err}
+	}
+
+	firstCtx, cancel := context.WithCancel(context.Background())
+	first := newJoinedContext(firstCtx)
+	go get(first)
+	<-blocked
+	<-first.joined
+	second := newJoinedContext(context.Background())
+	go get(second)
+	<-second.joined
+
+	// the caller waiting for the fetch the first one started still gets
+	// the block number
+	cancel()
+	require.ErrorIs(t, (<-results).err, context.Canceled)
+	close(release)
+
+	r := <-results
+	require.NoError(t, r.err)
+	require.Equal(t, 0x10, r.blockNumber)
+}
+
+func TestParserBlockNumberCallersGiveUp(t *testing.T) {
+	blocked, release := make(chan struct{}), make(chan struct{})
+	var claimed atomic.Bool
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		if claimed.CompareAndSwap(false, true) {
+			close(blocked)
+			<-release
+		}
+		return "0x10"
+	})
+	t.Cleanup(func() { close(release) })
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := parser.GetCurrentBlockCtx(ctx)
+		errs <- err
+	}()
+	<-blocked
+
+	// the fetch is canceled along with its only caller, the next one
+	// fetching again rather than waiting for it
+	cancel()
+	require.ErrorIs(t, <-errs, context.Canceled)
+
+	blockNumber, err := parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, 0x10, blockNumber)
+}
+
+func TestParserBlockNumberTTLDisabled(t *testing.T) {
+	node, requests := newFlakyNode(t, 0, 0)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := parser.GetCurrentBlock()
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(3), requests.Load())
+
+	_, err = NewEthParser(WithBlockNumberTTL(-time.Second))
+	require.Error(t, err)
+}
+
+func TestParserPing(t *testing.T) {
+	node, requests := newFlakyNode(t, 1, http.StatusInternalServerError)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRetry(1, time.Millisecond))
+	require.NoError(t, err)
+
+	require.Error(t, parser.Ping(context.Background()))
+	require.NoError(t, parser.Ping(context.Background()))
+
+	// the block number is reused for its ttl
+	require.NoError(t, parser.Ping(context.Background()))
+	require.Equal(t, int32(2), requests.Load())
+}
+
+func TestParserCurrentBlockNumberHex(t *testing.T) {
+	tests := []struct {
+		result  string
+		want    int
+		wantErr bool
+	}{
+		{result: "0x13ecaeb", want: 20892395},
+		{result: "0x0", want: 0},
+		{result: "0x", want: 0},
+		{result: " 0x10 ", want: 16},
+		{result: "", wantErr: true},
+		{result: "0xzz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		node := newTestNode(t, func(method string, params []interface{}) interface{} {
+			return tt.result
+		})
+
+		parser, err := NewEthParser(WithNodeUrl(node.URL))
+		require.NoError(t, err)
+
+		got, err := parser.GetCurrentBlock()
+		if tt.wantErr {
+			// the error names what the node answered
+			require.ErrorContains(t, err, fmt.Sprintf("%q", tt.result), tt.result)
+			continue
+		}
+		require.NoError(t, err, tt.result)
+		require.Equal(t, tt.want, got, tt.result)
+	}
+}
+
+func TestParserCurrentBlockWithoutAddressesLock(t *testing.T) {
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		return "0x10"
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	blockNumber, err := parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, 16, blockNumber)
+
+	// a cached block number is read while a Subscribe holds the lock
+	parser.m.Lock()
+	defer parser.m.Unlock()
+
+	blockNumber, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, 16, blockNumber)
+}
+
+func BenchmarkGetCurrentBlockSubscribing(b *testing.B) {
+	node := newTestNode(b, func(method string, params []interface{}) interface{} {
+		return "0x10"
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(b, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.NoError(b, err)
+
+	// Subscribe bursts keep taking the addresses lock meanwhile
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for n := 0; ctx.Err() == nil; n++ {
+				address := fmt.Sprintf("0x%040x", i<<32|n)
+				parser.Subscribe(address)
+				parser.Unsubscribe(address)
+			}
+		}()
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := parser.GetCurrentBlock(); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+	b.StopTimer()
+
+	cancel()
+	wg.Wait()
+}
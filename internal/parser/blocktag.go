@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+
+	"ethparser/internal/models"
+)
+
+// BlockTag names a block relative to the state of the node rather than by
+// its number
+type BlockTag string
+
+const (
+	// LatestBlock is the most recent mined block
+	LatestBlock BlockTag = "latest"
+	// PendingBlock is the block the node is building from its mempool
+	PendingBlock BlockTag = "pending"
+	// EarliestBlock is the genesis block
+	EarliestBlock BlockTag = "earliest"
+)
+
+// ParseBlockTag parses the "latest", "pending" and "earliest" block tags
+func ParseBlockTag(s string) (BlockTag, error) {
+	switch tag := BlockTag(s); tag {
+	case LatestBlock, PendingBlock, EarliestBlock:
+		return tag, nil
+	default:
+		return "", fmt.Errorf("invalid block tag: %q", s)
+	}
+}
+
+func (e *ethParser) GetBlockByTag(tag BlockTag) (*models.BlockWithDetails, error) {
+	return e.GetBlockByTagCtx(context.Background(), tag)
+}
+
+// GetBlockByTagCtx gets the block tag refers to, passing the tag to the node
+// as is rather than resolving it to a block number first
+func (e *ethParser) GetBlockByTagCtx(ctx context.Context, tag BlockTag) (*models.BlockWithDetails, error) {
+	if _, err := ParseBlockTag(string(tag)); err != nil {
+		return nil, err
+	}
+
+	block, err := e.fetchBlock(ctx, string(tag))
+	if err != nil {
+		return nil, err
+	}
+
+	// the node answers null when it has no pending block
+	if block.Number == "" {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, tag)
+	}
+
+	return block, nil
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParseBlockTag(t *testing.T) {
+	for _, tag := range []BlockTag{LatestBlock, PendingBlock, EarliestBlock} {
+		got, err := ParseBlockTag(string(tag))
+		require.NoError(t, err)
+		require.Equal(t, tag, got)
+	}
+
+	for _, s := range []string{"", "Latest", "0x10", "safe"} {
+		_, err := ParseBlockTag(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestParserGetBlockByTag(t *testing.T) {
+	blocks := map[string]models.BlockWithDetails{
+		"latest":   {Hash: blockHash(101), Number: "0x65"},
+		"earliest": {Hash: blockHash(0), Number: "0x0"},
+		// pending blocks have no hash yet
+		"pending": {Number: "0x66", Transactions: []models.Transaction{{Hash: "0x102", To: address}}},
+	}
+	var noPending atomic.Bool
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		require.Equal(t, "eth_getBlockByNumber", method)
+		if params[0] == "pending" && noPending.Load() {
+			return nil
+		}
+		return blocks[params[0].(string)]
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	tests := []struct {
+		tag    BlockTag
+		number int
+	}{
+		{tag: LatestBlock, number: 101},
+		{tag: PendingBlock, number: 102},
+		{tag: EarliestBlock, number: 0},
+	}
+
+	for _, tt := range tests {
+		block, err := parser.GetBlockByTag(tt.tag)
+		require.NoError(t, err, tt.tag)
+
+		number, err := models.ParseHexInt(block.Number)
+		require.NoError(t, err, tt.tag)
+		require.Equal(t, tt.number, number, tt.tag)
+	}
+
+	_, err = parser.GetBlockByTag("safe")
+	require.Error(t, err)
+
+	// the node answers null when it has no pending block
+	noPending.Store(true)
+	_, err = parser.GetBlockByTag(PendingBlock)
+	require.ErrorIs(t, err, ErrBlockNotFound)
+}
@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without asking the node while the circuit
+// breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type circuitState int
+
+const (
+	// circuitClosed lets every request through
+	circuitClosed circuitState = iota
+	// circuitOpen fails every request until the cooldown is over
+	circuitOpen
+	// circuitHalfOpen lets a single probe through, closing the circuit
+	// when it succeeds and opening it again when it fails
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker stops sending requests to a failing node for a cooldown
+// once threshold requests in a row failed, a zero threshold disabling it
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	m     sync.Mutex
+	state circuitState
+	// failures counts the consecutive failed requests while closed
+	failures int
+	openedAt time.Time
+	// probing is set while the probe of the half-open circuit is in flight
+	probing bool
+}
+
+// WithCircuitBreaker fails requests with ErrCircuitOpen for cooldown once
+// failures requests in a row failed, every retry included, then lets a
+// single request through to probe the node, closing the circuit again if it
+// succeeds
+func WithCircuitBreaker(failures int, cooldown time.Duration) EthParserOpt {
+	return func(p *ethParser) error {
+		if failures < 1 {
+			return errors.New("circuit breaker failures must be positive")
+		}
+		if cooldown <= 0 {
+			return errors.New("circuit breaker cooldown must be positive")
+		}
+		p.breaker.threshold = failures
+		p.breaker.cooldown = cooldown
+		return nil
+	}
+}
+
+// allow reports whether a request may be sent at now, making it the probe
+// when the cooldown of the open circuit is over
+func (cb *circuitBreaker) allow(now time.Time) error {
+	cb.m.Lock()
+	defer cb.m.Unlock()
+
+	switch {
+	case cb.threshold == 0 || cb.state == circuitClosed:
+		return nil
+	case cb.state == circuitOpen && now.Sub(cb.openedAt) < cb.cooldown:
+		return ErrCircuitOpen
+	case cb.state == circuitHalfOpen && cb.probing:
+		return ErrCircuitOpen
+	}
+
+	cb.state = circuitHalfOpen
+	cb.probing = true
+	return nil
+}
+
+// record records the outcome of a request allowed at now, returning the
+// state of the circuit when it changed
+func (cb *circuitBreaker) record(failed bool, now time.Time) (circuitState, bool) {
+	cb.m.Lock()
+	defer cb.m.Unlock()
+
+	if cb.threshold == 0 {
+		return cb.state, false
+	}
+
+	previous := cb.state
+	cb.probing = false
+	switch {
+	case !failed:
+		cb.state = circuitClosed
+		cb.failures = 0
+	case cb.state == circuitHalfOpen:
+		cb.state = circuitOpen
+		cb.openedAt = now
+	default:
+		cb.failures++
+		if cb.failures >= cb.threshold {
+			cb.state = circuitOpen
+			cb.openedAt = now
+			cb.failures = 0
+		}
+	}
+
+	return cb.state, cb.state != previous
+}
+
+// release gives up the probe of the half-open circuit without an outcome,
+// as when its caller went away, letting the next request probe instead
+func (cb *circuitBreaker) release() {
+	cb.m.Lock()
+	defer cb.m.Unlock()
+
+	cb.probing = false
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserCircuitBreaker(t *testing.T) {
+	node, requests := newFlakyNode(t, 4, http.StatusServiceUnavailable)
+	clock := newFakeClock()
+
+	parser, err := NewEthParser(
+		WithNodeUrl(node.URL),
+		WithRetry(1, time.Millisecond),
+		WithBlockNumberTTL(0),
+		WithClock(clock),
+		WithCircuitBreaker(3, time.Minute),
+	)
+	require.NoError(t, err)
+
+	// closed, until 3 requests in a row failed
+	for i := 0; i < 3; i++ {
+		_, err := parser.GetCurrentBlock()
+		require.ErrorContains(t, err, "unexpected status code: 503")
+	}
+	require.Equal(t, int32(3), requests.Load())
+
+	// open, failing without asking the node
+	_, err = parser.GetCurrentBlock()
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.Equal(t, int32(3), requests.Load())
+
+	// half-open, the failed probe opening it again
+	clock.Advance(time.Minute)
+	_, err = parser.GetCurrentBlock()
+	require.ErrorContains(t, err, "unexpected status code: 503")
+	require.Equal(t, int32(4), requests.Load())
+
+	_, err = parser.GetCurrentBlock()
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	// half-open, the probe succeeding closes it
+	clock.Advance(time.Minute)
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, int32(6), requests.Load())
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cb := &circuitBreaker{threshold: 1, cooldown: time.Minute}
+
+	require.NoError(t, cb.allow(now))
+	state, changed := cb.record(true, now)
+	require.True(t, changed)
+	require.Equal(t, circuitOpen, state)
+
+	now = now.Add(time.Minute)
+	require.NoError(t, cb.allow(now))
+	// the probe is in flight
+	require.ErrorIs(t, cb.allow(now), ErrCircuitOpen)
+
+	// the probe was given up, the next request probes instead
+	cb.release()
+	require.NoError(t, cb.allow(now))
+	state, changed = cb.record(false, now)
+	require.True(t, changed)
+	require.Equal(t, circuitClosed, state)
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	_, err := NewEthParser(WithCircuitBreaker(0, time.Minute))
+	require.Error(t, err)
+
+	_, err = NewEthParser(WithCircuitBreaker(3, 0))
+	require.Error(t, err)
+
+	// disabled by default
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		parser.breaker.record(true, time.Now())
+	}
+	require.NoError(t, parser.breaker.allow(time.Now()))
+}
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ethparser/internal/models"
+)
+
+// budgetWindowSize is the number of blocks walked at a time under a time
+// budget, the blocks of an unfinished window being fetched again later
+const budgetWindowSize = 10
+
+// PartialTransactions are the transactions of an address fetched within a
+// time budget
+type PartialTransactions struct {
+	Transactions []*models.Transaction `json:"transactions"`
+	// Truncated is set when the budget ran out before every block was scanned
+	Truncated bool `json:"truncated"`
+	// ResumeFrom is the highest block left to scan when Truncated, the next
+	// call resuming the walk down from it
+	ResumeFrom int `json:"resumeFrom,omitempty"`
+	// FromBlock and ToBlock are the inclusive range of blocks scanned
+	// without gaps from the block the address was subscribed at, ToBlock
+	// being below FromBlock when none was
+	FromBlock int `json:"fromBlock"`
+	ToBlock   int `json:"toBlock"`
+}
+
+// GetTransactionsWithBudget is GetTransactionsCtx returning whatever
+// transactions could be fetched within budget rather than waiting for the
+// whole walk
+func (e *ethParser) GetTransactionsWithBudget(ctx context.Context, address string, budget time.Duration) (*PartialTransactions, error) {
+	address, err := e.parseSubscription(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if budget <= 0 {
+		return nil, errors.New("time budget must be positive")
+	}
+
+	return e.getTransactions(ctx, address, budget, nil)
+}
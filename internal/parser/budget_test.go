@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+// slowTransport delays every request sent to the node
+type slowTransport struct {
+	delay time.Duration
+}
+
+func (st *slowTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case <-time.After(st.delay):
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestParserGetTransactionsWithBudget(t *testing.T) {
+	_, node := newTestChain(t, 150, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+		125: {{Hash: "0x125", To: address}},
+		149: {{Hash: "0x149", From: address}},
+	})
+
+	parser, err := NewEthParser(
+		WithNodeUrl(node.URL),
+		WithHTTPClient(&http.Client{Transport: &slowTransport{delay: 10 * time.Millisecond}}),
+		WithRateLimit(math.Inf(1), 1),
+	)
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	// scanning the 51 blocks takes over 500ms
+	result, err := parser.GetTransactionsWithBudget(context.Background(), address, 200*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, result.Truncated)
+	require.Less(t, result.ResumeFrom, 150)
+	require.GreaterOrEqual(t, result.ResumeFrom, 100)
+
+	for _, tx := range result.Transactions {
+		blockNumber, err := models.ParseHexInt(tx.BlockNumber)
+		require.NoError(t, err)
+		require.Greater(t, blockNumber, result.ResumeFrom)
+	}
+
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Equal(t, []BlockRange{{From: result.ResumeFrom + 1, To: 150}}, ranges)
+
+	// a later call resumes where the walk stopped
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	require.ElementsMatch(t, []string{"0x101", "0x125", "0x149"}, hashes)
+
+	_, err = parser.GetTransactionsWithBudget(context.Background(), address, 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"ethparser/internal/models"
+)
+
+// ErrChainMismatch is returned by NewEthParser when the node is on another
+// chain than the one set by WithExpectedChainID
+var ErrChainMismatch = errors.New("node is on an unexpected chain")
+
+type JsonRPCResponseChainID struct {
+	Result string `json:"result"`
+}
+
+// chainIDCache memoizes the chain id of the node, which does not change
+type chainIDCache struct {
+	m  sync.Mutex
+	id int64
+}
+
+// WithExpectedChainID makes NewEthParser ask the node for its chain id and
+// fail with ErrChainMismatch when it is not id, as 1 for mainnet, rather
+// than silently parsing the blocks of another network
+func WithExpectedChainID(id int64) EthParserOpt {
+	return func(p *ethParser) error {
+		if id <= 0 {
+			return errors.New("expected chain id must be positive")
+		}
+		p.expectedChainID = id
+		return nil
+	}
+}
+
+func (e *ethParser) ChainID() (int64, error) {
+	return e.ChainIDCtx(context.Background())
+}
+
+// ChainIDCtx gets the EIP-155 chain id of the node with eth_chainId, asking
+// the node only until it answers
+func (e *ethParser) ChainIDCtx(ctx context.Context) (int64, error) {
+	e.chainID.m.Lock()
+	defer e.chainID.m.Unlock()
+
+	if e.chainID.id != 0 {
+		return e.chainID.id, nil
+	}
+
+	rpcRequest := JsonRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_chainId",
+		Params:  []interface{}{},
+	}
+
+	rpcResponse, err := do[JsonRPCResponseChainID](ctx, e, rpcRequest)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := models.ParseHexUint64(rpcResponse.Result)
+	if err != nil || id == 0 || id > 1<<63-1 {
+		return 0, fmt.Errorf("invalid chain id: %q", rpcResponse.Result)
+	}
+
+	e.chainID.id = int64(id)
+	return e.chainID.id, nil
+}
+
+// checkChainID fails when the node is not on the expected chain, if set
+func (e *ethParser) checkChainID(ctx context.Context) error {
+	if e.expectedChainID == 0 {
+		return nil
+	}
+
+	id, err := e.ChainIDCtx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check the chain id: %w", err)
+	}
+
+	if id != e.expectedChainID {
+		return fmt.Errorf("%w: chain id %d, expected %d", ErrChainMismatch, id, e.expectedChainID)
+	}
+
+	return nil
+}
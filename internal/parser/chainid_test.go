@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newChainIDNode serves a node on the chain with the given hex id, counting
+// the eth_chainId calls
+func newChainIDNode(t *testing.T, id string) (string, *atomic.Int64) {
+	t.Helper()
+
+	var calls atomic.Int64
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		if method == "eth_chainId" {
+			calls.Add(1)
+			return id
+		}
+		return nil
+	})
+
+	return node.URL, &calls
+}
+
+func TestParserChainID(t *testing.T) {
+	url, calls := newChainIDNode(t, "0xaa36a7")
+
+	parser, err := NewEthParser(WithNodeUrl(url))
+	require.NoError(t, err)
+	require.Zero(t, calls.Load())
+
+	for i := 0; i < 2; i++ {
+		id, err := parser.ChainID()
+		require.NoError(t, err)
+		require.Equal(t, int64(11155111), id)
+	}
+	// the chain id is asked once
+	require.Equal(t, int64(1), calls.Load())
+}
+
+func TestParserExpectedChainID(t *testing.T) {
+	url, calls := newChainIDNode(t, "0x1")
+
+	parser, err := NewEthParser(WithNodeUrl(url), WithExpectedChainID(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), calls.Load())
+
+	id, err := parser.ChainID()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), id)
+	require.Equal(t, int64(1), calls.Load())
+
+	_, err = NewEthParser(WithNodeUrl(url), WithExpectedChainID(11155111))
+	require.ErrorIs(t, err, ErrChainMismatch)
+	require.ErrorContains(t, err, "chain id 1, expected 11155111")
+
+	_, err = NewEthParser(WithExpectedChainID(0))
+	require.Error(t, err)
+}
+
+func TestParserChainIDInvalid(t *testing.T) {
+	for _, id := range []string{"", "0x0", "mainnet"} {
+		url, _ := newChainIDNode(t, id)
+
+		parser, err := NewEthParser(WithNodeUrl(url))
+		require.NoError(t, err)
+
+		_, err = parser.ChainID()
+		require.ErrorContains(t, err, "invalid chain id", id)
+	}
+}
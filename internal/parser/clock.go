@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"errors"
+	"time"
+)
+
+// Clock tells the time and waits, so that tests can control both
+type Clock interface {
+	Now() time.Time
+	// After sends the time on the returned channel once d elapsed
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the wall clock of the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithClock sets the clock used for retry backoffs, the block number TTL,
+// the cache TTL and the poller schedule, the wall clock otherwise
+func WithClock(clock Clock) EthParserOpt {
+	return func(p *ethParser) error {
+		if clock == nil {
+			return errors.New("clock cannot be nil")
+		}
+		p.clock = clock
+		return nil
+	}
+}
@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a Clock whose time only moves when advanced
+type fakeClock struct {
+	m       sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (fc *fakeClock) Now() time.Time {
+	fc.m.Lock()
+	defer fc.m.Unlock()
+
+	return fc.now
+}
+
+func (fc *fakeClock) After(d time.Duration) <-chan time.Time {
+	fc.m.Lock()
+	defer fc.m.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- fc.now
+		return ch
+	}
+
+	fc.waiters = append(fc.waiters, fakeWaiter{at: fc.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the time forward by d, firing the waits it ends
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.m.Lock()
+	defer fc.m.Unlock()
+
+	fc.now = fc.now.Add(d)
+
+	waiters := fc.waiters[:0]
+	for _, w := range fc.waiters {
+		if w.at.After(fc.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- fc.now
+	}
+	fc.waiters = waiters
+}
+
+// waitForWaiters blocks until n calls to After are pending
+func (fc *fakeClock) waitForWaiters(t *testing.T, n int) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		fc.m.Lock()
+		defer fc.m.Unlock()
+
+		return len(fc.waiters) == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestParserRetryBackoffWithClock(t *testing.T) {
+	node, requests := newFlakyNode(t, 2, http.StatusServiceUnavailable)
+
+	clock := newFakeClock()
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRetry(3, time.Hour), WithClock(clock))
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := parser.GetCurrentBlock()
+		done <- err
+	}()
+
+	// each retry waits on the clock, which only moves when advanced, for
+	// at least half of the doubling delay
+	for attempt := 1; attempt <= 2; attempt++ {
+		delay := time.Hour << (attempt - 1)
+
+		clock.waitForWaiters(t, 1)
+		clock.Advance(delay/2 - time.Nanosecond)
+		require.Equal(t, int32(attempt), requests.Load())
+
+		clock.Advance(delay/2 + time.Nanosecond)
+		require.Eventually(t, func() bool {
+			return requests.Load() == int32(attempt+1)
+		}, time.Second, time.Millisecond)
+	}
+
+	require.NoError(t, <-done)
+	require.Equal(t, int32(3), requests.Load())
+}
+
+func TestWithClockNil(t *testing.T) {
+	_, err := NewEthParser(WithClock(nil))
+	require.Error(t, err)
+}
+
+func TestParserCacheTTLWithClock(t *testing.T) {
+	clock := newFakeClock()
+	// the clock applies whichever of the options comes first
+	parser, err := NewEthParser(WithCacheTTL(time.Minute), WithClock(clock))
+	require.NoError(t, err)
+
+	parser.transactionCache.AddTransactions(address, nil, 10)
+	_, blockNumber := parser.transactionCache.GetTransactions(address)
+	require.Equal(t, 10, blockNumber)
+
+	clock.Advance(time.Minute)
+	_, blockNumber = parser.transactionCache.GetTransactions(address)
+	require.Zero(t, blockNumber)
+
+	_, err = NewEthParser(WithCacheTTL(0))
+	require.Error(t, err)
+}
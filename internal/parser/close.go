@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"context"
+	"io"
+)
+
+// runBackground runs fn in a goroutine until ctx is done or the parser is
+// closed, Close waiting for it to return
+func (e *ethParser) runBackground(ctx context.Context, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(e.closed, cancel)
+
+	e.running.Add(1)
+	go func() {
+		defer e.running.Done()
+		defer stop()
+		defer cancel()
+
+		fn(ctx)
+	}()
+}
+
+// Close stops the background work started by Start, closing the WebSocket
+// new heads are followed on, waits for it to return, then releases the idle
+// node connections and closes the cache when it is an io.Closer. The parser
+// must not be used after Close.
+func (e *ethParser) Close() error {
+	e.closeOnce.Do(func() {
+		e.close()
+		e.running.Wait()
+
+		e.client.CloseIdleConnections()
+		if closer, ok := e.transactionCache.(io.Closer); ok {
+			e.closeErr = closer.Close()
+		}
+	})
+
+	return e.closeErr
+}
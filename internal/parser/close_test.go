@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/cache"
+)
+
+type closingCache struct {
+	cache.Cache
+	closes atomic.Int32
+}
+
+func (cc *closingCache) Close() error {
+	cc.closes.Add(1)
+	return nil
+}
+
+func TestParserCloseStopsPolling(t *testing.T) {
+	parser, err := NewEthParser(WithPollInterval(10 * time.Millisecond))
+	require.NoError(t, err)
+
+	var polls atomic.Int32
+	parser.pollAddress = func(ctx context.Context, address string) {}
+	parser.pollBlocks = func(ctx context.Context, lastBlock int) int {
+		polls.Add(1)
+		return lastBlock
+	}
+	parser.addresses[address] = subscription{blockNumber: 1}
+
+	parser.Start(context.Background())
+	require.Eventually(t, func() bool {
+		return polls.Load() > 0
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, parser.Close())
+
+	stopped := polls.Load()
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, stopped, polls.Load())
+}
+
+func TestParserCloseClosesCache(t *testing.T) {
+	c := &closingCache{Cache: cache.NewMemCache()}
+	parser, err := NewEthParser(WithCache(c))
+	require.NoError(t, err)
+
+	require.NoError(t, parser.Close())
+	require.NoError(t, parser.Close())
+	require.Equal(t, int32(1), c.closes.Load())
+}
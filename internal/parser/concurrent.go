@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+
+	"ethparser/internal/models"
+)
+
+// getTransactionsConcurrently gets transactions from endingBlockNumber to
+// headBlockNumber, splitting the range into disjoint chunks fetched by at
+// most e.concurrency goroutines. Results are sorted by block number.
+func (e *ethParser) getTransactionsConcurrently(ctx context.Context, endingBlockNumber, headBlockNumber int, match matchFunc) ([]*models.Transaction, error) {
+	chunkSize := e.batchSize
+	if chunkSize == 0 {
+		chunkSize = (headBlockNumber - endingBlockNumber + e.concurrency) / e.concurrency
+	}
+
+	var chunks []BlockRange
+	for from := endingBlockNumber; from <= headBlockNumber; from += chunkSize {
+		chunks = append(chunks, BlockRange{From: from, To: min(from+chunkSize-1, headBlockNumber)})
+	}
+
+	results := make([][]*models.Transaction, len(chunks))
+	sem := make(chan struct{}, e.concurrency)
+	g, ctx := errgroup.WithContext(ctx)
+
+	for i, chunk := range chunks {
+		g.Go(func() error {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer func() { <-sem }()
+
+			transactions, err := e.getTransactionsInChunk(ctx, chunk, headBlockNumber, match)
+			if err != nil {
+				return err
+			}
+
+			results[i] = transactions
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var allTransactions []*models.Transaction
+	for _, transactions := range results {
+		allTransactions = append(allTransactions, transactions...)
+	}
+
+	models.SortTransactions(allTransactions)
+
+	return allTransactions, nil
+}
+
+// getTransactionsInChunk gets transactions from the blocks of chunk by
+// number, headBlockNumber being the head of the range the chunk is part of
+func (e *ethParser) getTransactionsInChunk(ctx context.Context, chunk BlockRange, headBlockNumber int, match matchFunc) ([]*models.Transaction, error) {
+	if e.batchSize > 0 {
+		return e.getTransactionsInBatches(ctx, chunk.From, chunk.To, headBlockNumber, match)
+	}
+
+	var allTransactions []*models.Transaction
+	for blockNumber := chunk.From; blockNumber <= chunk.To; blockNumber++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		e.logger.Debug("fetching transactions", "blockNumber", blockNumber)
+
+		block, err := e.getBlockFromNumber(ctx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		if block.Number == "" {
+			return nil, nullBlockError(blockNumber, headBlockNumber)
+		}
+
+		transactions, err := e.getTransactionsFromBlock(block, match)
+		if err != nil {
+			return nil, err
+		}
+		allTransactions = append(allTransactions, transactions...)
+	}
+
+	return allTransactions, nil
+}
@@ -0,0 +1,184 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"ethparser/internal/cache"
+)
+
+// Config is the JSON file read by LoadConfig, every field being optional
+// and left to the parser default when missing
+type Config struct {
+	// NodeURL is the JSON-RPC endpoint of the node
+	NodeURL string `json:"nodeUrl"`
+	// Timeout bounds every request sent to the node, as a duration such as
+	// "10s"
+	Timeout *Duration `json:"timeout"`
+	// Concurrency is the number of goroutines fetching a block range
+	Concurrency *int `json:"concurrency"`
+	// RateLimit caps the requests sent to the node per second
+	RateLimit *float64 `json:"rateLimit"`
+	// RateBurst is the number of requests sent at once within RateLimit,
+	// RateLimit when missing
+	RateBurst *int `json:"rateBurst"`
+	// Confirmations is the depth below the head transactions are returned
+	// from
+	Confirmations *int `json:"confirmations"`
+	// Cache is the transaction cache
+	Cache *CacheConfig `json:"cache"`
+}
+
+// CacheConfig is the transaction cache of a Config
+type CacheConfig struct {
+	// Type is "memory" or "sqlite"
+	Type string `json:"type"`
+	// Size is the number of addresses a memory cache tracks, 0 for no limit
+	Size int `json:"size"`
+	// Path is the database file of a sqlite cache
+	Path string `json:"path"`
+}
+
+// Duration is a time.Duration written in JSON as a string such as "10s"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.New("duration must be a string such as \"10s\"")
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LoadConfig reads the JSON config file at path and returns the options it
+// sets, to be passed to NewEthParser. Unknown fields and out of range values
+// are rejected.
+func LoadConfig(path string) ([]EthParserOpt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return nil, fmt.Errorf("config %s: unexpected data after the config object", path)
+	}
+
+	opts, err := cfg.Opts()
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+
+	return opts, nil
+}
+
+// Opts validates cfg and returns the options it sets
+func (cfg Config) Opts() ([]EthParserOpt, error) {
+	var opts []EthParserOpt
+	if cfg.NodeURL != "" {
+		opts = append(opts, WithNodeUrl(cfg.NodeURL))
+	}
+
+	if cfg.Timeout != nil {
+		if *cfg.Timeout < 0 {
+			return nil, errors.New("timeout cannot be negative")
+		}
+		opts = append(opts, WithTimeout(time.Duration(*cfg.Timeout)))
+	}
+
+	if cfg.Concurrency != nil {
+		if *cfg.Concurrency < 1 {
+			return nil, fmt.Errorf("concurrency must be positive, got %d", *cfg.Concurrency)
+		}
+		opts = append(opts, WithConcurrency(*cfg.Concurrency))
+	}
+
+	if cfg.RateBurst != nil && cfg.RateLimit == nil {
+		return nil, errors.New("rateBurst requires rateLimit")
+	}
+	if cfg.RateLimit != nil {
+		if *cfg.RateLimit <= 0 {
+			return nil, fmt.Errorf("rateLimit must be positive, got %v", *cfg.RateLimit)
+		}
+		burst := max(int(*cfg.RateLimit), 1)
+		if cfg.RateBurst != nil {
+			if *cfg.RateBurst < 1 {
+				return nil, fmt.Errorf("rateBurst must be at least 1, got %d", *cfg.RateBurst)
+			}
+			burst = *cfg.RateBurst
+		}
+		opts = append(opts, WithRateLimit(*cfg.RateLimit, burst))
+	}
+
+	if cfg.Confirmations != nil {
+		if *cfg.Confirmations < 0 {
+			return nil, fmt.Errorf("confirmations cannot be negative, got %d", *cfg.Confirmations)
+		}
+		opts = append(opts, WithConfirmations(*cfg.Confirmations))
+	}
+
+	if cfg.Cache != nil {
+		opt, err := cfg.Cache.opt()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+
+	return opts, nil
+}
+
+// opt returns the WithCache option of the cache described by c
+func (c CacheConfig) opt() (EthParserOpt, error) {
+	switch c.Type {
+	case "memory":
+		if c.Size < 0 {
+			return nil, fmt.Errorf("cache size cannot be negative, got %d", c.Size)
+		}
+		if c.Path != "" {
+			return nil, errors.New("cache path is only used by the sqlite cache")
+		}
+		return WithCache(cache.NewMemCacheWithCapacity(c.Size)), nil
+	case "sqlite":
+		if c.Path == "" {
+			return nil, errors.New("sqlite cache path cannot be empty")
+		}
+		if c.Size != 0 {
+			return nil, errors.New("cache size is only used by the memory cache")
+		}
+		// the database is opened when the option is applied, not when the
+		// config is loaded
+		path := c.Path
+		return func(p *ethParser) error {
+			sqliteCache, err := cache.NewSQLiteCache(path)
+			if err != nil {
+				return err
+			}
+			p.transactionCache = sqliteCache
+			return nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("cache type must be \"memory\" or \"sqlite\", got %q", c.Type)
+	}
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestLoadConfig(t *testing.T) {
+	opts, err := LoadConfig(filepath.Join("testdata", "config.json"))
+	require.NoError(t, err)
+
+	parser, err := NewEthParser(opts...)
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8545", parser.nodes.endpoints[0].url)
+	require.Equal(t, 5*time.Second, parser.timeout)
+	require.Equal(t, 4, parser.concurrency)
+	require.Equal(t, rate.Limit(25), parser.limiter.Limit())
+	require.Equal(t, 50, parser.limiter.Burst())
+	require.Equal(t, 6, parser.confirmations)
+	require.NotNil(t, parser.transactionCache)
+}
+
+func TestLoadConfigSQLiteCache(t *testing.T) {
+	path := writeConfig(t, `{"cache": {"type": "sqlite", "path": "`+filepath.Join(t.TempDir(), "cache.db")+`"}}`)
+
+	opts, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	parser, err := NewEthParser(opts...)
+	require.NoError(t, err)
+	require.NoError(t, parser.Close())
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	for config, want := range map[string]string{
+		`{"nodeUrl": "http://localhost:8545", "timout": "5s"}`: `unknown field "timout"`,
+		`{"timeout": 5}`:                            "duration must be a string",
+		`{"timeout": "-1s"}`:                        "timeout cannot be negative",
+		`{"concurrency": 0}`:                        "concurrency must be positive",
+		`{"rateLimit": 0}`:                          "rateLimit must be positive",
+		`{"rateLimit": 10, "rateBurst": 0}`:         "rateBurst must be at least 1",
+		`{"rateBurst": 10}`:                         "rateBurst requires rateLimit",
+		`{"confirmations": -1}`:                     "confirmations cannot be negative",
+		`{"cache": {"type": "redis"}}`:              `cache type must be "memory" or "sqlite"`,
+		`{"cache": {"type": "sqlite"}}`:             "sqlite cache path cannot be empty",
+		`{"cache": {"type": "memory", "size": -1}}`: "cache size cannot be negative",
+		`{} {}`: "unexpected data",
+	} {
+		_, err := LoadConfig(writeConfig(t, config))
+		require.ErrorContains(t, err, want, config)
+	}
+
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// writeConfig writes config to a file in a temporary directory and returns
+// its path
+func writeConfig(t *testing.T, config string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+	return path
+}
@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+
+	"ethparser/internal/models"
+)
+
+// Direction selects transactions by the side of the address they're on
+type Direction int
+
+const (
+	// All selects the transactions from or to the address
+	All Direction = iota
+	// Inbound selects the transactions to the address
+	Inbound
+	// Outbound selects the transactions from the address
+	Outbound
+)
+
+func (d Direction) String() string {
+	switch d {
+	case All:
+		return "all"
+	case Inbound:
+		return "in"
+	case Outbound:
+		return "out"
+	default:
+		return fmt.Sprintf("Direction(%d)", int(d))
+	}
+}
+
+// ParseDirection parses the "all", "in" and "out" forms of a Direction
+func ParseDirection(s string) (Direction, error) {
+	switch s {
+	case "all":
+		return All, nil
+	case "in":
+		return Inbound, nil
+	case "out":
+		return Outbound, nil
+	default:
+		return 0, fmt.Errorf("invalid direction: %q", s)
+	}
+}
+
+func (e *ethParser) GetTransactionsFiltered(address string, direction Direction) ([]*models.Transaction, error) {
+	return e.GetTransactionsFilteredCtx(context.Background(), address, direction)
+}
+
+func (e *ethParser) GetTransactionsFilteredCtx(ctx context.Context, address string, direction Direction) ([]*models.Transaction, error) {
+	if direction < All || direction > Outbound {
+		return nil, fmt.Errorf("invalid direction: %v", direction)
+	}
+
+	address, err := e.parseSubscription(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := e.getAllTransactions(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return FilterDirection(address, transactions, direction)
+}
+
+// FilterDirection keeps the transactions of address in the given direction
+func FilterDirection(address string, transactions []*models.Transaction, direction Direction) ([]*models.Transaction, error) {
+	if direction < All || direction > Outbound {
+		return nil, fmt.Errorf("invalid direction: %v", direction)
+	}
+
+	address, err := normalizeSubscription(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if direction == All {
+		return transactions, nil
+	}
+
+	matchAddress := addressMatch(address)
+
+	filtered := make([]*models.Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		if direction == Inbound && matchAddress(tx.To) || direction == Outbound && matchAddress(tx.From) {
+			filtered = append(filtered, tx)
+		}
+	}
+
+	return filtered, nil
+}
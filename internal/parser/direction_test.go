@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserGetTransactionsFiltered(t *testing.T) {
+	_, node := newTestChain(t, 102, map[int][]models.Transaction{
+		101: {{Hash: "0x101", To: address}},
+		102: {{Hash: "0x102", From: address}, {Hash: "0x1022", From: address, To: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	tests := []struct {
+		direction Direction
+		want      []string
+	}{
+		{direction: All, want: []string{"0x101", "0x102", "0x1022"}},
+		{direction: Inbound, want: []string{"0x101", "0x1022"}},
+		{direction: Outbound, want: []string{"0x102", "0x1022"}},
+	}
+
+	for _, tt := range tests {
+		txs, err := parser.GetTransactionsFiltered(address, tt.direction)
+		require.NoError(t, err, tt.direction)
+
+		hashes := make([]string, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash)
+		}
+		require.ElementsMatch(t, tt.want, hashes, tt.direction)
+	}
+
+	_, err = parser.GetTransactionsFiltered(address, Direction(7))
+	require.Error(t, err)
+}
+
+func TestParseDirection(t *testing.T) {
+	for _, direction := range []Direction{All, Inbound, Outbound} {
+		got, err := ParseDirection(direction.String())
+		require.NoError(t, err)
+		require.Equal(t, direction, got)
+	}
+
+	_, err := ParseDirection("sideways")
+	require.Error(t, err)
+}
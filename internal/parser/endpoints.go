@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// endpointFailureThreshold is the number of consecutive failures after
+	// which an endpoint is put aside for endpointCooldown
+	endpointFailureThreshold = 3
+	endpointCooldown         = 30 * time.Second
+)
+
+// endpoint is a node URL along with its recent failures
+type endpoint struct {
+	url string
+	// failures counts the consecutive failed requests
+	failures int
+	// unhealthyUntil is when the endpoint is tried first again
+	unhealthyUntil time.Time
+}
+
+// endpointPool is the list of node URLs requests fail over across, in order
+// of preference
+type endpointPool struct {
+	m         sync.Mutex
+	endpoints []*endpoint
+}
+
+func newEndpointPool(urls []string) *endpointPool {
+	endpoints := make([]*endpoint, 0, len(urls))
+	for _, url := range urls {
+		endpoints = append(endpoints, &endpoint{url: url})
+	}
+
+	return &endpointPool{endpoints: endpoints}
+}
+
+// WithNodeUrls sends requests to the first of urls, failing over to the
+// next ones in turn when it fails with a network error, a 429 or a 5xx.
+// Endpoints failing repeatedly are tried last for a while.
+func WithNodeUrls(urls ...string) EthParserOpt {
+	return func(p *ethParser) error {
+		if len(urls) == 0 {
+			return errors.New("urls cannot be empty")
+		}
+		for _, url := range urls {
+			if url == "" {
+				return errors.New("url cannot be empty")
+			}
+		}
+		p.nodes = newEndpointPool(urls)
+		return nil
+	}
+}
+
+// order lists the endpoints to try, the healthy ones first
+func (ep *endpointPool) order(now time.Time) []*endpoint {
+	ep.m.Lock()
+	defer ep.m.Unlock()
+
+	ordered := make([]*endpoint, 0, len(ep.endpoints))
+	var unhealthy []*endpoint
+	for _, e := range ep.endpoints {
+		if now.Before(e.unhealthyUntil) {
+			unhealthy = append(unhealthy, e)
+			continue
+		}
+		ordered = append(ordered, e)
+	}
+
+	return append(ordered, unhealthy...)
+}
+
+// failed records a failed request to e, reporting whether it was just put
+// aside
+func (ep *endpointPool) failed(e *endpoint, now time.Time) bool {
+	ep.m.Lock()
+	defer ep.m.Unlock()
+
+	e.failures++
+	if e.failures < endpointFailureThreshold || now.Before(e.unhealthyUntil) {
+		return false
+	}
+
+	e.unhealthyUntil = now.Add(endpointCooldown)
+	return true
+}
+
+// succeeded records a successful request to e
+func (ep *endpointPool) succeeded(e *endpoint) {
+	ep.m.Lock()
+	defer ep.m.Unlock()
+
+	e.failures = 0
+	e.unhealthyUntil = time.Time{}
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserNodeFailover(t *testing.T) {
+	down, downRequests := newFlakyNode(t, 1000, http.StatusServiceUnavailable)
+	up, upRequests := newFlakyNode(t, 0, 0)
+
+	clock := newFakeClock()
+	parser, err := NewEthParser(WithNodeUrls(down.URL, up.URL), WithRetry(1, time.Millisecond), WithBlockNumberTTL(0), WithClock(clock))
+	require.NoError(t, err)
+
+	for i := 0; i < endpointFailureThreshold; i++ {
+		blockNumber, err := parser.GetCurrentBlock()
+		require.NoError(t, err)
+		require.Equal(t, 0x13ecaeb, blockNumber)
+	}
+	require.Equal(t, int32(endpointFailureThreshold), downRequests.Load())
+	require.Equal(t, int32(endpointFailureThreshold), upRequests.Load())
+
+	// the failing endpoint is put aside until its cooldown is over
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, int32(endpointFailureThreshold), downRequests.Load())
+
+	clock.Advance(endpointCooldown)
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, int32(endpointFailureThreshold+1), downRequests.Load())
+}
+
+func TestParserNodeFailoverAllDown(t *testing.T) {
+	first, firstRequests := newFlakyNode(t, 1000, http.StatusServiceUnavailable)
+	second, secondRequests := newFlakyNode(t, 1000, http.StatusBadGateway)
+
+	parser, err := NewEthParser(WithNodeUrls(first.URL, second.URL), WithRetry(2, time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.ErrorContains(t, err, "502")
+	require.Equal(t, int32(2), firstRequests.Load())
+	require.Equal(t, int32(2), secondRequests.Load())
+}
+
+func TestWithNodeUrlsInvalid(t *testing.T) {
+	_, err := NewEthParser(WithNodeUrls())
+	require.Error(t, err)
+
+	_, err = NewEthParser(WithNodeUrls("http://localhost:8545", ""))
+	require.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// ensResolverSelector is the selector of resolver(bytes32) of the ENS
+	// registry
+	ensResolverSelector = "0x0178b8bf"
+	// ensAddrSelector is the selector of addr(bytes32) of an ENS resolver
+	ensAddrSelector = "0x3b3b57de"
+	// ensNameSuffix ends the ENS names resolved by WithENSResolution
+	ensNameSuffix = ".eth"
+	// zeroAddress is answered for names without a resolver or an address
+	zeroAddress = "0x0000000000000000000000000000000000000000"
+)
+
+// ErrENSNameNotResolved is returned for ENS names without a resolver or an
+// address
+var ErrENSNameNotResolved = errors.New("ENS name not resolved")
+
+type JsonRPCResponseCall struct {
+	Result string `json:"result"`
+}
+
+// ensCache memoizes the addresses ENS names resolve to
+type ensCache struct {
+	// registry is the address of the ENS registry, empty when names are
+	// not resolved
+	registry string
+
+	m         sync.Mutex
+	addresses map[string]string
+}
+
+// WithENSResolution makes every method taking an address accept ENS names
+// ending in .eth, as vitalik.eth, resolved with eth_call through the ENS
+// registry at registryAddr, 0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e on
+// mainnet. Resolutions are cached for the life of the parser. Names are only
+// lowercased, not normalized with UTS-46.
+func WithENSResolution(registryAddr string) EthParserOpt {
+	return func(p *ethParser) error {
+		registry, err := normalizeAddress(registryAddr)
+		if err != nil {
+			return fmt.Errorf("ENS registry: %w", err)
+		}
+		p.ens.registry = registry
+		p.ens.addresses = make(map[string]string)
+		return nil
+	}
+}
+
+// ResolveAddress normalizes address, resolving it first when it is an ENS
+// name with WithENSResolution, for callers needing the address a name stands
+// for, as to match it against transactions
+func (e *ethParser) ResolveAddress(ctx context.Context, address string) (string, error) {
+	return e.parseAddress(ctx, address)
+}
+
+// resolveAddress resolves address when it is an ENS name and ENS resolution
+// is enabled, returning it unchanged otherwise
+func (e *ethParser) resolveAddress(ctx context.Context, address string) (string, error) {
+	if e.ens.registry == "" || !strings.HasSuffix(strings.ToLower(address), ensNameSuffix) {
+		return address, nil
+	}
+
+	name := strings.ToLower(address)
+
+	e.ens.m.Lock()
+	resolved, ok := e.ens.addresses[name]
+	e.ens.m.Unlock()
+	if ok {
+		return resolved, nil
+	}
+
+	node := ensNamehash(name)
+	resolver, err := e.ensCall(ctx, e.ens.registry, ensResolverSelector, node)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", name, err)
+	}
+	if resolver == zeroAddress {
+		return "", fmt.Errorf("%w: %s has no resolver", ErrENSNameNotResolved, name)
+	}
+
+	resolved, err = e.ensCall(ctx, resolver, ensAddrSelector, node)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", name, err)
+	}
+	if resolved == zeroAddress {
+		return "", fmt.Errorf("%w: %s has no address", ErrENSNameNotResolved, name)
+	}
+
+	e.ens.m.Lock()
+	e.ens.addresses[name] = resolved
+	e.ens.m.Unlock()
+
+	return resolved, nil
+}
+
+// ensCall calls the function of contract with selector on node, returning
+// the address it answers
+func (e *ethParser) ensCall(ctx context.Context, contract, selector string, node []byte) (string, error) {
+	rpcRequest := JsonRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_call",
+		Params: []interface{}{
+			map[string]string{"to": contract, "data": selector + hex.EncodeToString(node)},
+			"latest",
+		},
+	}
+
+	rpcResponse, err := do[JsonRPCResponseCall](ctx, e, rpcRequest)
+	if err != nil {
+		return "", err
+	}
+
+	// the address is right-aligned in a 32-byte word, a contract without
+	// code answering nothing
+	word := strings.TrimPrefix(rpcResponse.Result, "0x")
+	if word == "" {
+		return "", fmt.Errorf("%w: %s has no code", ErrENSNameNotResolved, contract)
+	}
+	if len(word) != 64 {
+		return "", fmt.Errorf("invalid eth_call result: %q", rpcResponse.Result)
+	}
+
+	return normalizeAddress("0x" + word[24:])
+}
+
+// ensNamehash computes the ENS namehash of name, hashing its labels from the
+// last one
+func ensNamehash(name string) []byte {
+	node := make([]byte, 32)
+	if name == "" {
+		return node
+	}
+
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = keccak256(node, keccak256([]byte(labels[i])))
+	}
+
+	return node
+}
+
+// keccak256 hashes the concatenation of data with the Keccak-256 Ethereum
+// uses, which pads differently than SHA3-256
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+const (
+	ensRegistry = "0x00000000000c2e074ec69a0dfb2997ba6c7d2e1e"
+	ensResolver = "0x4976fb03c32e5b8cfe2b6ccb31c09ba78ebaba41"
+	// ensNoCode is the resolver of nocode.eth, an address without code
+	ensNoCode = "0x1111111111111111111111111111111111111111"
+	// vitalikNamehash is the namehash of vitalik.eth
+	vitalikNamehash = "ee6c4522aab0003e8d14cd40a6af439055fd2577951148c14b6cea9a53475835"
+)
+
+// newENSNode serves an ENS registry and resolver resolving vitalik.eth to
+// address, and nocode.eth to a resolver without code, on top of the blocks
+// of a test chain
+func newENSNode(t *testing.T, calls *atomic.Int32) string {
+	t.Helper()
+
+	chain := &testChain{transactions: map[int][]models.Transaction{
+		101: {{Hash: "0x101", To: address}},
+	}}
+	chain.head.Store(101)
+
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		switch method {
+		case "eth_call":
+			calls.Add(1)
+			call := params[0].(map[string]interface{})
+			to, data := call["to"].(string), call["data"].(string)
+
+			word := strings.Repeat("0", 64)
+			switch {
+			case to == ensRegistry && data == ensResolverSelector+vitalikNamehash:
+				word = strings.Repeat("0", 24) + strings.TrimPrefix(ensResolver, "0x")
+			case to == ensResolver && data == ensAddrSelector+vitalikNamehash:
+				word = strings.Repeat("0", 24) + strings.TrimPrefix(address, "0x")
+			case to == ensRegistry && data == ensResolverSelector+hex.EncodeToString(ensNamehash("nocode.eth")):
+				word = strings.Repeat("0", 24) + strings.TrimPrefix(ensNoCode, "0x")
+			case to == ensNoCode:
+				word = ""
+			}
+			return "0x" + word
+		case "eth_blockNumber":
+			return hexNumber(int(chain.head.Load()))
+		case "eth_getBlockByNumber":
+			number, _ := models.ParseHexInt(params[0].(string))
+			return chain.block(number)
+		}
+		return nil
+	})
+
+	return node.URL
+}
+
+func TestENSNamehash(t *testing.T) {
+	require.Equal(t, strings.Repeat("0", 64), hex.EncodeToString(ensNamehash("")))
+	require.Equal(t, "93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae", hex.EncodeToString(ensNamehash("eth")))
+	require.Equal(t, vitalikNamehash, hex.EncodeToString(ensNamehash("vitalik.eth")))
+}
+
+func TestParserENSResolution(t *testing.T) {
+	var calls atomic.Int32
+	parser, err := NewEthParser(WithNodeUrl(newENSNode(t, &calls)), WithConfirmations(0), WithENSResolution(ensRegistry))
+	require.NoError(t, err)
+
+	require.NoError(t, parser.Subscribe("Vitalik.eth"))
+	require.Equal(t, []string{address}, parser.SubscribedAddresses())
+	require.Equal(t, int32(2), calls.Load())
+
+	// the resolution is cached
+	txs, err := parser.GetTransactions("vitalik.eth")
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, "0x101", txs[0].Hash)
+	require.Equal(t, int32(2), calls.Load())
+
+	err = parser.Subscribe("nobody.eth")
+	require.ErrorIs(t, err, ErrENSNameNotResolved)
+	_, err = parser.GetTransactions("nobody.eth")
+	require.ErrorIs(t, err, ErrENSNameNotResolved)
+
+	// calling an address without code answers nothing
+	err = parser.Subscribe("nocode.eth")
+	require.ErrorIs(t, err, ErrENSNameNotResolved)
+}
+
+func TestParserENSResolutionEverywhere(t *testing.T) {
+	var calls atomic.Int32
+	parser, err := NewEthParser(WithNodeUrl(newENSNode(t, &calls)), WithConfirmations(0), WithENSResolution(ensRegistry))
+	require.NoError(t, err)
+
+	resolved, err := parser.ResolveAddress(context.Background(), "vitalik.eth")
+	require.NoError(t, err)
+	require.Equal(t, address, resolved)
+
+	require.NoError(t, parser.SubscribeWithInterval("vitalik.eth", time.Minute))
+	require.Equal(t, []string{address}, parser.SubscribedAddresses())
+
+	txs, fromBlock, toBlock, err := parser.GetTransactionsWithRange("vitalik.eth")
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, 101, fromBlock)
+	require.Equal(t, 101, toBlock)
+	require.NoError(t, parser.LastError("vitalik.eth"))
+
+	// a name subscribed is unsubscribed by name
+	require.True(t, parser.Unsubscribe("vitalik.eth"))
+	require.Empty(t, parser.SubscribedAddresses())
+	require.False(t, parser.Unsubscribe("nobody.eth"))
+
+	require.NoError(t, parser.SubscribeAndWarm("vitalik.eth"))
+	require.Equal(t, []string{address}, parser.SubscribedAddresses())
+	_, err = parser.WarmupStatus("vitalik.eth")
+	require.NoError(t, err)
+	require.NoError(t, parser.Close())
+
+	_, _, _, err = parser.GetTransactionsWithRange("nobody.eth")
+	require.ErrorIs(t, err, ErrENSNameNotResolved)
+}
+
+func TestParserENSResolutionDisabled(t *testing.T) {
+	var calls atomic.Int32
+	parser, err := NewEthParser(WithNodeUrl(newENSNode(t, &calls)))
+	require.NoError(t, err)
+
+	require.ErrorContains(t, parser.Subscribe("vitalik.eth"), "invalid address")
+	require.Zero(t, calls.Load())
+
+	_, err = NewEthParser(WithENSResolution("registry"))
+	require.Error(t, err)
+}
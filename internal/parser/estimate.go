@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrRangeTooLarge is returned for scans of more blocks than allowed by
+// WithMaxBlockRange
+var ErrRangeTooLarge = errors.New("block range too large")
+
+// WithMaxBlockRange fails the calls that would scan more than n blocks with
+// ErrRangeTooLarge rather than scanning them, 0 allowing any number
+func WithMaxBlockRange(n int) EthParserOpt {
+	return func(p *ethParser) error {
+		if n < 0 {
+			return errors.New("max block range cannot be negative")
+		}
+		p.maxBlockRange = n
+		return nil
+	}
+}
+
+// checkScanSize fails scans of more than the max block range
+func (e *ethParser) checkScanSize(blocksToScan int) error {
+	if e.maxBlockRange > 0 && blocksToScan > e.maxBlockRange {
+		return fmt.Errorf("%w: %d blocks to scan, at most %d allowed", ErrRangeTooLarge, blocksToScan, e.maxBlockRange)
+	}
+
+	return nil
+}
+
+func (e *ethParser) EstimateScan(address string) (int, bool, error) {
+	return e.EstimateScanCtx(context.Background(), address)
+}
+
+// EstimateScanCtx gets how many blocks GetTransactions would fetch for
+// address, without fetching them, and whether part of its blocks were
+// already scanned and are served from the cache. Blocks dropped by a reorg
+// the scan would detect are not counted.
+func (e *ethParser) EstimateScanCtx(ctx context.Context, address string) (blocksToScan int, cached bool, err error) {
+	address, err = e.parseSubscription(ctx, address)
+	if err != nil {
+		return 0, false, err
+	}
+
+	initialBlockNumber, err := e.getAddressInitialBlockNumber(address)
+	if err != nil {
+		return 0, false, err
+	}
+
+	currentBlockNumber, err := e.getCurrentBlockNumber(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	_, cachedBlockNumber := e.transactionCache.GetTransactions(address)
+	processed := e.processedRanges(address, initialBlockNumber, cachedBlockNumber)
+	gaps := missingRanges(processed, initialBlockNumber, e.finalizedBlockNumber(currentBlockNumber))
+	return rangesSize(gaps), len(processed) > 0, nil
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserEstimateScan(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		106: {{Hash: "0x106", To: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.addresses[hot] = subscription{blockNumber: 100}
+	parser.processed[hot] = []BlockRange{{From: 100, To: 104}, {From: 108, To: 110}}
+
+	blocksToScan, cached, err := parser.EstimateScan(address)
+	require.NoError(t, err)
+	require.Equal(t, 11, blocksToScan)
+	require.False(t, cached)
+
+	// only the gap is left to scan
+	blocksToScan, cached, err = parser.EstimateScan(hot)
+	require.NoError(t, err)
+	require.Equal(t, 3, blocksToScan)
+	require.True(t, cached)
+
+	require.Empty(t, chain.fetchedBlocks())
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	blocksToScan, cached, err = parser.EstimateScan(address)
+	require.NoError(t, err)
+	require.Zero(t, blocksToScan)
+	require.True(t, cached)
+
+	_, _, err = parser.EstimateScan(other)
+	require.Error(t, err)
+}
+
+func TestParserMaxBlockRange(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		106: {{Hash: "0x106", To: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithMaxBlockRange(5))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	_, err = parser.GetTransactions(address)
+	require.ErrorIs(t, err, ErrRangeTooLarge)
+	require.ErrorContains(t, err, "11 blocks to scan, at most 5 allowed")
+
+	_, err = parser.GetTransactionsMany([]string{address})
+	require.ErrorIs(t, err, ErrRangeTooLarge)
+
+	_, err = parser.GetTransactionsFrom(address, 100)
+	require.ErrorIs(t, err, ErrRangeTooLarge)
+	require.Empty(t, chain.fetchedBlocks())
+
+	// a range within the max is scanned
+	txs, err := parser.GetTransactionsFrom(address, 106)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	parser.processed[address] = []BlockRange{{From: 100, To: 105}}
+	parser.transactionCache.AddTransactions(address, nil, 105)
+	txs, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	_, err = NewEthParser(WithMaxBlockRange(-1))
+	require.Error(t, err)
+}
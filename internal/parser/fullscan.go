@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrFullChainScan is returned for scans of more than fullScanThreshold
+// blocks from the genesis block, unless WithAllowFullScan is set
+var ErrFullChainScan = errors.New("refusing to scan the chain from the genesis block")
+
+// fullScanThreshold is the number of blocks past which a scan from the
+// genesis block is refused, so that an address subscribed while a devnet
+// was at block 0 can still be scanned from it
+const fullScanThreshold = 100_000
+
+// WithAllowFullScan lets GetTransactions scan from the genesis block, which
+// takes millions of requests on mainnet and never completes against a
+// public endpoint
+func WithAllowFullScan() EthParserOpt {
+	return func(p *ethParser) error {
+		p.allowFullScan = true
+		return nil
+	}
+}
+
+// checkFullScan fails the scan of gaps for an address subscribed at the
+// genesis block when it spans more than fullScanThreshold blocks, which is
+// almost always an initial block number lost or looked up as 0 rather than
+// an intended replay
+func (e *ethParser) checkFullScan(address string, initialBlockNumber int, gaps []BlockRange) error {
+	blocksToScan := rangesSize(gaps)
+	if e.allowFullScan || initialBlockNumber > 0 || blocksToScan <= fullScanThreshold {
+		return nil
+	}
+
+	e.logger.Error("refusing to scan the chain from the genesis block, set WithAllowFullScan if this is intended",
+		"address", address, "blocksToScan", blocksToScan)
+	return fmt.Errorf("%w: %d blocks to scan for %s", ErrFullChainScan, blocksToScan, address)
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserRefusesFullChainScan(t *testing.T) {
+	var fetched int
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		if method == "eth_blockNumber" {
+			return hexNumber(fullScanThreshold + 2)
+		}
+		fetched++
+		return nil
+	})
+
+	logger, records := newTestLogger(t)
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithLogger(logger))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 0}
+
+	_, err = parser.GetTransactions(address)
+	require.ErrorIs(t, err, ErrFullChainScan)
+	require.Zero(t, fetched)
+	require.Equal(t, "ERROR", records()[0]["level"])
+
+	// allowed, the scan is only bounded by the max block range
+	parser, err = NewEthParser(WithNodeUrl(node.URL), WithAllowFullScan(), WithMaxBlockRange(10))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 0}
+
+	_, err = parser.GetTransactions(address)
+	require.ErrorIs(t, err, ErrRangeTooLarge)
+}
+
+func TestParserScansShortChainFromGenesis(t *testing.T) {
+	_, node := newTestChain(t, 20, map[int][]models.Transaction{
+		1: {{Hash: "0x1", To: address}},
+		5: {{Hash: "0x5", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 0}
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+}
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"ethparser/internal/models"
+)
+
+const (
+	defaultHeaderBufferSize = 64
+)
+
+// blockHeader is the part of a block needed to follow the chain
+type blockHeader struct {
+	Number     int
+	Hash       string
+	ParentHash string
+}
+
+// headerRing keeps the headers of the most recent processed blocks, each
+// block number taking the slot number % size so that processing blocks in
+// any order keeps the highest ones
+type headerRing struct {
+	m       sync.Mutex
+	headers []blockHeader
+}
+
+func newHeaderRing(size int) *headerRing {
+	return &headerRing{
+		headers: make([]blockHeader, size),
+	}
+}
+
+// add records the header unless its slot holds a more recent block
+func (hr *headerRing) add(header blockHeader) {
+	hr.m.Lock()
+	defer hr.m.Unlock()
+
+	slot := header.Number % len(hr.headers)
+	if hr.headers[slot].Hash != "" && hr.headers[slot].Number > header.Number {
+		return
+	}
+
+	hr.headers[slot] = header
+}
+
+// byHash looks up a recorded header by block hash
+func (hr *headerRing) byHash(hash string) (blockHeader, bool) {
+	hr.m.Lock()
+	defer hr.m.Unlock()
+
+	for _, header := range hr.headers {
+		if header.Hash != "" && header.Hash == hash {
+			return header, true
+		}
+	}
+
+	return blockHeader{}, false
+}
+
+// byNumber looks up a recorded header by block number
+func (hr *headerRing) byNumber(number int) (blockHeader, bool) {
+	hr.m.Lock()
+	defer hr.m.Unlock()
+
+	header := hr.headers[number%len(hr.headers)]
+	if header.Hash == "" || header.Number != number {
+		return blockHeader{}, false
+	}
+
+	return header, true
+}
+
+// tip gets the recorded header with the highest block number
+func (hr *headerRing) tip() (blockHeader, bool) {
+	hr.m.Lock()
+	defer hr.m.Unlock()
+
+	var tip blockHeader
+	for _, header := range hr.headers {
+		if header.Hash != "" && (tip.Hash == "" || header.Number > tip.Number) {
+			tip = header
+		}
+	}
+
+	return tip, tip.Hash != ""
+}
+
+// dropAfter forgets the headers of blocks above number
+func (hr *headerRing) dropAfter(number int) {
+	hr.m.Lock()
+	defer hr.m.Unlock()
+
+	for i, header := range hr.headers {
+		if header.Number > number {
+			hr.headers[i] = blockHeader{}
+		}
+	}
+}
+
+// recordHeader adds a processed block to the recent headers
+func (e *ethParser) recordHeader(block *models.BlockWithDetails) {
+	blockNumber, err := models.ParseHexInt(block.Number)
+	if err != nil {
+		return
+	}
+
+	e.headers.add(blockHeader{
+		Number:     blockNumber,
+		Hash:       block.Hash,
+		ParentHash: block.ParentHash,
+	})
+}
+
+// findCommonAncestor walks back from head, a block of a chain that may have
+// diverged from the processed one, until it reaches a block found in the
+// recent headers. Only the blocks of the new chain missing from the recent
+// headers are fetched from the node.
+func (e *ethParser) findCommonAncestor(ctx context.Context, head *models.BlockWithDetails) (blockHeader, error) {
+	parentHash := head.ParentHash
+	for i := 0; i < len(e.headers.headers); i++ {
+		if ancestor, ok := e.headers.byHash(parentHash); ok {
+			return ancestor, nil
+		}
+
+		req := JsonRPCRequest{
+			Jsonrpc: "2.0",
+			Method:  "eth_getBlockByHash",
+			Params:  []interface{}{parentHash, false},
+		}
+
+		rpcResponse, err := do[JsonRPCResponseBlock](ctx, e, req)
+		if err != nil {
+			return blockHeader{}, err
+		}
+
+		if rpcResponse.Result.Number == "" {
+			return blockHeader{}, fmt.Errorf("block not found: %s", parentHash)
+		}
+
+		parentHash = rpcResponse.Result.ParentHash
+	}
+
+	return blockHeader{}, fmt.Errorf("no common ancestor within the last %d blocks", len(e.headers.headers))
+}
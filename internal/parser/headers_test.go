@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestHeaderRingKeepsMostRecentBlocks(t *testing.T) {
+	ring := newHeaderRing(3)
+
+	// blocks processed from the head backwards, as the range walk does
+	for number := 10; number >= 5; number-- {
+		ring.add(blockHeader{Number: number, Hash: blockHash(number)})
+	}
+
+	for number := 8; number <= 10; number++ {
+		header, ok := ring.byNumber(number)
+		require.True(t, ok)
+		require.Equal(t, blockHash(number), header.Hash)
+	}
+
+	_, ok := ring.byNumber(7)
+	require.False(t, ok)
+
+	_, ok = ring.byHash(blockHash(5))
+	require.False(t, ok)
+}
+
+func TestParserFindCommonAncestor(t *testing.T) {
+	// the reorged chain forks off after block 98
+	forked := map[string]models.BlockWithDetails{
+		"0xf99": {Hash: "0xf99", ParentHash: blockHash(98), Number: hexNumber(99)},
+	}
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		if block, ok := forked[params[0].(string)]; ok {
+			return block
+		}
+		return nil
+	})
+
+	transport := &countingTransport{}
+	parser, err := NewEthParser(
+		WithNodeUrl(node.URL),
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithHeaderBufferSize(8),
+	)
+	require.NoError(t, err)
+
+	for number := 95; number <= 100; number++ {
+		parser.recordHeader(&models.BlockWithDetails{
+			Hash:       blockHash(number),
+			ParentHash: blockHash(number - 1),
+			Number:     hexNumber(number),
+		})
+	}
+
+	// shallow reorg replacing block 100 only, resolved from the buffer
+	ancestor, err := parser.findCommonAncestor(context.Background(), &models.BlockWithDetails{
+		Hash:       "0xf100",
+		ParentHash: blockHash(99),
+		Number:     hexNumber(100),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 99, ancestor.Number)
+	require.Zero(t, transport.requests.Load())
+
+	// reorg replacing blocks 99 and 100 fetches the new block 99 only
+	ancestor, err = parser.findCommonAncestor(context.Background(), &models.BlockWithDetails{
+		Hash:       "0xf100",
+		ParentHash: "0xf99",
+		Number:     hexNumber(100),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 98, ancestor.Number)
+	require.Equal(t, int32(1), transport.requests.Load())
+}
@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"ethparser/internal/models"
+)
+
+const (
+	// headsReconnectBase is the delay before reconnecting after the first
+	// failure, doubling on each consecutive one up to headsReconnectMax
+	headsReconnectBase = time.Second
+	headsReconnectMax  = 30 * time.Second
+)
+
+// headsNotification is a new head pushed by an eth_subscribe subscription
+type headsNotification struct {
+	Method string `json:"method"`
+	Params struct {
+		Subscription string `json:"subscription"`
+		Result       struct {
+			Number string `json:"number"`
+		} `json:"result"`
+	} `json:"params"`
+}
+
+// WithWebSocketURL follows the new heads pushed by the node on a WebSocket
+// endpoint once started, instead of asking it for the current block. The
+// node is asked again whenever the connection is down.
+func WithWebSocketURL(wsURL string) EthParserOpt {
+	return func(p *ethParser) error {
+		u, err := url.Parse(wsURL)
+		if err != nil {
+			return err
+		}
+		if u.Scheme != "ws" && u.Scheme != "wss" {
+			return fmt.Errorf("websocket url must be ws or wss: %q", wsURL)
+		}
+		p.wsURL = wsURL
+		return nil
+	}
+}
+
+// followHeads keeps a newHeads subscription open until ctx is done,
+// reconnecting with backoff when the connection drops
+func (e *ethParser) followHeads(ctx context.Context) {
+	failures := 0
+	for {
+		connected, err := e.subscribeHeads(ctx)
+		e.blockNumber.unfollow()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if connected {
+			failures = 0
+		}
+		failures++
+		e.logger.Warn("new heads subscription closed", "url", e.wsURL, "error", err)
+
+		delay := min(headsReconnectBase<<(failures-1), headsReconnectMax)
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.clock.After(delay/2 + rand.N(delay/2+1)):
+		}
+	}
+}
+
+// subscribeHeads subscribes to new heads and records their block number
+// until the connection fails, reporting whether the subscription was made
+func (e *ethParser) subscribeHeads(ctx context.Context) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, e.wsURL, nil)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// unblock the reads below once ctx is done
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	req := JsonRPCRequest{
+		ID:      e.nextRequestID(),
+		Jsonrpc: "2.0",
+		Method:  "eth_subscribe",
+		Params:  []interface{}{"newHeads"},
+	}
+	if err := conn.WriteJSON(req); err != nil {
+		return false, err
+	}
+
+	var subscription struct {
+		JsonRPCResponseError
+		Result string `json:"result"`
+	}
+	if err := conn.ReadJSON(&subscription); err != nil {
+		return false, err
+	}
+	if subscription.Error != nil {
+		return false, subscription.Error
+	}
+	if subscription.Result == "" {
+		return false, errors.New("no subscription id in eth_subscribe response")
+	}
+
+	for {
+		var notification headsNotification
+		if err := conn.ReadJSON(&notification); err != nil {
+			return true, err
+		}
+
+		if notification.Method != "eth_subscription" || notification.Params.Subscription != subscription.Result {
+			continue
+		}
+
+		blockNumber, err := models.ParseHexInt(notification.Params.Result.Number)
+		if err != nil {
+			return true, err
+		}
+
+		e.logger.Debug("new head", "blockNumber", blockNumber)
+		e.blockNumber.push(blockNumber, e.clock.Now())
+	}
+}
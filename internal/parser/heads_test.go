@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// newHeadsNode starts a WebSocket node pushing every block number sent on
+// the returned channel as a new head, closing the connection on a negative
+// one. It also reports each eth_subscribe it answers.
+func newHeadsNode(t *testing.T) (*httptest.Server, chan<- int, <-chan struct{}) {
+	t.Helper()
+
+	heads := make(chan int)
+	subscribed := make(chan struct{}, 10)
+
+	upgrader := websocket.Upgrader{}
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var req JsonRPCRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		conn.WriteJSON(map[string]interface{}{"id": req.ID, "jsonrpc": "2.0", "result": "0x1"})
+		subscribed <- struct{}{}
+
+		for head := range heads {
+			if head < 0 {
+				return
+			}
+			conn.WriteJSON(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "eth_subscription",
+				"params": map[string]interface{}{
+					"subscription": "0x1",
+					"result":       map[string]interface{}{"number": hexNumber(head)},
+				},
+			})
+		}
+	}))
+	t.Cleanup(node.Close)
+	t.Cleanup(func() { close(heads) })
+
+	return node, heads, subscribed
+}
+
+func TestParserFollowHeads(t *testing.T) {
+	node, requests := newFlakyNode(t, 0, 0)
+	wsNode, heads, subscribed := newHeadsNode(t)
+
+	clock := newFakeClock()
+	parser, err := NewEthParser(
+		WithNodeUrl(node.URL),
+		WithWebSocketURL("ws"+strings.TrimPrefix(wsNode.URL, "http")),
+		WithBlockNumberTTL(0),
+		WithClock(clock),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	parser.Start(ctx)
+	<-subscribed
+
+	heads <- 100
+	require.Eventually(t, func() bool {
+		blockNumber, err := parser.GetCurrentBlock()
+		return err == nil && blockNumber == 100
+	}, time.Second, time.Millisecond)
+
+	heads <- 101
+	require.Eventually(t, func() bool {
+		blockNumber, err := parser.GetCurrentBlock()
+		return err == nil && blockNumber == 101
+	}, time.Second, time.Millisecond)
+	require.Equal(t, int32(0), requests.Load())
+
+	// the node is asked again once the connection drops, until reconnected
+	heads <- -1
+	require.Eventually(t, func() bool {
+		blockNumber, err := parser.GetCurrentBlock()
+		return err == nil && blockNumber == 0x13ecaeb
+	}, time.Second, time.Millisecond)
+	require.NotZero(t, requests.Load())
+
+	clock.waitForWaiters(t, 2)
+	clock.Advance(headsReconnectBase)
+	<-subscribed
+
+	heads <- 102
+	require.Eventually(t, func() bool {
+		blockNumber, err := parser.GetCurrentBlock()
+		return err == nil && blockNumber == 102
+	}, time.Second, time.Millisecond)
+}
+
+func TestWithWebSocketURL(t *testing.T) {
+	_, err := NewEthParser(WithWebSocketURL("wss://node.example"))
+	require.NoError(t, err)
+
+	_, err = NewEthParser(WithWebSocketURL(""))
+	require.Error(t, err)
+
+	_, err = NewEthParser(WithWebSocketURL("https://node.example"))
+	require.Error(t, err)
+}
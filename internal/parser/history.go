@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+
+	"ethparser/internal/models"
+)
+
+func (e *ethParser) GetTransactionsFrom(address string, fromBlock int) ([]*models.Transaction, error) {
+	return e.GetTransactionsFromCtx(context.Background(), address, fromBlock)
+}
+
+// GetTransactionsFromCtx scans the blocks from fromBlock up to the current
+// block for the transactions of address, which doesn't need to be
+// subscribed. Nothing is cached for address, every call scanning its blocks
+// again.
+func (e *ethParser) GetTransactionsFromCtx(ctx context.Context, address string, fromBlock int) ([]*models.Transaction, error) {
+	address, err := e.parseSubscription(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromBlock < 0 {
+		return nil, fmt.Errorf("invalid block number: %d", fromBlock)
+	}
+
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	currentBlockNumber, err := e.getCurrentBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fromBlock = max(fromBlock, e.earliestBlock)
+	finalBlockNumber := e.finalizedBlockNumber(currentBlockNumber)
+	if fromBlock > finalBlockNumber {
+		return nil, nil
+	}
+
+	if err := e.checkScanSize(finalBlockNumber - fromBlock + 1); err != nil {
+		return nil, err
+	}
+
+	found, err := e.getTransactionsFromBlockNumbers(ctx, fromBlock, finalBlockNumber, e.addressMatcher(address))
+	if err != nil {
+		return nil, e.historyError(ctx, fromBlock, finalBlockNumber, err)
+	}
+
+	// the transactions point into the cached blocks
+	transactions := copyTransactions(found)
+	models.SortTransactions(transactions)
+	return transactions, nil
+}
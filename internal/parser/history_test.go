@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserGetTransactionsFrom(t *testing.T) {
+	chain, node := newTestChain(t, 105, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address, BlockNumber: "0x65"}},
+		103: {{Hash: "0x103", To: address, BlockNumber: "0x67"}},
+		104: {{Hash: "0x104", From: other, To: hot, BlockNumber: "0x68"}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	// address is not subscribed
+	txs, err := parser.GetTransactionsFrom(address, 102)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, "0x103", txs[0].Hash)
+	require.ElementsMatch(t, []int{105, 104, 103, 102}, chain.fetchedBlocks())
+
+	txs, err = parser.GetTransactionsFrom(address, 100)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	require.Equal(t, "0x101", txs[0].Hash)
+	require.Equal(t, "0x103", txs[1].Hash)
+
+	// nothing is kept for the address
+	require.Empty(t, parser.SubscribedAddresses())
+	cached, _ := parser.transactionCache.GetTransactions(address)
+	require.Empty(t, cached)
+
+	txs, err = parser.GetTransactionsFrom(address, 106)
+	require.NoError(t, err)
+	require.Empty(t, txs)
+
+	_, err = parser.GetTransactionsFrom(address, -1)
+	require.Error(t, err)
+
+	_, err = parser.GetTransactionsFrom("0xzz", 100)
+	require.Error(t, err)
+}
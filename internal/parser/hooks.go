@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+
+	"ethparser/internal/models"
+)
+
+// hookQueueSize is the number of calls waiting for the hooks to return,
+// calls being dropped when it is full
+const hookQueueSize = 64
+
+// hookCall is the new transactions of an address waiting to be passed to
+// the hooks
+type hookCall struct {
+	address      string
+	transactions []*models.Transaction
+}
+
+// OnNewTransactions calls hook with the new transactions of a subscribed
+// address once they are cached, whether found by the poller or by a scan.
+// Hooks are called one at a time in registration order, away from the
+// poller: a slow hook delays the next calls, dropped once hookQueueSize of
+// them are waiting, and a hook panicking is reported on Errors. Nil hooks
+// are ignored.
+func (e *ethParser) OnNewTransactions(hook func(address string, txs []*models.Transaction)) {
+	if hook == nil {
+		return
+	}
+
+	e.hooksM.Lock()
+	defer e.hooksM.Unlock()
+
+	e.hooks = append(e.hooks, hook)
+	e.hooksOnce.Do(func() {
+		e.runBackground(context.Background(), e.callHooks)
+	})
+}
+
+// queueHooks queues the call of the hooks with the new transactions of
+// address, dropping it when the queue is full
+func (e *ethParser) queueHooks(address string, transactions []*models.Transaction) {
+	if len(transactions) == 0 {
+		return
+	}
+
+	e.hooksM.Lock()
+	registered := len(e.hooks) > 0
+	e.hooksM.Unlock()
+	if !registered {
+		return
+	}
+
+	// the hooks get the transactions in chain order, as listed
+	transactions = copyTransactions(transactions)
+	models.SortTransactions(transactions)
+
+	select {
+	case e.hookQueue <- hookCall{address: address, transactions: transactions}:
+	default:
+		e.logger.Error("dropped hook call, queue full", "address", address)
+	}
+}
+
+// callHooks passes the queued calls to the hooks until ctx is done
+func (e *ethParser) callHooks(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case call := <-e.hookQueue:
+			e.hooksM.Lock()
+			hooks := e.hooks
+			e.hooksM.Unlock()
+
+			for _, hook := range hooks {
+				e.callHook(hook, call)
+			}
+		}
+	}
+}
+
+// callHook calls hook with copies of the transactions of call, recovering
+// from its panic
+func (e *ethParser) callHook(hook func(address string, txs []*models.Transaction), call hookCall) {
+	defer func() {
+		if r := recover(); r != nil {
+			e.logger.Error("hook panicked", "address", call.address, "panic", r)
+			e.reportError(fmt.Errorf("hook for %s panicked: %v", call.address, r))
+		}
+	}()
+
+	hook(call.address, copyTransactions(call.transactions))
+}
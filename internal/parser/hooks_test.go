@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+// hookCallOf is a call of a hook under test
+type hookCallOf struct {
+	hook    string
+	address string
+	hashes  []string
+}
+
+func TestParserOnNewTransactions(t *testing.T) {
+	_, node := newTestChain(t, 105, map[int][]models.Transaction{
+		103: {{Hash: "0x103", From: address, To: hot}},
+		105: {{Hash: "0x105b", To: address, TransactionIndex: "0x1"}, {Hash: "0x105a", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0))
+	require.NoError(t, err)
+	t.Cleanup(func() { parser.Close() })
+
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.processed[address] = []BlockRange{{From: 100, To: 102}}
+
+	calls := make(chan hookCallOf, 16)
+	record := func(name string) func(string, []*models.Transaction) {
+		return func(address string, txs []*models.Transaction) {
+			hashes := make([]string, 0, len(txs))
+			for _, tx := range txs {
+				hashes = append(hashes, tx.Hash)
+			}
+			calls <- hookCallOf{hook: name, address: address, hashes: hashes}
+		}
+	}
+	parser.OnNewTransactions(record("first"))
+	parser.OnNewTransactions(func(string, []*models.Transaction) { panic("hook failed") })
+	parser.OnNewTransactions(record("second"))
+	parser.OnNewTransactions(nil)
+
+	lastBlock, err := parser.processNewBlocks(context.Background(), 102)
+	require.NoError(t, err)
+	require.Equal(t, 105, lastBlock)
+
+	// each new block calls the hooks in registration order, the panicking
+	// one being skipped
+	for _, want := range []hookCallOf{
+		{hook: "first", address: address, hashes: []string{"0x103"}},
+		{hook: "second", address: address, hashes: []string{"0x103"}},
+		{hook: "first", address: address, hashes: []string{"0x105a", "0x105b"}},
+		{hook: "second", address: address, hashes: []string{"0x105a", "0x105b"}},
+	} {
+		select {
+		case call := <-calls:
+			require.Equal(t, want, call)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("hook %s not called", want.hook)
+		}
+	}
+
+	require.ErrorContains(t, <-parser.Errors(), "hook failed")
+
+	// the transactions were cached before the hooks were called
+	txs, _ := parser.transactionCache.GetTransactions(address)
+	require.Len(t, txs, 3)
+
+	// cached transactions are not passed again
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Empty(t, calls)
+}
+
+func TestParserOnNewTransactionsSlowHook(t *testing.T) {
+	chain, node := newTestChain(t, 101, map[int][]models.Transaction{})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0), WithRateLimit(math.Inf(1), 1))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 101}
+
+	release := make(chan struct{})
+	parser.OnNewTransactions(func(string, []*models.Transaction) { <-release })
+
+	// the poller goes on while the hook is stuck
+	lastBlock := 101
+	for number := 102; number <= 110; number++ {
+		chain.transactions[number] = []models.Transaction{{Hash: hexNumber(number), To: address}}
+	}
+	chain.head.Store(110)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lastBlock, err = parser.processNewBlocks(context.Background(), lastBlock)
+
+		// the calls beyond the queue are dropped rather than waited for
+		for i := 0; i < hookQueueSize+2; i++ {
+			parser.queueHooks(address, []*models.Transaction{{Hash: "0xa", BlockNumber: "0x6f"}})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poller blocked by a slow hook")
+	}
+	require.NoError(t, err)
+	require.Equal(t, 110, lastBlock)
+
+	close(release)
+	require.NoError(t, parser.Close())
+}
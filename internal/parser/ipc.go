@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+)
+
+// WithIPCPath sends the requests to a local node over the Unix socket at
+// path, as geth.ipc, instead of HTTP. It cannot be combined with node urls
+// or a WebSocket url.
+func WithIPCPath(path string) EthParserOpt {
+	return func(p *ethParser) error {
+		if path == "" {
+			return errors.New("ipc path cannot be empty")
+		}
+		p.ipcPath = path
+		return nil
+	}
+}
+
+// checkTransports rejects an IPC path set along with another transport
+func (e *ethParser) checkTransports() error {
+	if e.ipcPath == "" {
+		return nil
+	}
+
+	if e.wsURL != "" {
+		return errors.New("ipc path cannot be combined with a websocket url")
+	}
+
+	endpoints := e.nodes.endpoints
+	if len(endpoints) != 1 || endpoints[0].url != defaultNodeUrl {
+		return errors.New("ipc path cannot be combined with node urls")
+	}
+
+	return nil
+}
+
+// sendIPC writes a JSON request body followed by a newline to the socket at
+// the IPC path of e and reads the JSON response, within the timeout of e if
+// positive. It returns the response body, or an error and whether it is
+// worth retrying.
+func (e *ethParser) sendIPC(ctx context.Context, requestBody []byte) ([]byte, bool, error) {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return nil, false, err
+	}
+
+	reqCtx := ctx
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(reqCtx, "unix", e.ipcPath)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer conn.Close()
+
+	// unblock the write and read below once reqCtx is done
+	stop := context.AfterFunc(reqCtx, func() { conn.Close() })
+	defer stop()
+
+	if _, err := conn.Write(append(requestBody, '\n')); err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+
+	// the node may answer without a trailing newline, so the response
+	// ends with the JSON value rather than the line
+	var responseBody json.RawMessage
+	if err := json.NewDecoder(conn).Decode(&responseBody); err != nil {
+		if reqCtx.Err() != nil {
+			err = reqCtx.Err()
+		}
+		return nil, ctx.Err() == nil, err
+	}
+
+	return responseBody, false, nil
+}
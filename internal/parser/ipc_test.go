@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestIPCNode listens on a Unix socket and answers every newline
+// delimited request with the canned result of its method
+func newTestIPCNode(t *testing.T, results map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "node.ipc")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				line, err := bufio.NewReader(conn).ReadBytes('\n')
+				if err != nil {
+					return
+				}
+
+				var request JsonRPCRequest
+				if err := json.Unmarshal(line, &request); err != nil {
+					return
+				}
+
+				// no trailing newline, as a node may answer
+				fmt.Fprintf(conn, `{"jsonrpc":"2.0","id":%d,"result":%s}`, request.ID, results[request.Method])
+			}()
+		}
+	}()
+
+	return path
+}
+
+func TestParserIPC(t *testing.T) {
+	path := newTestIPCNode(t, map[string]string{
+		"eth_blockNumber": `"0x6e"`,
+		"eth_gasPrice":    `"0x3b9aca00"`,
+	})
+
+	parser, err := NewEthParser(WithIPCPath(path), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+
+	blockNumber, err := parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, 110, blockNumber)
+
+	gasPrice, err := parser.GasPrice()
+	require.NoError(t, err)
+	require.Equal(t, int64(1_000_000_000), gasPrice.Int64())
+}
+
+func TestParserIPCUnavailable(t *testing.T) {
+	parser, err := NewEthParser(WithIPCPath(filepath.Join(t.TempDir(), "missing.ipc")), WithRetry(1, time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.Error(t, err)
+}
+
+func TestWithIPCPathExclusive(t *testing.T) {
+	_, err := NewEthParser(WithIPCPath(""))
+	require.Error(t, err)
+
+	_, err = NewEthParser(WithIPCPath("/tmp/geth.ipc"), WithNodeUrl("http://127.0.0.1:8545"))
+	require.Error(t, err)
+
+	_, err = NewEthParser(WithNodeUrl("http://127.0.0.1:8545"), WithIPCPath("/tmp/geth.ipc"))
+	require.Error(t, err)
+
+	_, err = NewEthParser(WithIPCPath("/tmp/geth.ipc"), WithWebSocketURL("ws://127.0.0.1:8546"))
+	require.Error(t, err)
+}
@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// LastError gets the error the last scan for the transactions of a
+// subscribed address failed with, nil when it succeeded or none ran yet
+func (e *ethParser) LastError(address string) error {
+	address, err := e.parseSubscription(context.Background(), address)
+	if err != nil {
+		return err
+	}
+
+	e.m.RLock()
+	_, ok := e.addresses[address]
+	e.m.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotSubscribed, address)
+	}
+
+	e.lastErrorsM.Lock()
+	defer e.lastErrorsM.Unlock()
+
+	return e.lastErrors[address]
+}
+
+// recordLastError remembers err as the last error of the scan for address,
+// forgetting the previous one when the scan succeeded. Addresses not
+// subscribed are left out, not to grow the map with any address asked for.
+func (e *ethParser) recordLastError(address string, err error) {
+	if errors.Is(err, ErrNotSubscribed) {
+		return
+	}
+
+	e.lastErrorsM.Lock()
+	defer e.lastErrorsM.Unlock()
+
+	if err == nil {
+		delete(e.lastErrors, address)
+		return
+	}
+	e.lastErrors[address] = err
+}
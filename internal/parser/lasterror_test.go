@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserLastError(t *testing.T) {
+	_, fail, node := newFailingChain(t, 110, 105, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0), WithRetry(1, time.Millisecond))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+	require.NoError(t, parser.LastError(address))
+
+	_, err = parser.GetTransactions(address)
+	require.Error(t, err)
+
+	lastErr := parser.LastError(address)
+	var rpcErr *JsonRPCError
+	require.ErrorAs(t, lastErr, &rpcErr)
+	require.Equal(t, "internal error", rpcErr.Message)
+
+	// a successful scan clears it
+	fail.Store(false)
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.NoError(t, parser.LastError(address))
+
+	require.ErrorIs(t, parser.LastError(other), ErrNotSubscribed)
+	_, err = parser.GetTransactions(other)
+	require.ErrorIs(t, err, ErrNotSubscribed)
+	require.Empty(t, parser.lastErrors)
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+// newTestLogger returns a logger writing JSON records of any level, and a
+// func decoding the records written so far
+func newTestLogger(t *testing.T) (*slog.Logger, func() []map[string]interface{}) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	records := func() []map[string]interface{} {
+		var records []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+
+			var record map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			records = append(records, record)
+		}
+		return records
+	}
+
+	return logger, records
+}
+
+func TestParserLogsReorg(t *testing.T) {
+	chain, node := newTestChain(t, 110, nil)
+
+	logger, records := newTestLogger(t)
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0), WithReorgDepth(5), WithLogger(logger))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	fetched := records()
+	require.NotEmpty(t, fetched)
+	for _, record := range fetched {
+		require.Equal(t, "DEBUG", record["level"])
+		require.Equal(t, "fetching transactions", record["msg"])
+	}
+
+	chain.fork(109, map[int][]models.Transaction{})
+	chain.head.Store(111)
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	var reorgs []map[string]interface{}
+	for _, record := range records() {
+		if record["msg"] == "chain reorganization" {
+			reorgs = append(reorgs, record)
+		}
+	}
+	require.Len(t, reorgs, 1)
+	require.Equal(t, "WARN", reorgs[0]["level"])
+	require.Equal(t, 110.0, reorgs[0]["blockNumber"])
+	require.Equal(t, 108.0, reorgs[0]["ancestor"])
+}
+
+func TestParserLogsBroadPattern(t *testing.T) {
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		return "0x10"
+	})
+
+	logger, records := newTestLogger(t)
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithLogger(logger))
+	require.NoError(t, err)
+
+	require.NoError(t, parser.SubscribePattern("0xab"))
+	require.Len(t, records(), 1)
+	require.Equal(t, "0xab", records()[0]["prefix"])
+	require.Equal(t, 256.0, records()[0]["matchesOneIn"])
+
+	_, err = NewEthParser(WithLogger(nil))
+	require.Error(t, err)
+}
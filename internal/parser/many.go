@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"ethparser/internal/models"
+)
+
+// addressScan is the state of an address scanned by GetTransactionsMany
+type addressScan struct {
+	match              matchFunc
+	cachedTransactions []*models.Transaction
+	cachedBlockNumber  int
+	processed          []BlockRange
+	// gaps are the block ranges not scanned yet for the address
+	gaps []BlockRange
+}
+
+func (e *ethParser) SubscribeMany(addresses []string) map[string]error {
+	return e.SubscribeManyCtx(context.Background(), addresses)
+}
+
+// SubscribeManyCtx adds addresses to the observer at the current block
+// number, fetched once for all of them. The addresses that could not be
+// added, as given, are mapped to their error.
+func (e *ethParser) SubscribeManyCtx(ctx context.Context, addresses []string) map[string]error {
+	errs := make(map[string]error)
+
+	type request struct {
+		given, address string
+	}
+	subscriptions := make([]request, 0, len(addresses))
+	for _, given := range addresses {
+		address, err := e.parseAddress(ctx, given)
+		if err != nil {
+			errs[given] = err
+			continue
+		}
+		subscriptions = append(subscriptions, request{given: given, address: address})
+	}
+
+	if len(subscriptions) == 0 {
+		return errs
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	blockNumber, err := e.getCurrentBlockNumber(ctx)
+	if err != nil {
+		for _, s := range subscriptions {
+			errs[s.given] = err
+		}
+		return errs
+	}
+
+	for _, s := range subscriptions {
+		if _, ok := e.addresses[s.address]; ok {
+			errs[s.given] = fmt.Errorf("address already subscribed: %s", s.address)
+			continue
+		}
+		e.addresses[s.address] = subscription{blockNumber: blockNumber}
+	}
+	e.saveSubscriptions()
+
+	return errs
+}
+
+func (e *ethParser) GetTransactionsMany(addresses []string) (map[string][]*models.Transaction, error) {
+	return e.GetTransactionsManyCtx(context.Background(), addresses)
+}
+
+// GetTransactionsManyCtx lists the transactions of subscribed addresses
+// mapped by normalized address. Each block not scanned yet for one of them
+// is fetched once and its transactions are bucketed per address.
+func (e *ethParser) GetTransactionsManyCtx(ctx context.Context, addresses []string) (map[string][]*models.Transaction, error) {
+	normalized := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		address, err := e.parseSubscription(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, address)
+	}
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	currentBlockNumber, err := e.getCurrentBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.checkReorg(ctx, currentBlockNumber); err != nil {
+		return nil, err
+	}
+	rewinds := e.rewindCount()
+
+	finalBlockNumber := e.finalizedBlockNumber(currentBlockNumber)
+
+	// gather the blocks every address misses, so blocks missed by several
+	// of them are fetched once
+	scans := make(map[string]*addressScan, len(normalized))
+	var blocks []BlockRange
+	for _, address := range normalized {
+		initialBlockNumber, err := e.initialBlockNumber(address)
+		if err != nil {
+			return nil, err
+		}
+
+		scan := &addressScan{match: e.addressMatcher(address)}
+		scan.cachedTransactions, scan.cachedBlockNumber = e.transactionCache.GetTransactions(address)
+		e.forgetEvicted(address, scan.cachedBlockNumber)
+		scan.processed = e.processedRanges(address, initialBlockNumber, scan.cachedBlockNumber)
+		scan.gaps = missingRanges(scan.processed, initialBlockNumber, finalBlockNumber)
+		for _, gap := range scan.gaps {
+			blocks = addRange(blocks, gap)
+		}
+		scans[address] = scan
+	}
+
+	if err := e.checkScanSize(rangesSize(blocks)); err != nil {
+		return nil, err
+	}
+
+	matchAny := func(tx *models.Transaction) bool {
+		for _, scan := range scans {
+			if scan.match(tx) {
+				return true
+			}
+		}
+		return false
+	}
+
+	found := make(map[string][]*models.Transaction, len(scans))
+	for _, r := range blocks {
+		transactions, err := e.getTransactionsFromBlockNumbers(ctx, r.From, r.To, matchAny)
+		if err != nil {
+			return nil, e.historyError(ctx, r.From, r.To, err)
+		}
+
+		for _, tx := range transactions {
+			blockNumber, err := models.ParseHexInt(tx.BlockNumber)
+			if err != nil {
+				return nil, err
+			}
+
+			// a block is only new to the addresses missing it
+			for address, scan := range scans {
+				if scan.match(tx) && containsBlock(scan.gaps, blockNumber) {
+					txCopy := *tx
+					found[address] = append(found[address], &txCopy)
+				}
+			}
+		}
+	}
+
+	result := make(map[string][]*models.Transaction, len(scans))
+	for address, scan := range scans {
+		if len(scan.gaps) == 0 {
+			result[address] = copyTransactions(scan.cachedTransactions)
+			continue
+		}
+
+		processed := scan.processed
+		for _, gap := range scan.gaps {
+			processed = addRange(processed, gap)
+		}
+
+		transactions := e.storeTransactions(address, found[address], scan.cachedTransactions, max(scan.cachedBlockNumber, finalBlockNumber), processed, rewinds)
+		result[address] = copyTransactions(transactions)
+	}
+
+	return result, nil
+}
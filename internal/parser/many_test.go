@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserSubscribeMany(t *testing.T) {
+	node, requests := newFlakyNode(t, 0, 0)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+
+	require.NoError(t, parser.Subscribe(other))
+	requests.Store(0)
+
+	errs := parser.SubscribeMany([]string{address, "0x" + strings.ToUpper(hot[2:]), "0x1234", other})
+	require.Len(t, errs, 2)
+	require.Error(t, errs["0x1234"])
+	require.Error(t, errs[other])
+
+	// the current block is fetched once for every address
+	require.Equal(t, int32(1), requests.Load())
+	require.ElementsMatch(t, []string{address, hot, other}, parser.SubscribedAddresses())
+	require.Equal(t, 0x13ecaeb, parser.addresses[address].blockNumber)
+	require.Equal(t, 0x13ecaeb, parser.addresses[hot].blockNumber)
+}
+
+func TestParserSubscribeManyNodeDown(t *testing.T) {
+	node, _ := newFlakyNode(t, 100, 500)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRetry(1, defaultRetryBase))
+	require.NoError(t, err)
+
+	errs := parser.SubscribeMany([]string{address, hot})
+	require.Len(t, errs, 2)
+	require.Empty(t, parser.SubscribedAddresses())
+}
+
+func TestParserGetTransactionsMany(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address, To: hot}},
+		104: {{Hash: "0x104", To: address}},
+		106: {{Hash: "0x106", From: hot}, {Hash: "0x1060", From: other}},
+		109: {{Hash: "0x109", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.addresses[hot] = subscription{blockNumber: 103}
+	parser.addresses[other] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactionsMany([]string{address, hot, "0x" + strings.ToUpper(hot[2:])})
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+
+	hashes := func(txs []*models.Transaction) []string {
+		hashes := make([]string, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash)
+		}
+		return hashes
+	}
+	require.ElementsMatch(t, []string{"0x101", "0x104", "0x109"}, hashes(txs[address]))
+	// block 101 precedes the subscription of hot
+	require.ElementsMatch(t, []string{"0x106"}, hashes(txs[hot]))
+
+	// each block is fetched once for both addresses
+	require.ElementsMatch(t, []int{100, 101, 102, 103, 104, 105, 106, 107, 108, 109, 110}, chain.fetchedBlocks())
+
+	cached, err := parser.GetTransactions(hot)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"0x106"}, hashes(cached))
+
+	// other was not asked for
+	ranges, err := parser.ProcessedRanges(other)
+	require.NoError(t, err)
+	require.Empty(t, ranges)
+
+	_, err = parser.GetTransactionsMany([]string{address, cold})
+	require.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"errors"
+	"time"
+)
+
+// Metrics receives measures of the calls sent to the node, for instance to
+// export them to a monitoring system
+type Metrics interface {
+	// ObserveCall records a call of method taking duration, retries
+	// included, with status "ok", "rpc_error" when the node answered with
+	// an error or "error" otherwise
+	ObserveCall(method string, status string, duration time.Duration)
+	// ObserveRetry records a failed attempt of a call of method being retried
+	ObserveRetry(method string)
+}
+
+// noopMetrics drops every measure
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveCall(string, string, time.Duration) {}
+
+func (noopMetrics) ObserveRetry(string) {}
+
+func WithMetrics(metrics Metrics) EthParserOpt {
+	return func(p *ethParser) error {
+		if metrics == nil {
+			return errors.New("metrics cannot be nil")
+		}
+		p.metrics = metrics
+		return nil
+	}
+}
+
+// observeCall records a call of method started at start ending with err
+func (e *ethParser) observeCall(method string, start time.Time, err error) {
+	status := "ok"
+	var rpcErr *JsonRPCError
+	switch {
+	case errors.As(err, &rpcErr):
+		status = "rpc_error"
+	case err != nil:
+		status = "error"
+	}
+
+	e.metrics.ObserveCall(method, status, e.clock.Now().Sub(start))
+}
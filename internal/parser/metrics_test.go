@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMetrics records the measures it receives
+type recordingMetrics struct {
+	m       sync.Mutex
+	calls   []string
+	retries []string
+}
+
+func (rm *recordingMetrics) ObserveCall(method string, status string, duration time.Duration) {
+	rm.m.Lock()
+	defer rm.m.Unlock()
+
+	rm.calls = append(rm.calls, method+" "+status)
+}
+
+func (rm *recordingMetrics) ObserveRetry(method string) {
+	rm.m.Lock()
+	defer rm.m.Unlock()
+
+	rm.retries = append(rm.retries, method)
+}
+
+func TestParserMetrics(t *testing.T) {
+	node, _ := newFlakyNode(t, 1, http.StatusServiceUnavailable)
+
+	metrics := &recordingMetrics{}
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRetry(2, time.Millisecond), WithMetrics(metrics))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, []string{"eth_blockNumber ok"}, metrics.calls)
+	require.Equal(t, []string{"eth_blockNumber"}, metrics.retries)
+
+	_, err = NewEthParser(WithMetrics(nil))
+	require.Error(t, err)
+}
+
+func TestParserMetricsRPCError(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      requestID(t, r),
+			"jsonrpc": "2.0",
+			"error":   map[string]interface{}{"code": -32005, "message": "limit exceeded"},
+		})
+	}))
+	t.Cleanup(node.Close)
+
+	metrics := &recordingMetrics{}
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithMetrics(metrics))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.Error(t, err)
+	require.Equal(t, []string{"eth_blockNumber rpc_error"}, metrics.calls)
+	require.Empty(t, metrics.retries)
+}
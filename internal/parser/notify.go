@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"context"
+	"sync"
+
+	"ethparser/internal/models"
+)
+
+// notificationBufferSize is the number of transactions buffered per
+// notification channel, transactions being dropped when it is full
+const notificationBufferSize = 64
+
+// Notifications returns a channel receiving the transactions of address as
+// they are fetched, and a func unregistering and closing it. Transactions
+// are dropped rather than blocking the parser when the channel is not read.
+func (e *ethParser) Notifications(address string) (<-chan *models.Transaction, func()) {
+	ch := make(chan *models.Transaction, notificationBufferSize)
+
+	address, err := e.parseSubscription(context.Background(), address)
+	if err != nil {
+		close(ch)
+		return ch, func() {}
+	}
+
+	e.notificationsM.Lock()
+	if e.notifications[address] == nil {
+		e.notifications[address] = make(map[chan *models.Transaction]struct{})
+	}
+	e.notifications[address][ch] = struct{}{}
+	e.notificationsM.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			e.notificationsM.Lock()
+			defer e.notificationsM.Unlock()
+
+			delete(e.notifications[address], ch)
+			if len(e.notifications[address]) == 0 {
+				delete(e.notifications, address)
+			}
+			close(ch)
+		})
+	}
+}
+
+// notify sends transactions to the notification channels of address, and
+// queues their delivery to its webhook
+func (e *ethParser) notify(address string, transactions []*models.Transaction) {
+	if len(transactions) == 0 {
+		return
+	}
+	e.queueWebhook(address, transactions)
+
+	e.notificationsM.Lock()
+	defer e.notificationsM.Unlock()
+
+	for ch := range e.notifications[address] {
+		for _, tx := range transactions {
+			select {
+			case ch <- tx:
+			default:
+				e.logger.Warn("dropped notification", "address", address, "hash", tx.Hash)
+			}
+		}
+	}
+}
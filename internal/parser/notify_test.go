@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserNotifications(t *testing.T) {
+	chain, node := newTestChain(t, 101, map[int][]models.Transaction{
+		101: {{Hash: "0x101", To: address}},
+		103: {{Hash: "0x103", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	notifications, cancel := parser.Notifications(address)
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Equal(t, "0x101", (<-notifications).Hash)
+
+	// cached transactions are not sent again
+	chain.head.Store(103)
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Equal(t, "0x103", (<-notifications).Hash)
+	require.Empty(t, notifications)
+
+	cancel()
+	cancel()
+	_, ok := <-notifications
+	require.False(t, ok)
+	require.Empty(t, parser.notifications)
+
+	chain.head.Store(104)
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+}
+
+func TestParserNotificationsInvalidAddress(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	notifications, cancel := parser.Notifications("not an address")
+	defer cancel()
+
+	_, ok := <-notifications
+	require.False(t, ok)
+}
@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"context"
+	"errors"
+
+	"ethparser/internal/models"
+)
+
+// TransactionsPage is a page of the transactions of an address
+type TransactionsPage struct {
+	Transactions []*models.Transaction `json:"transactions"`
+	// Total is the number of transactions in the block range, across pages
+	Total int `json:"total"`
+	// ScannedFrom and ScannedTo are the inclusive range of blocks the
+	// transactions were fetched from, set by QueryTransactions
+	ScannedFrom int `json:"scannedFrom,omitempty"`
+	ScannedTo   int `json:"scannedTo,omitempty"`
+}
+
+// TransactionsQuery selects a page of the transactions of an address for
+// QueryTransactions. A ToBlock or Limit of zero leaves the range or the page
+// unbounded.
+type TransactionsQuery struct {
+	Direction Direction
+	FromBlock int
+	ToBlock   int
+	Limit     int
+	Offset    int
+}
+
+func (e *ethParser) GetTransactionsPaged(address string, fromBlock, toBlock, limit, offset int) (*TransactionsPage, error) {
+	return e.GetTransactionsPagedCtx(context.Background(), address, fromBlock, toBlock, limit, offset)
+}
+
+func (e *ethParser) GetTransactionsPagedCtx(ctx context.Context, address string, fromBlock, toBlock, limit, offset int) (*TransactionsPage, error) {
+	transactions, err := e.getAllTransactions(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return Paginate(transactions, fromBlock, toBlock, limit, offset)
+}
+
+// QueryTransactions filters the transactions of address by direction and
+// pages them before capping the page to the max result size, returned along
+// with ErrResultTruncated when it is, so that every transaction can be
+// reached by paging whatever the max result size
+func (e *ethParser) QueryTransactions(ctx context.Context, address string, query TransactionsQuery) (*TransactionsPage, error) {
+	address, err := e.parseSubscription(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := e.getTransactionsCoalesced(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered, err := FilterDirection(address, result.Transactions, query.Direction)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := Paginate(filtered, query.FromBlock, query.ToBlock, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+	page.ScannedFrom, page.ScannedTo = result.FromBlock, result.ToBlock
+
+	page.Transactions, err = e.capTransactions(page.Transactions)
+	return page, err
+}
+
+// Paginate sorts transactions by block number and position in the block,
+// keeps those mined between fromBlock and toBlock included, and skips offset
+// of them before returning up to limit. A toBlock or limit of zero leaves
+// the range or the page unbounded.
+func Paginate(transactions []*models.Transaction, fromBlock, toBlock, limit, offset int) (*TransactionsPage, error) {
+	if fromBlock < 0 || toBlock < 0 {
+		return nil, errors.New("block range cannot be negative")
+	}
+	if toBlock > 0 && fromBlock > toBlock {
+		return nil, errors.New("fromBlock cannot be above toBlock")
+	}
+	if limit < 0 || offset < 0 {
+		return nil, errors.New("limit and offset cannot be negative")
+	}
+
+	inRange := make([]*models.Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		blockNumber, err := models.ParseHexInt(tx.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		if blockNumber < fromBlock || toBlock > 0 && blockNumber > toBlock {
+			continue
+		}
+
+		inRange = append(inRange, tx)
+	}
+	models.SortTransactions(inRange)
+
+	page := &TransactionsPage{
+		Transactions: []*models.Transaction{},
+		Total:        len(inRange),
+	}
+
+	if offset >= len(inRange) {
+		return page, nil
+	}
+	inRange = inRange[offset:]
+	if limit > 0 && limit < len(inRange) {
+		inRange = inRange[:limit]
+	}
+	page.Transactions = append(page.Transactions, inRange...)
+
+	return page, nil
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func pageHashes(page *TransactionsPage) []string {
+	hashes := make([]string, 0, len(page.Transactions))
+	for _, tx := range page.Transactions {
+		hashes = append(hashes, tx.Hash)
+	}
+
+	return hashes
+}
+
+func TestPaginate(t *testing.T) {
+	transactions := []*models.Transaction{
+		{Hash: "0x103", BlockNumber: "0x67"},
+		{Hash: "0x1011", BlockNumber: "0x65", TransactionIndex: "0x1"},
+		{Hash: "0x102", BlockNumber: "0x66"},
+		{Hash: "0x1010", BlockNumber: "0x65", TransactionIndex: "0x0"},
+	}
+
+	tests := []struct {
+		fromBlock, toBlock, limit, offset int
+		want                              []string
+		total                             int
+	}{
+		{want: []string{"0x1010", "0x1011", "0x102", "0x103"}, total: 4},
+		{limit: 2, want: []string{"0x1010", "0x1011"}, total: 4},
+		{limit: 2, offset: 2, want: []string{"0x102", "0x103"}, total: 4},
+		{offset: 4, want: []string{}, total: 4},
+		{fromBlock: 102, want: []string{"0x102", "0x103"}, total: 2},
+		{fromBlock: 101, toBlock: 102, limit: 1, offset: 1, want: []string{"0x1011"}, total: 3},
+	}
+
+	for _, tt := range tests {
+		page, err := Paginate(transactions, tt.fromBlock, tt.toBlock, tt.limit, tt.offset)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, pageHashes(page), tt)
+		require.Equal(t, tt.total, page.Total, tt)
+	}
+
+	_, err := Paginate(transactions, 103, 102, 0, 0)
+	require.Error(t, err)
+
+	_, err = Paginate(transactions, 0, 0, -1, 0)
+	require.Error(t, err)
+}
+
+func TestParserGetTransactionsPaged(t *testing.T) {
+	_, node := newTestChain(t, 103, map[int][]models.Transaction{
+		101: {{Hash: "0x101", To: address, TransactionIndex: "0x0"}},
+		102: {{Hash: "0x102", From: address, TransactionIndex: "0x0"}},
+		103: {{Hash: "0x103", From: address, TransactionIndex: "0x0"}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	page, err := parser.GetTransactionsPaged(address, 102, 0, 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0x102"}, pageHashes(page))
+	require.Equal(t, 2, page.Total)
+}
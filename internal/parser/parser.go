@@ -2,47 +2,391 @@ package parser
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"iter"
+	"log/slog"
+	"math/big"
 	"net/http"
+	"regexp"
+	"runtime/debug"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"golang.org/x/time/rate"
+
 	"ethparser/internal/cache"
 	"ethparser/internal/models"
 )
 
 const (
 	defaultNodeUrl = "https://cloudflare-eth.com"
+	// defaultTimeout bounds a request to a node that stopped answering
+	defaultTimeout = 30 * time.Second
 )
 
+// methodSelectorRegexp matches a 0x-prefixed 4-byte method selector
+var methodSelectorRegexp = regexp.MustCompile(`^0x[0-9a-fA-F]{8}$`)
+
 type Parser interface {
 	// GetCurrentBlock gets last parsed block
-	GetCurrentBlock() int
+	GetCurrentBlock() (int, error)
+	// GetCurrentBlockCtx is GetCurrentBlock bound to ctx
+	GetCurrentBlockCtx(ctx context.Context) (int, error)
+	// Ping checks that the node answers
+	Ping(ctx context.Context) error
+	// ChainID gets the chain id of the node
+	ChainID() (int64, error)
+	// ChainIDCtx is ChainID bound to ctx
+	ChainIDCtx(ctx context.Context) (int64, error)
+	// ResolveAddress normalizes address, resolving it first when it is an
+	// ENS name with WithENSResolution
+	ResolveAddress(ctx context.Context, address string) (string, error)
 	// Subscribe adds address to observer
-	Subscribe(address string) bool
+	Subscribe(address string) error
+	// SubscribeCtx is Subscribe bound to ctx
+	SubscribeCtx(ctx context.Context, address string) error
+	// SubscribeMany adds addresses to observer at once, mapping the ones
+	// that could not be added to their error
+	SubscribeMany(addresses []string) map[string]error
+	// SubscribeManyCtx is SubscribeMany bound to ctx
+	SubscribeManyCtx(ctx context.Context, addresses []string) map[string]error
+	// SubscribePattern adds every address starting with prefix to observer
+	SubscribePattern(prefix string) error
+	// SubscribeWithSelectors adds address to observer, collecting only
+	// transactions calling one of the given method selectors
+	SubscribeWithSelectors(address string, selectors ...string) error
+	// SubscribeWithInterval adds address to observer, polling it every
+	// interval rather than every poll interval of the parser
+	SubscribeWithInterval(address string, interval time.Duration) error
+	// SubscribeAndWarm adds address to observer and scans its history in
+	// the background
+	SubscribeAndWarm(address string) error
+	// WarmupStatus gets how far the warm-up of an address went
+	WarmupStatus(address string) (WarmupProgress, error)
+	// Unsubscribe removes address from observer
+	Unsubscribe(address string) bool
+	// ResetAddress forgets the transactions fetched for an address, keeping
+	// it subscribed
+	ResetAddress(address string) error
+	// RefreshRange scans a range of blocks again for the transactions of an
+	// address, merging the ones found into its cached transactions
+	RefreshRange(address string, fromBlock, toBlock int) error
+	// RefreshRangeCtx is RefreshRange bound to ctx
+	RefreshRangeCtx(ctx context.Context, address string, fromBlock, toBlock int) error
+	// ResetCache is ResetAddress, resetting every address when address is
+	// empty
+	ResetCache(address string) error
+	// Start polls the subscribed addresses in the background until ctx is done
+	Start(ctx context.Context)
+	// Close stops the background work and releases the resources of the
+	// parser
+	Close() error
 	// GetTransactions lists inbound or outbound transactions for an address
-	GetTransactions(address string) []*models.Transaction
+	GetTransactions(address string) ([]*models.Transaction, error)
+	// GetTransactionsCtx is GetTransactions bound to ctx
+	GetTransactionsCtx(ctx context.Context, address string) ([]*models.Transaction, error)
+	// GetTransactionsFrom scans the blocks from a block up to the current
+	// block for the transactions of an address, subscribed or not
+	GetTransactionsFrom(address string, fromBlock int) ([]*models.Transaction, error)
+	// GetTransactionsFromCtx is GetTransactionsFrom bound to ctx
+	GetTransactionsFromCtx(ctx context.Context, address string, fromBlock int) ([]*models.Transaction, error)
+	// GetTransactionsMany lists the transactions of several addresses,
+	// scanning each block once for all of them
+	GetTransactionsMany(addresses []string) (map[string][]*models.Transaction, error)
+	// GetTransactionsManyCtx is GetTransactionsMany bound to ctx
+	GetTransactionsManyCtx(ctx context.Context, addresses []string) (map[string][]*models.Transaction, error)
+	// GetTransactionsWithRange lists the transactions of an address along
+	// with the inclusive range of blocks they were fetched from
+	GetTransactionsWithRange(address string) (txs []*models.Transaction, fromBlock, toBlock int, err error)
+	// GetTransactionsWithRangeCtx is GetTransactionsWithRange bound to ctx
+	GetTransactionsWithRangeCtx(ctx context.Context, address string) (txs []*models.Transaction, fromBlock, toBlock int, err error)
+	// GetTransactionsFiltered lists the transactions of an address in the
+	// given direction
+	GetTransactionsFiltered(address string, direction Direction) ([]*models.Transaction, error)
+	// GetTransactionsFilteredCtx is GetTransactionsFiltered bound to ctx
+	GetTransactionsFilteredCtx(ctx context.Context, address string, direction Direction) ([]*models.Transaction, error)
+	// GetTransactionsPaged lists a page of the transactions of an address
+	// mined between fromBlock and toBlock, sorted by block and position
+	GetTransactionsPaged(address string, fromBlock, toBlock, limit, offset int) (*TransactionsPage, error)
+	// GetTransactionsPagedCtx is GetTransactionsPaged bound to ctx
+	GetTransactionsPagedCtx(ctx context.Context, address string, fromBlock, toBlock, limit, offset int) (*TransactionsPage, error)
+	// QueryTransactions lists a page of the transactions of an address in
+	// a direction, capped to the max result size after paging
+	QueryTransactions(ctx context.Context, address string, query TransactionsQuery) (*TransactionsPage, error)
+	// GetTransactionsWithBudget lists the transactions of an address that
+	// could be fetched within budget
+	GetTransactionsWithBudget(ctx context.Context, address string, budget time.Duration) (*PartialTransactions, error)
+	// GetTransactionsProgressive lists the transactions of an address,
+	// passing them to yield as they are found
+	GetTransactionsProgressive(ctx context.Context, address string, yield func([]*models.Transaction) error) ([]*models.Transaction, error)
+	// GetTransactionsSeq iterates over the transactions of an address as
+	// they are found, stopping the scan when the loop breaks
+	GetTransactionsSeq(address string) (iter.Seq[*models.Transaction], func() error)
+	// GetTransactionsSeqCtx is GetTransactionsSeq bound to ctx
+	GetTransactionsSeqCtx(ctx context.Context, address string) (iter.Seq[*models.Transaction], func() error)
+	// TransactionsHash gets a digest of the transactions of an address,
+	// changing whenever the set of transactions does
+	TransactionsHash(address string) (string, error)
+	// GetTransactionSummary counts the transactions of an address and sums
+	// the wei they send to and from it
+	GetTransactionSummary(address string) (count int, totalIn, totalOut *big.Int, err error)
+	// GetTransactionSummaryCtx is GetTransactionSummary bound to ctx
+	GetTransactionSummaryCtx(ctx context.Context, address string) (count int, totalIn, totalOut *big.Int, err error)
+	// ProcessedRanges lists the block ranges already scanned for an address
+	ProcessedRanges(address string) ([]BlockRange, error)
+	// EstimateScan gets how many blocks GetTransactions would fetch for an
+	// address, and whether part of its blocks were already scanned
+	EstimateScan(address string) (blocksToScan int, cached bool, err error)
+	// EstimateScanCtx is EstimateScan bound to ctx
+	EstimateScanCtx(ctx context.Context, address string) (blocksToScan int, cached bool, err error)
+	// IsSynced reports whether the transactions of an address are
+	// fetched up to the current block
+	IsSynced(address string) (bool, error)
+	// RegisterWebhook POSTs the new transactions of an address to
+	// callbackURL
+	RegisterWebhook(address, callbackURL string) error
+	// LastError gets the error the last scan for the transactions of an
+	// address failed with
+	LastError(address string) error
+	// SyncStatus tells how many blocks the transactions cached for an
+	// address are behind the current block
+	SyncStatus(address string) (processedBlock, headBlock, behind int, err error)
+	// SubscribedAddresses lists the subscribed addresses
+	SubscribedAddresses() []string
+	// Subscriptions maps the subscribed addresses to the block number they
+	// were subscribed at
+	Subscriptions() map[string]int
+	// ExportAll maps the subscribed addresses to their cached transactions
+	ExportAll() map[string][]*models.Transaction
+	// ExportAddress gets the cached transactions of a subscribed address
+	ExportAddress(address string) []*models.Transaction
+	// GetTokenTransfers lists the ERC-20 transfers of a token for an address
+	GetTokenTransfers(address string, token string) ([]*models.TokenTransfer, error)
+	// GetBalance gets the current balance in wei of an address
+	GetBalance(address string) (*big.Int, error)
+	// GetBalanceCtx is GetBalance bound to ctx
+	GetBalanceCtx(ctx context.Context, address string) (*big.Int, error)
+	// Notifications streams the new transactions of an address until the
+	// returned func is called
+	Notifications(address string) (<-chan *models.Transaction, func())
+	// OnNewTransactions calls hook with the new transactions of the
+	// subscribed addresses once they are cached
+	OnNewTransactions(hook func(address string, txs []*models.Transaction))
+	// Errors streams the non-fatal errors met in the background
+	Errors() <-chan error
+	// Stats gets the JSON-RPC calls sent to the node
+	Stats() Stats
+	// CacheStats gets the size and hit rate of the transaction cache
+	CacheStats() cache.CacheStats
+	// RecentActivity lists the most recently scanned blocks, the most
+	// recent first
+	RecentActivity() []BlockActivity
+	// BalanceDelta gets the change of the balance of an address over a
+	// block range, which requires an archive node
+	BalanceDelta(address string, fromBlock, toBlock int) (*big.Int, error)
+	// GetBlock gets a block with its transactions
+	GetBlock(blockNumber int) (*models.BlockWithDetails, error)
+	// GetBlockCtx is GetBlock bound to ctx
+	GetBlockCtx(ctx context.Context, blockNumber int) (*models.BlockWithDetails, error)
+	// GetBlockByTag gets the block a tag refers to with its transactions
+	GetBlockByTag(tag BlockTag) (*models.BlockWithDetails, error)
+	// GetBlockByTagCtx is GetBlockByTag bound to ctx
+	GetBlockByTagCtx(ctx context.Context, tag BlockTag) (*models.BlockWithDetails, error)
+	// GetTransactionByHash gets a transaction by its hash
+	GetTransactionByHash(hash string) (*models.Transaction, error)
+	// GetTransactionByHashCtx is GetTransactionByHash bound to ctx
+	GetTransactionByHashCtx(ctx context.Context, hash string) (*models.Transaction, error)
+	// GetTransactionReceipt gets the receipt of a transaction, telling
+	// whether it succeeded or reverted
+	GetTransactionReceipt(hash string) (*models.Receipt, error)
+	// GetTransactionReceiptCtx is GetTransactionReceipt bound to ctx
+	GetTransactionReceiptCtx(ctx context.Context, hash string) (*models.Receipt, error)
+	// GetBlockReceipts gets the receipts of every transaction of a block
+	GetBlockReceipts(blockNumber int) ([]*models.Receipt, error)
+	// GetBlockReceiptsCtx is GetBlockReceipts bound to ctx
+	GetBlockReceiptsCtx(ctx context.Context, blockNumber int) ([]*models.Receipt, error)
+	// GasPrice gets the current gas price in wei
+	GasPrice() (*big.Int, error)
+	// GasPriceCtx is GasPrice bound to ctx
+	GasPriceCtx(ctx context.Context) (*big.Int, error)
 }
 
 type ethParser struct {
 	client *http.Client
-	url    string
+	// timeout bounds every request sent to a node, 0 leaving it to the
+	// client and the caller's context
+	timeout time.Duration
+	// requestHeaders are set on every request sent to a node, as the
+	// credentials of hosted nodes. They are never logged.
+	requestHeaders http.Header
+	// nodes are the endpoints requests are sent to
+	nodes *endpointPool
+	// batchSize is the number of blocks fetched per JSON-RPC batch request,
+	// 0 walks the blocks one request at a time
+	batchSize int
+	// concurrency is the number of goroutines fetching a block range,
+	// 0 or 1 fetches it sequentially
+	concurrency int
+	// confirmations is the depth below the head transactions are returned
+	// from
+	confirmations int
+	// reorgDepth is the depth after which blocks are no longer checked for
+	// reorgs
+	reorgDepth int
+	// pollInterval is how often the poller refreshes an address
+	pollInterval time.Duration
+	// pollAddress refreshes an address on behalf of the poller
+	pollAddress func(ctx context.Context, address string)
+	// pollBlocks processes the blocks mined after a block on behalf of the
+	// poller, returning the last block processed
+	pollBlocks func(ctx context.Context, lastBlock int) int
+	// maxBlockRange is the most blocks a call scans, 0 for no limit
+	maxBlockRange int
+	// maxResultSize is the most transactions GetTransactions returns, 0 for
+	// no limit
+	maxResultSize int
+	// chainID memoizes the chain id of the node
+	chainID chainIDCache
+	// ens resolves the ENS names set by WithENSResolution
+	ens ensCache
+	// expectedChainID is the chain id the node must be on, 0 for any
+	expectedChainID int64
+	// filter further restricts the transactions collected, if set
+	filter matchFunc
+	// earliestBlock is the block scans start from at the earliest
+	earliestBlock int
+	// scanStrategy is the order the blocks not scanned yet are walked in
+	scanStrategy ScanStrategy
+	// partialResults keeps the transactions found before a failure
+	partialResults bool
+	// allowFullScan lets GetTransactions scan from the genesis block of a
+	// long chain
+	allowFullScan bool
+	// maxAttempts is the number of times a request is sent before failing
+	maxAttempts int
+	// retryBase is the backoff before the first retry, doubling with
+	// every other one
+	retryBase time.Duration
+	// limiter is shared by every request sent to the node
+	limiter *rate.Limiter
+	metrics Metrics
+	// wsURL is the WebSocket endpoint of the node new heads are followed
+	// on, if any
+	wsURL string
+	// ipcPath is the Unix socket of the node requests are sent on instead
+	// of HTTP, if any
+	ipcPath string
+	logger  *slog.Logger
+	clock   Clock
+	// blocks keeps the most recently fetched blocks
+	blocks *blockCache
+	// headers keeps the most recent processed block headers to resolve
+	// reorgs without querying the node
+	headers *headerRing
+	// activity keeps the most recently scanned blocks
+	activity *activityLog
+
+	// closed is done once Close is called, stopping the background work
+	// tracked by running
+	closed    context.Context
+	close     context.CancelFunc
+	running   sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
 
 	m sync.RWMutex
-	// addresses is a set of addresses mapped by the latest block number
-	// when they were added to the observer
-	addresses map[string]int
+	// addresses is a set of subscriptions mapped by the addresses added
+	// to the observer
+	addresses map[string]subscription
+	// selectors is a set of method selectors mapped by the addresses
+	// whose transactions are restricted to them
+	selectors  map[string][]string
+	processedM sync.Mutex
+	// processed is a set of scanned block ranges mapped by addresses
+	processed map[string][]BlockRange
+	// rewinds counts the rewinds of reorgs, guarded by processedM, so that
+	// a scan overlapping one drops the state it read before it
+	rewinds int
+	// lastErrors are the errors the last scan of the addresses failed
+	// with, guarded by their own mutex as scans only hold e.m for reading
+	lastErrorsM sync.Mutex
+	lastErrors  map[string]error
+
+	// warmups are the warm-ups started by SubscribeAndWarm mapped by
+	// addresses
+	warmupsM sync.Mutex
+	warmups  map[string]*warmup
+	// subscribeGroup coalesces concurrent Subscribe calls for the same address
+	subscribeGroup sharedCalls
+	// transactionsGroup coalesces concurrent GetTransactions calls for the
+	// same address
+	transactionsGroup sharedCalls
+	// blockNumber memoizes the current block number
+	blockNumber *blockNumberCache
+	// breaker fast-fails requests while the node keeps failing
+	breaker *circuitBreaker
+	// rpcStats counts the JSON-RPC calls sent to the node
+	rpcStats *rpcStats
+	// notifications are the channels new transactions are sent to mapped
+	// by address
+	notificationsM sync.Mutex
+	notifications  map[string]map[chan *models.Transaction]struct{}
+	// webhooks are the callback URLs new transactions are POSTed to mapped
+	// by address
+	webhooksM          sync.Mutex
+	webhooks           map[string]string
+	webhookQueue       chan webhookDelivery
+	webhookSecret      []byte
+	webhookClient      *http.Client
+	webhookMaxAttempts int
+	webhookRetryBase   time.Duration
+	// hooks are the funcs registered with OnNewTransactions, called with
+	// the calls queued in hookQueue
+	hooksM    sync.Mutex
+	hooks     []func(address string, txs []*models.Transaction)
+	hookQueue chan hookCall
+	hooksOnce sync.Once
+	// errs are the non-fatal errors not read from Errors yet
+	errs          chan error
+	droppedErrors atomic.Int64
+	// requestID is the id of the last request sent to the node
+	requestID atomic.Int64
+	// blockReceiptsUnsupported is set once the node answered that it
+	// doesn't support eth_getBlockReceipts
+	blockReceiptsUnsupported atomic.Bool
 
 	transactionCache cache.Cache
+	// cacheTTL is the ttl of the in-memory cache set with WithCacheTTL,
+	// created once the clock is known
+	cacheTTL time.Duration
+	// subscriptionStore persists addresses, if set
+	subscriptionStore SubscriptionStore
 }
 
 var _ Parser = &ethParser{}
 
+// ErrBlockNotFound is returned for blocks the node doesn't have
+var ErrBlockNotFound = errors.New("block not found")
+
+// ErrBlockNotYetAvailable is returned for blocks at the head of the chain
+// the node answered null for, which it may have when asked again
+var ErrBlockNotYetAvailable = errors.New("block not yet available")
+
+// ErrNotSubscribed is returned for addresses not in the observer
+var ErrNotSubscribed = errors.New("address not found in the observer")
+
+// ErrTransactionNotFound is returned for transactions the node doesn't have
+var ErrTransactionNotFound = errors.New("transaction not found")
+
 type JsonRPCRequest struct {
 	ID      int           `json:"id"`
 	Jsonrpc string        `json:"jsonrpc"`
@@ -50,10 +394,31 @@ type JsonRPCRequest struct {
 	Params  []interface{} `json:"params"`
 }
 
+// JsonRPCResponseError is the error member of any JSON-RPC response
+type JsonRPCResponseError struct {
+	// ID is the id of the request the response answers
+	ID    int           `json:"id"`
+	Error *JsonRPCError `json:"error"`
+}
+
+// JsonRPCError is the error a node answers a request with
+type JsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (rpcErr *JsonRPCError) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", rpcErr.Code, rpcErr.Message)
+}
+
 type JsonRPCResponseBlockNumber struct {
 	Result string `json:"result"`
 }
 
+type JsonRPCResponseGasPrice struct {
+	Result string `json:"result"`
+}
+
 type JsonRPCResponseBlock struct {
 	Result models.BlockWithDetails `json:"result"`
 }
@@ -62,6 +427,9 @@ type JsonRPCResponseTransaction struct {
 	Result models.Transaction `json:"result"`
 }
 
+// matchFunc reports whether a transaction is collected while parsing blocks
+type matchFunc func(tx *models.Transaction) bool
+
 type EthParserOpt func(*ethParser) error
 
 func WithHTTPClient(client *http.Client) EthParserOpt {
@@ -74,24 +442,211 @@ func WithHTTPClient(client *http.Client) EthParserOpt {
 	}
 }
 
+// WithTimeout bounds every request sent to a node to d, whatever the HTTP
+// client, a request timing out being retried on the next endpoint. 0
+// disables it.
+func WithTimeout(d time.Duration) EthParserOpt {
+	return func(p *ethParser) error {
+		if d < 0 {
+			return errors.New("timeout cannot be negative")
+		}
+		p.timeout = d
+		return nil
+	}
+}
+
+// WithHeader sets the header key to value on every request sent to the
+// nodes, as the custom headers some providers want
+func WithHeader(key, value string) EthParserOpt {
+	return func(p *ethParser) error {
+		if key == "" {
+			return errors.New("header key cannot be empty")
+		}
+		p.requestHeaders.Set(key, value)
+		return nil
+	}
+}
+
+// WithAuthHeader is WithHeader for the API key header some hosted nodes
+// require
+func WithAuthHeader(key, value string) EthParserOpt {
+	return WithHeader(key, value)
+}
+
+// WithUserAgent replaces the default User-Agent of the requests sent to the
+// nodes, which some public endpoints throttle
+func WithUserAgent(userAgent string) EthParserOpt {
+	return func(p *ethParser) error {
+		if userAgent == "" {
+			return errors.New("user agent cannot be empty")
+		}
+		p.requestHeaders.Set("User-Agent", userAgent)
+		return nil
+	}
+}
+
+// defaultUserAgent identifies the parser and the version of the module it
+// was built from
+func defaultUserAgent() string {
+	version := "devel"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+
+	return "ethparser/" + version
+}
+
+// WithBearerToken authenticates every request sent to the nodes with token
+func WithBearerToken(token string) EthParserOpt {
+	return func(p *ethParser) error {
+		if token == "" {
+			return errors.New("bearer token cannot be empty")
+		}
+		p.requestHeaders.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// WithBasicAuth authenticates every request sent to the nodes with HTTP
+// basic auth
+func WithBasicAuth(username, password string) EthParserOpt {
+	return func(p *ethParser) error {
+		if username == "" {
+			return errors.New("basic auth username cannot be empty")
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		p.requestHeaders.Set("Authorization", "Basic "+credentials)
+		return nil
+	}
+}
+
+// WithCache stores the transactions of the subscribed addresses in c, an
+// in-memory cache otherwise
+func WithCache(c cache.Cache) EthParserOpt {
+	return func(p *ethParser) error {
+		if c == nil {
+			return errors.New("cache cannot be nil")
+		}
+		p.transactionCache = c
+		return nil
+	}
+}
+
+// WithCacheTTL stores the transactions of the subscribed addresses in an
+// in-memory cache dropping those of an address ttl after they were last
+// added to, the time being told by the clock set with WithClock. It replaces
+// the cache set with WithCache.
+func WithCacheTTL(ttl time.Duration) EthParserOpt {
+	return func(p *ethParser) error {
+		if ttl <= 0 {
+			return errors.New("cache ttl must be positive")
+		}
+		p.cacheTTL = ttl
+		return nil
+	}
+}
+
+// WithLogger sets the logger of the parser, slog.Default() otherwise
+func WithLogger(logger *slog.Logger) EthParserOpt {
+	return func(p *ethParser) error {
+		if logger == nil {
+			return errors.New("logger cannot be nil")
+		}
+		p.logger = logger
+		return nil
+	}
+}
+
 func WithNodeUrl(url string) EthParserOpt {
+	return WithNodeUrls(url)
+}
+
+// WithTransactionFilter collects only the transactions of the subscribed
+// addresses filter accepts, as ones above a value or to a given contract.
+// It runs on every transaction matching an address in every block scanned,
+// the ones it rejects being neither cached nor notified.
+func WithTransactionFilter(filter func(tx *models.Transaction) bool) EthParserOpt {
+	return func(p *ethParser) error {
+		if filter == nil {
+			return errors.New("transaction filter cannot be nil")
+		}
+		p.filter = filter
+		return nil
+	}
+}
+
+// WithBatchSize fetches block ranges with JSON-RPC batch requests of n blocks
+func WithBatchSize(n int) EthParserOpt {
+	return func(p *ethParser) error {
+		if n < 1 {
+			return errors.New("batch size must be positive")
+		}
+		p.batchSize = n
+		return nil
+	}
+}
+
+// WithConcurrency fetches block ranges with up to n concurrent goroutines
+func WithConcurrency(n int) EthParserOpt {
+	return func(p *ethParser) error {
+		if n < 1 {
+			return errors.New("concurrency must be positive")
+		}
+		p.concurrency = n
+		return nil
+	}
+}
+
+// WithHeaderBufferSize keeps the headers of the last k processed blocks
+func WithHeaderBufferSize(k int) EthParserOpt {
 	return func(p *ethParser) error {
-		if url == "" {
-			return errors.New("url cannot be empty")
+		if k < 1 {
+			return errors.New("header buffer size must be positive")
 		}
-		p.url = url
+		p.headers = newHeaderRing(k)
 		return nil
 	}
 }
 
 func NewEthParser(opts ...EthParserOpt) (*ethParser, error) {
 	e := &ethParser{
-		url:              defaultNodeUrl,
-		client:           http.DefaultClient,
-		m:                sync.RWMutex{},
-		addresses:        make(map[string]int),
-		transactionCache: cache.NewMemCache(),
+		nodes:              newEndpointPool([]string{defaultNodeUrl}),
+		client:             http.DefaultClient,
+		timeout:            defaultTimeout,
+		requestHeaders:     http.Header{"User-Agent": {defaultUserAgent()}},
+		m:                  sync.RWMutex{},
+		addresses:          make(map[string]subscription),
+		selectors:          make(map[string][]string),
+		processed:          make(map[string][]BlockRange),
+		lastErrors:         make(map[string]error),
+		warmups:            make(map[string]*warmup),
+		webhooks:           make(map[string]string),
+		webhookQueue:       make(chan webhookDelivery, webhookQueueSize),
+		hookQueue:          make(chan hookCall, hookQueueSize),
+		webhookClient:      http.DefaultClient,
+		webhookMaxAttempts: webhookMaxAttempts,
+		webhookRetryBase:   webhookRetryBase,
+		headers:            newHeaderRing(defaultHeaderBufferSize),
+		blocks:             newBlockCache(defaultBlockCacheSize),
+		activity:           newActivityLog(defaultActivityBufferSize),
+		pollInterval:       defaultPollInterval,
+		reorgDepth:         defaultReorgDepth,
+		maxAttempts:        defaultMaxAttempts,
+		retryBase:          defaultRetryBase,
+		limiter:            rate.NewLimiter(defaultRateLimit, defaultRateBurst),
+		metrics:            noopMetrics{},
+		logger:             slog.Default(),
+		clock:              realClock{},
+		rpcStats:           newRPCStats(),
+		blockNumber:        &blockNumberCache{ttl: defaultBlockNumberTTL},
+		breaker:            &circuitBreaker{},
+		notifications:      make(map[string]map[chan *models.Transaction]struct{}),
+		errs:               make(chan error, errorsBufferSize),
+		transactionCache:   cache.NewMemCache(),
 	}
+	e.closed, e.close = context.WithCancel(context.Background())
+	e.pollAddress = e.refreshAddress
+	e.pollBlocks = e.refreshBlocks
 
 	for _, opt := range opts {
 		if err := opt(e); err != nil {
@@ -99,135 +654,821 @@ func NewEthParser(opts ...EthParserOpt) (*ethParser, error) {
 		}
 	}
 
+	// WithClock may come after WithCacheTTL
+	if e.cacheTTL > 0 {
+		e.transactionCache = cache.NewMemCacheWithTTL(e.cacheTTL, e.clock)
+	}
+
+	if err := e.checkTransports(); err != nil {
+		return nil, err
+	}
+
+	if err := e.checkChainID(context.Background()); err != nil {
+		return nil, err
+	}
+
+	e.restoreSubscriptions()
+
 	return e, nil
 }
 
-func (e *ethParser) GetCurrentBlock() int {
-	blockNumber, err := e.getCurrentBlockNumber()
+func (e *ethParser) GetCurrentBlock() (int, error) {
+	return e.GetCurrentBlockCtx(context.Background())
+}
+
+func (e *ethParser) GetCurrentBlockCtx(ctx context.Context) (int, error) {
+	return e.getCurrentBlockNumber(ctx)
+}
+
+func (e *ethParser) Subscribe(address string) error {
+	return e.SubscribeCtx(context.Background(), address)
+}
+
+func (e *ethParser) SubscribeCtx(ctx context.Context, address string) error {
+	address, err := e.parseAddress(ctx, address)
 	if err != nil {
-		log.Println(err)
-		return 0
+		return err
 	}
 
-	return blockNumber
+	return e.subscribeCoalesced(ctx, address, nil, 0)
 }
 
-func (e *ethParser) Subscribe(address string) bool {
+func (e *ethParser) SubscribeWithSelectors(address string, selectors ...string) error {
+	address, err := e.parseAddress(context.Background(), address)
+	if err != nil {
+		return err
+	}
+
+	normalized := make([]string, 0, len(selectors))
+	for _, selector := range selectors {
+		if !methodSelectorRegexp.MatchString(selector) {
+			return fmt.Errorf("invalid method selector: %q", selector)
+		}
+		normalized = append(normalized, strings.ToLower(selector))
+	}
+
+	return e.subscribeCoalesced(context.Background(), address, normalized, 0)
+}
+
+// SubscribeWithInterval adds address to the observer, the poller refreshing
+// it every interval rather than every poll interval of the parser
+func (e *ethParser) SubscribeWithInterval(address string, interval time.Duration) error {
+	address, err := e.parseAddress(context.Background(), address)
+	if err != nil {
+		return err
+	}
+
+	if interval <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+
+	return e.subscribeCoalesced(context.Background(), address, nil, interval)
+}
+
+// subscribeCoalesced coalesces concurrent identical subscriptions. The shared
+// subscription runs until the last of its callers is done rather than until
+// the ctx of the call starting it is, a caller giving up not failing the
+// others, and is canceled once they all give up so that the address is
+// subscribed only when one of them succeeds.
+func (e *ethParser) subscribeCoalesced(ctx context.Context, address string, selectors []string, interval time.Duration) error {
+	key := strings.Join(append([]string{address, interval.String()}, selectors...), ",")
+	_, err := e.subscribeGroup.do(ctx, e.closed, key, func(ctx context.Context) (interface{}, error) {
+		return nil, e.subscribe(ctx, address, selectors, interval)
+	})
+
+	return err
+}
+
+// subscribe adds address to the observer at the current block number, with
+// its own poll interval unless interval is 0
+func (e *ethParser) subscribe(ctx context.Context, address string, selectors []string, interval time.Duration) error {
 	e.m.Lock()
 	defer e.m.Unlock()
 
 	if _, ok := e.addresses[address]; ok {
-		log.Println("address already subscribed", address)
-		return false
+		return fmt.Errorf("address already subscribed: %s", address)
 	}
 
-	blockNumber, err := e.getCurrentBlockNumber()
+	blockNumber, err := e.getCurrentBlockNumber(ctx)
 	if err != nil {
-		log.Println(err)
+		return err
+	}
+
+	e.addresses[address] = subscription{blockNumber: blockNumber, interval: interval}
+	if len(selectors) > 0 {
+		e.selectors[address] = selectors
+	}
+	e.saveSubscriptions()
+
+	return nil
+}
+
+func (e *ethParser) Unsubscribe(address string) bool {
+	address, err := e.parseSubscription(context.Background(), address)
+	if err != nil {
+		return false
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if _, ok := e.addresses[address]; !ok {
 		return false
 	}
 
-	e.addresses[address] = blockNumber
+	delete(e.addresses, address)
+	delete(e.selectors, address)
+	e.saveSubscriptions()
+	e.transactionCache.ClearAddress(address)
+
+	e.processedM.Lock()
+	delete(e.processed, address)
+	e.processedM.Unlock()
+
+	e.lastErrorsM.Lock()
+	delete(e.lastErrors, address)
+	e.lastErrorsM.Unlock()
+
+	e.warmupsM.Lock()
+	delete(e.warmups, address)
+	e.warmupsM.Unlock()
+
+	e.webhooksM.Lock()
+	delete(e.webhooks, address)
+	e.webhooksM.Unlock()
+
 	return true
 }
 
-func (e *ethParser) GetTransactions(address string) []*models.Transaction {
+// ResetAddress forgets the transactions fetched for a subscribed address, so
+// the next GetTransactions walks again from the block it was subscribed at.
+// Unlike Unsubscribe, the address stays observed.
+func (e *ethParser) ResetAddress(address string) error {
+	address, err := e.parseSubscription(context.Background(), address)
+	if err != nil {
+		return err
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if _, ok := e.addresses[address]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotSubscribed, address)
+	}
+
+	e.transactionCache.ClearAddress(address)
+
+	e.processedM.Lock()
+	delete(e.processed, address)
+	e.processedM.Unlock()
+
+	return nil
+}
+
+// ResetCache is ResetAddress, resetting every subscribed address along with
+// the recent blocks and block headers when address is empty, as after
+// switching nodes
+func (e *ethParser) ResetCache(address string) error {
+	if address != "" {
+		return e.ResetAddress(address)
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	e.transactionCache.Clear()
+	e.headers.dropAfter(-1)
+	e.blocks.dropAfter(-1)
+
+	e.processedM.Lock()
+	clear(e.processed)
+	e.processedM.Unlock()
+
+	return nil
+}
+
+func (e *ethParser) GetTransactions(address string) ([]*models.Transaction, error) {
+	return e.GetTransactionsCtx(context.Background(), address)
+}
+
+// GetTransactionsCtx lists the transactions of address, at most the max
+// result size most recent ones along with ErrResultTruncated
+func (e *ethParser) GetTransactionsCtx(ctx context.Context, address string) ([]*models.Transaction, error) {
+	transactions, err := e.getAllTransactions(ctx, address)
+	if err != nil {
+		return transactions, err
+	}
+
+	return e.capTransactions(transactions)
+}
+
+// getAllTransactions is GetTransactionsCtx without the max result size, for
+// the calls going through every transaction
+func (e *ethParser) getAllTransactions(ctx context.Context, address string) ([]*models.Transaction, error) {
+	address, err := e.parseSubscription(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := e.getTransactionsCoalesced(ctx, address)
+	if result == nil {
+		return nil, err
+	}
+
+	return result.Transactions, err
+}
+
+func (e *ethParser) GetTransactionsWithRange(address string) ([]*models.Transaction, int, int, error) {
+	return e.GetTransactionsWithRangeCtx(context.Background(), address)
+}
+
+// GetTransactionsWithRangeCtx is GetTransactionsCtx also returning the
+// inclusive range of blocks the transactions were fetched from, cached
+// blocks included
+func (e *ethParser) GetTransactionsWithRangeCtx(ctx context.Context, address string) (txs []*models.Transaction, fromBlock, toBlock int, err error) {
+	address, err = e.parseSubscription(ctx, address)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	result, err := e.getTransactionsCoalesced(ctx, address)
+	if result == nil {
+		return nil, 0, 0, err
+	}
+	if err != nil {
+		return result.Transactions, result.FromBlock, result.ToBlock, err
+	}
+
+	txs, err = e.capTransactions(result.Transactions)
+	return txs, result.FromBlock, result.ToBlock, err
+}
+
+// getTransactionsCoalesced is getTransactions without a budget, concurrent
+// calls for an address sharing a single walk and each getting its own copy
+// of the transactions. The walk runs until the last of its callers is done
+// rather than until the ctx of the call starting it is, a caller giving up
+// not failing the others.
+func (e *ethParser) getTransactionsCoalesced(ctx context.Context, address string) (*PartialTransactions, error) {
+	val, err := e.transactionsGroup.do(ctx, e.closed, address, func(ctx context.Context) (interface{}, error) {
+		return e.getTransactions(ctx, address, 0, nil)
+	})
+
+	// with partial results, the transactions found before a failure come
+	// along with it
+	shared, _ := val.(*PartialTransactions)
+	if shared == nil {
+		return nil, err
+	}
+
+	result := *shared
+	result.Transactions = copyTransactions(shared.Transactions)
+	return &result, err
+}
+
+// copyTransactions deep copies transactions, so a caller modifying them
+// doesn't affect the callers sharing the same result
+func copyTransactions(transactions []*models.Transaction) []*models.Transaction {
+	copies := make([]*models.Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		txCopy := *tx
+		copies = append(copies, &txCopy)
+	}
+
+	return copies
+}
+
+// getTransactions fetches the transactions of address in every block not
+// scanned yet. With a positive budget, the walk stops when the budget runs
+// out and the transactions found so far are returned. A non-nil yield is
+// called with the cached transactions and then with the new ones of each
+// range as it is scanned. The outcome of the scan is kept for LastError.
+func (e *ethParser) getTransactions(ctx context.Context, address string, budget time.Duration, yield func([]*models.Transaction) error) (*PartialTransactions, error) {
+	// the errors of yield are the caller's, not the address failing
+	var yieldErr error
+	if yield != nil {
+		callerYield := yield
+		yield = func(transactions []*models.Transaction) error {
+			yieldErr = callerYield(transactions)
+			return yieldErr
+		}
+	}
+
+	result, err := e.scanTransactions(ctx, address, budget, yield)
+	if yieldErr == nil && ctx.Err() == nil {
+		e.recordLastError(address, err)
+	}
+
+	return result, err
+}
+
+// scanTransactions is getTransactions without recording its error
+func (e *ethParser) scanTransactions(ctx context.Context, address string, budget time.Duration, yield func([]*models.Transaction) error) (*PartialTransactions, error) {
 	e.m.RLock()
 	defer e.m.RUnlock()
 
-	initialBlockNumber, err := e.getAddressInitialBlockNumber(address)
+	// e.m is already held, taking it again would deadlock behind a pending
+	// Subscribe
+	initialBlockNumber, err := e.initialBlockNumber(address)
 	if err != nil {
-		log.Println(err)
-		return nil
+		return nil, err
+	}
+
+	currentBlockNumber, err := e.getCurrentBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.checkReorg(ctx, currentBlockNumber); err != nil {
+		return nil, err
 	}
 
+	// a reorg found by a concurrent scan, which only holds e.m for reading
+	// as well, rewinds the state read from here on
+	rewinds := e.rewindCount()
+	match := e.addressMatcher(address)
 	cachedTransactions, cachedBlockNumber := e.transactionCache.GetTransactions(address)
+	e.forgetEvicted(address, cachedBlockNumber)
+
+	// backfill every block not scanned yet, including gaps left behind
+	// while the parser was not running
+	processed := e.processedRanges(address, initialBlockNumber, cachedBlockNumber)
+	if yield != nil && len(cachedTransactions) > 0 {
+		yieldErr := e.yieldUnlocked(yield, copyTransactions(cachedTransactions))
+		if err := e.checkStillSubscribed(address, initialBlockNumber); err != nil {
+			return nil, err
+		}
+		if yieldErr != nil {
+			return nil, yieldErr
+		}
+	}
 
-	currentBlockNumber := e.GetCurrentBlock()
-	if cachedBlockNumber == currentBlockNumber {
-		return cachedTransactions
+	finalBlockNumber := e.finalizedBlockNumber(currentBlockNumber)
+	gaps := missingRanges(processed, initialBlockNumber, finalBlockNumber)
+	if len(gaps) == 0 {
+		fromBlock, toBlock := scannedRange(processed, initialBlockNumber)
+		return &PartialTransactions{Transactions: cachedTransactions, FromBlock: fromBlock, ToBlock: toBlock}, nil
 	}
 
-	var fromBlockNumber int
-	var toBlockNumber int
+	// a budget already bounds the scan
+	if budget <= 0 {
+		if err := e.checkFullScan(address, initialBlockNumber, gaps); err != nil {
+			return nil, err
+		}
+		if err := e.checkScanSize(rangesSize(gaps)); err != nil {
+			return nil, err
+		}
+	}
+
+	walkCtx := ctx
+	if budget > 0 {
+		var cancel context.CancelFunc
+		walkCtx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+
+		// walk down from the head one window at a time, so the most
+		// recent blocks are scanned first and finished windows are kept
+		gaps = splitRanges(gaps, budgetWindowSize)
+	} else if (e.partialResults || yield != nil || e.warming(address)) && e.scanStrategy == FullRange {
+		// walk up from the oldest block one window at a time, so the
+		// windows finished before a failure or before yield stops the walk
+		// are kept without a gap, and a warm-up reports its progress as
+		// they are done
+		gaps = splitRanges(gaps, partialWindowSize)
+		slices.Reverse(gaps)
+	}
+
+	// without a budget, the incremental strategy walks down from the head
+	// instead, in windows growing while they are empty
+	windowSize := 0
+	if budget <= 0 && e.scanStrategy == Incremental {
+		windowSize = incrementalWindowSize
+	}
+
+	yielded := make(map[string]bool, len(cachedTransactions))
+	for _, tx := range cachedTransactions {
+		yielded[tx.Hash] = true
+	}
 
-	if cachedBlockNumber == 0 {
-		fromBlockNumber = initialBlockNumber
-		toBlockNumber = currentBlockNumber
-	} else {
-		fromBlockNumber = cachedBlockNumber
-		toBlockNumber = currentBlockNumber
+	result := &PartialTransactions{}
+	var transactions []*models.Transaction
+	for len(gaps) > 0 {
+		var gap BlockRange
+		if windowSize > 0 {
+			gap, gaps = takeWindow(gaps, windowSize)
+		} else {
+			gap, gaps = gaps[0], gaps[1:]
+		}
+
+		gapTransactions, err := e.getTransactionsFromBlockNumbers(walkCtx, gap.From, gap.To, match)
+		if err != nil {
+			if budget > 0 && walkCtx.Err() != nil && ctx.Err() == nil {
+				result.Truncated = true
+				result.ResumeFrom = gap.To
+				break
+			}
+
+			err = e.historyError(ctx, gap.From, gap.To, err)
+			if e.partialResults {
+				// the cached block only covers the blocks scanned
+				// without a gap, the next call resuming after them
+				result.FromBlock, result.ToBlock = scannedRange(processed, initialBlockNumber)
+				result.Transactions = e.storeTransactions(address, transactions, cachedTransactions, max(cachedBlockNumber, result.ToBlock), processed, rewinds)
+				return result, &PartialResultError{ReachedBlock: result.ToBlock, Err: err}
+			}
+			return nil, err
+		}
+
+		transactions = append(transactions, gapTransactions...)
+		processed = addRange(processed, gap)
+		e.recordWarmupScan(address, gap)
+
+		if yield != nil {
+			var found []*models.Transaction
+			for _, tx := range gapTransactions {
+				if !yielded[tx.Hash] {
+					yielded[tx.Hash] = true
+					found = append(found, tx)
+				}
+			}
+
+			if len(found) > 0 {
+				models.SortTransactions(found)
+				yieldErr := e.yieldUnlocked(yield, copyTransactions(found))
+				if err := e.checkStillSubscribed(address, initialBlockNumber); err != nil {
+					return nil, err
+				}
+				if yieldErr != nil {
+					// the windows scanned so far are kept for the next call
+					_, toBlock := scannedRange(processed, initialBlockNumber)
+					e.storeTransactions(address, transactions, cachedTransactions, max(cachedBlockNumber, toBlock), processed, rewinds)
+					return nil, yieldErr
+				}
+			}
+		}
+
+		if windowSize > 0 && len(gapTransactions) == 0 {
+			windowSize *= 2
+		}
 	}
 
-	transactions, err := e.getTransactionsFromBlockNumbers(fromBlockNumber, toBlockNumber, address)
+	result.Transactions = e.storeTransactions(address, transactions, cachedTransactions, max(cachedBlockNumber, finalBlockNumber), processed, rewinds)
+	result.FromBlock, result.ToBlock = scannedRange(processed, initialBlockNumber)
+	return result, nil
+}
+
+// yieldUnlocked calls yield with transactions while e.m, held by the scan,
+// is released, so that yield may call back into the parser and a slow yield
+// doesn't hold up Subscribe and Unsubscribe
+func (e *ethParser) yieldUnlocked(yield func([]*models.Transaction) error, transactions []*models.Transaction) error {
+	e.m.RUnlock()
+	defer e.m.RLock()
+
+	return yield(transactions)
+}
+
+// checkStillSubscribed fails with ErrNotSubscribed once address is no longer
+// subscribed from initialBlockNumber, as after being unsubscribed while e.m
+// was released, the scan then having to stop without caching anything
+func (e *ethParser) checkStillSubscribed(address string, initialBlockNumber int) error {
+	current, err := e.initialBlockNumber(address)
 	if err != nil {
-		log.Println(err)
-		return nil
+		return err
+	}
+	if current != initialBlockNumber {
+		return fmt.Errorf("%w: %s was subscribed again during the scan", ErrNotSubscribed, address)
 	}
 
+	return nil
+}
+
+// storeTransactions caches the transactions found for address along with
+// the cached ones up to blockNumber and records the processed ranges, then
+// notifies the new ones and passes them to the hooks, returning every
+// transaction of address once. The cache and the ranges are left as they are
+// when a reorg rewound them since the scan read them, rewinds being the
+// rewind count then, the blocks being scanned again by the next scan.
+func (e *ethParser) storeTransactions(address string, found, cachedTransactions []*models.Transaction, blockNumber int, processed []BlockRange, rewinds int) []*models.Transaction {
+	// a block scanned again, as one a restored cache holds transactions
+	// of, finds transactions already cached
+	cachedHashes := make(map[string]bool, len(cachedTransactions))
+	for _, tx := range cachedTransactions {
+		cachedHashes[tx.Hash] = true
+	}
+
+	fresh := make([]*models.Transaction, 0, len(found))
+	for _, tx := range found {
+		if !cachedHashes[tx.Hash] {
+			fresh = append(fresh, tx)
+		}
+	}
+
+	transactions := found
 	if len(cachedTransactions) > 0 {
-		transactions = append(transactions, cachedTransactions...)
+		foundHashes := make(map[string]bool, len(found))
+		for _, tx := range found {
+			foundHashes[tx.Hash] = true
+		}
+
+		transactions = slices.Clone(found)
+		for _, tx := range cachedTransactions {
+			if !foundHashes[tx.Hash] {
+				transactions = append(transactions, tx)
+			}
+		}
 	}
+	// blocks walked down from the head or in windows are not found in chain
+	// order
+	models.SortTransactions(transactions)
+
+	// processedM is held across the cache, for a rewind not to run in
+	// between
+	e.processedM.Lock()
+	if e.rewinds != rewinds {
+		e.processedM.Unlock()
+		e.logger.Debug("dropping a scan overlapping a reorg", "address", address)
+		return transactions
+	}
+	e.transactionCache.AddTransactions(address, transactions, blockNumber)
+	e.processed[address] = processed
+	e.processedM.Unlock()
+
+	e.notify(address, fresh)
+	e.queueHooks(address, fresh)
 
-	e.transactionCache.AddTransactions(address, transactions, toBlockNumber)
 	return transactions
 }
 
+// rewindCount gets the number of rewinds so far, for storeTransactions
+func (e *ethParser) rewindCount() int {
+	e.processedM.Lock()
+	defer e.processedM.Unlock()
+
+	return e.rewinds
+}
+
+func (e *ethParser) ProcessedRanges(address string) ([]BlockRange, error) {
+	address, err := e.parseSubscription(context.Background(), address)
+	if err != nil {
+		return nil, err
+	}
+
+	initialBlockNumber, err := e.getAddressInitialBlockNumber(address)
+	if err != nil {
+		return nil, err
+	}
+
+	_, cachedBlockNumber := e.transactionCache.GetTransactions(address)
+	return e.processedRanges(address, initialBlockNumber, cachedBlockNumber), nil
+}
+
+// IsSynced reports whether the backfill of address is done, i.e. every block
+// from its initial block up to the current block, bar the unconfirmed ones,
+// has been scanned
+func (e *ethParser) IsSynced(address string) (bool, error) {
+	address, err := e.parseSubscription(context.Background(), address)
+	if err != nil {
+		return false, err
+	}
+
+	initialBlockNumber, err := e.getAddressInitialBlockNumber(address)
+	if err != nil {
+		return false, err
+	}
+
+	currentBlockNumber, err := e.getCurrentBlockNumber(context.Background())
+	if err != nil {
+		return false, err
+	}
+
+	_, cachedBlockNumber := e.transactionCache.GetTransactions(address)
+	if cachedBlockNumber < initialBlockNumber {
+		return false, nil
+	}
+
+	processed := e.processedRanges(address, initialBlockNumber, cachedBlockNumber)
+	gaps := missingRanges(processed, initialBlockNumber, max(cachedBlockNumber, e.finalizedBlockNumber(currentBlockNumber)))
+	return len(gaps) == 0, nil
+}
+
+// SyncStatus gets the last block the transactions of address were cached up
+// to, the current block and how many blocks the former is behind. Before
+// anything is cached, the processed block is the one before the initial
+// block of address.
+func (e *ethParser) SyncStatus(address string) (processedBlock, headBlock, behind int, err error) {
+	address, err = e.parseSubscription(context.Background(), address)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	initialBlockNumber, err := e.getAddressInitialBlockNumber(address)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	headBlock, err = e.getCurrentBlockNumber(context.Background())
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	_, cachedBlockNumber := e.transactionCache.GetTransactions(address)
+	processedBlock = max(cachedBlockNumber, initialBlockNumber-1)
+	return processedBlock, headBlock, max(headBlock-processedBlock, 0), nil
+}
+
+func (e *ethParser) SubscribedAddresses() []string {
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	addresses := make([]string, 0, len(e.addresses))
+	for address := range e.addresses {
+		addresses = append(addresses, address)
+	}
+	slices.Sort(addresses)
+
+	return addresses
+}
+
+// Subscriptions gets a copy of the subscribed addresses mapped by the block
+// number they were subscribed at
+func (e *ethParser) Subscriptions() map[string]int {
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	return e.subscriptionBlocks()
+}
+
+func (e *ethParser) GetBlock(blockNumber int) (*models.BlockWithDetails, error) {
+	return e.GetBlockCtx(context.Background(), blockNumber)
+}
+
+func (e *ethParser) GetBlockCtx(ctx context.Context, blockNumber int) (*models.BlockWithDetails, error) {
+	if blockNumber < 0 {
+		return nil, fmt.Errorf("invalid block number: %d", blockNumber)
+	}
+
+	block, err := e.getBlockFromNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// the node answers null for blocks it doesn't have
+	if block.Number == "" {
+		return nil, fmt.Errorf("%w: %d", ErrBlockNotFound, blockNumber)
+	}
+
+	return block, nil
+}
+
+func (e *ethParser) GetTransactionByHash(hash string) (*models.Transaction, error) {
+	return e.GetTransactionByHashCtx(context.Background(), hash)
+}
+
+func (e *ethParser) GetTransactionByHashCtx(ctx context.Context, hash string) (*models.Transaction, error) {
+	if !models.IsValidHash(hash) {
+		return nil, fmt.Errorf("invalid transaction hash: %q", hash)
+	}
+
+	rpcRequest := JsonRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_getTransactionByHash",
+		Params:  []interface{}{hash},
+	}
+
+	rpcResponse, err := do[JsonRPCResponseTransaction](ctx, e, rpcRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	// the node answers null for transactions it doesn't have
+	if rpcResponse.Result.Hash == "" {
+		return nil, fmt.Errorf("%w: %s", ErrTransactionNotFound, hash)
+	}
+
+	return &rpcResponse.Result, nil
+}
+
+func (e *ethParser) GasPrice() (*big.Int, error) {
+	return e.GasPriceCtx(context.Background())
+}
+
+func (e *ethParser) GasPriceCtx(ctx context.Context) (*big.Int, error) {
+	rpcRequest := JsonRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_gasPrice",
+		Params:  []interface{}{},
+	}
+
+	rpcResponse, err := do[JsonRPCResponseGasPrice](ctx, e, rpcRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	gasPrice, ok := new(big.Int).SetString(strings.TrimPrefix(rpcResponse.Result, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid gas price: %q", rpcResponse.Result)
+	}
+
+	return gasPrice, nil
+}
+
 // getAddressInitialBlockNumber gets the initial block number for an address
 func (e *ethParser) getAddressInitialBlockNumber(address string) (int, error) {
 	e.m.RLock()
 	defer e.m.RUnlock()
 
-	blockNumber, ok := e.addresses[address]
+	return e.initialBlockNumber(address)
+}
+
+// initialBlockNumber is getAddressInitialBlockNumber for callers holding e.m,
+// clamped to the earliest block scanned
+func (e *ethParser) initialBlockNumber(address string) (int, error) {
+	sub, ok := e.addresses[address]
 	if !ok {
-		return 0, fmt.Errorf("address not found in the observer: %s", address)
+		return 0, fmt.Errorf("%w: %s", ErrNotSubscribed, address)
 	}
 
-	return blockNumber, nil
+	return max(sub.blockNumber, e.earliestBlock), nil
 }
 
-// getCurrentBlockNumber gets the current block number
-func (e *ethParser) getCurrentBlockNumber() (int, error) {
+// processedRanges gets the block ranges scanned for an address. When none
+// were tracked, e.g. for a cache filled by another process, everything from
+// the initial block up to the cached block is assumed scanned.
+func (e *ethParser) processedRanges(address string, initialBlockNumber, cachedBlockNumber int) []BlockRange {
+	e.processedM.Lock()
+	defer e.processedM.Unlock()
+
+	ranges, ok := e.processed[address]
+	if !ok && cachedBlockNumber >= initialBlockNumber {
+		return []BlockRange{{From: initialBlockNumber, To: cachedBlockNumber}}
+	}
+
+	return slices.Clone(ranges)
+}
+
+// forgetEvicted drops the processed ranges of an address the cache no
+// longer holds, as one evicted or expired, so that its blocks are scanned
+// again rather than its transactions lost
+func (e *ethParser) forgetEvicted(address string, cachedBlockNumber int) {
+	if cachedBlockNumber != 0 {
+		return
+	}
+
+	e.processedM.Lock()
+	defer e.processedM.Unlock()
+
+	delete(e.processed, address)
+}
+
+// fetchCurrentBlockNumber gets the current block number from the node
+func (e *ethParser) fetchCurrentBlockNumber(ctx context.Context) (int, error) {
 	rpcRequest := JsonRPCRequest{
-		ID:      1,
 		Jsonrpc: "2.0",
 		Method:  "eth_blockNumber",
 		Params:  []interface{}{},
 	}
 
-	rpcResponse, err := do[JsonRPCResponseBlockNumber](rpcRequest, e.url)
+	rpcResponse, err := do[JsonRPCResponseBlockNumber](ctx, e, rpcRequest)
 	if err != nil {
 		return 0, err
 	}
 
-	blockNumber, err := strconv.ParseInt(rpcResponse.Result, 0, 0)
+	blockNumber, err := models.ParseHexInt(rpcResponse.Result)
 	if err != nil {
-		log.Println(err)
-		return 0, err
+		return 0, fmt.Errorf("invalid current block number: %w", err)
 	}
 
-	return int(blockNumber), nil
+	return blockNumber, nil
 }
 
 // getTransactionsFromBlockNumber gets transactions from startBlock to endBlock
-func (e *ethParser) getTransactionsFromBlockNumbers(endingBlockNumber, headBlockNumber int, address string) ([]*models.Transaction, error) {
-	var allTransactions []*models.Transaction
+func (e *ethParser) getTransactionsFromBlockNumbers(ctx context.Context, endingBlockNumber, headBlockNumber int, match matchFunc) ([]*models.Transaction, error) {
+	// walking down from below endingBlockNumber would never reach it
+	if endingBlockNumber > headBlockNumber {
+		return nil, fmt.Errorf("invalid block range: %d to %d", endingBlockNumber, headBlockNumber)
+	}
 
-	req := JsonRPCRequest{
-		ID:      1,
-		Jsonrpc: "2.0",
-		Method:  "eth_getBlockByNumber",
-		Params:  []interface{}{intToHex(headBlockNumber), true},
+	if e.concurrency > 1 {
+		return e.getTransactionsConcurrently(ctx, endingBlockNumber, headBlockNumber, match)
 	}
 
-	rpcResponse, err := do[JsonRPCResponseBlock](req, e.url)
+	if e.batchSize > 0 {
+		return e.getTransactionsInBatches(ctx, endingBlockNumber, headBlockNumber, headBlockNumber, match)
+	}
+
+	var allTransactions []*models.Transaction
+
+	head, err := e.getBlockFromNumber(ctx, headBlockNumber)
 	if err != nil {
 		return nil, err
 	}
+	// walking down from a null head would follow an empty parent hash
+	if head.Number == "" {
+		return nil, nullBlockError(headBlockNumber, headBlockNumber)
+	}
 
-	log.Println("fetching transactions for block", headBlockNumber)
+	e.logger.Debug("fetching transactions", "blockNumber", headBlockNumber)
 
-	transactions, err := e.getTransactionsFromBlock(&rpcResponse.Result, address)
+	transactions, err := e.getTransactionsFromBlock(head, match)
 	if err != nil {
 		return nil, err
 	}
@@ -238,7 +1479,7 @@ func (e *ethParser) getTransactionsFromBlockNumbers(endingBlockNumber, headBlock
 		return allTransactions, nil
 	}
 
-	transactions, err = e.getTransactionsInBlockRange(endingBlockNumber, rpcResponse.Result.ParentHash, address)
+	transactions, err = e.getTransactionsInBlockRange(ctx, endingBlockNumber, head.ParentHash, match)
 	if err != nil {
 		return nil, err
 	}
@@ -248,69 +1489,94 @@ func (e *ethParser) getTransactionsFromBlockNumbers(endingBlockNumber, headBlock
 	return allTransactions, nil
 }
 
-// getTransactionsFromBlockHash recursively gets transactions from blocks
-// moving from headBlockHash to the lastBlockNumber
-func (e *ethParser) getTransactionsInBlockRange(endingBlockNumber int, headBlockHash string, address string) ([]*models.Transaction, error) {
+// getTransactionsInBlockRange gets transactions from blocks walking parent
+// hashes from headBlockHash down to endingBlockNumber
+func (e *ethParser) getTransactionsInBlockRange(ctx context.Context, endingBlockNumber int, headBlockHash string, match matchFunc) ([]*models.Transaction, error) {
 	var allTransactions []*models.Transaction
 
-	req := JsonRPCRequest{
-		ID:      1,
-		Jsonrpc: "2.0",
-		Method:  "eth_getBlockByHash",
-		Params:  []interface{}{headBlockHash, true},
-	}
+	for blockHash := headBlockHash; ; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
-	var rpcResponse *JsonRPCResponseBlock
-	var err error
+		block, err := e.getBlockFromHash(ctx, blockHash)
+		if err != nil {
+			return nil, err
+		}
 
-	for i := 0; i < 10; i++ {
-		time.Sleep(time.Duration(i) * time.Second)
-		rpcResponse, err = do[JsonRPCResponseBlock](req, e.url)
-		if err == nil && rpcResponse.Result.Number != "" {
-			break
+		if block.Number == "" {
+			return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, blockHash)
 		}
-	}
 
-	log.Println("fetching transactions for block", rpcResponse.Result.Number)
+		e.logger.Debug("fetching transactions", "blockNumber", block.Number)
 
-	if err != nil {
-		return nil, err
+		transactions, err := e.getTransactionsFromBlock(block, match)
+		if err != nil {
+			return nil, err
+		}
+		allTransactions = append(allTransactions, transactions...)
+
+		blockNumber, err := models.ParseHexInt(block.Number)
+		if err != nil {
+			return nil, err
+		}
+
+		if blockNumber <= endingBlockNumber {
+			return allTransactions, nil
+		}
+
+		blockHash = block.ParentHash
+	}
+}
+
+// nullBlockError is the error of blockNumber when the node answered null
+// for it: blocks from headBlockNumber on may not have reached the node yet,
+// the ones below it are missing
+func nullBlockError(blockNumber, headBlockNumber int) error {
+	if blockNumber >= headBlockNumber {
+		return fmt.Errorf("%w: %d", ErrBlockNotYetAvailable, blockNumber)
 	}
 
-	transactions, err := e.getTransactionsFromBlock(&rpcResponse.Result, address)
-	if err != nil {
-		return nil, err
+	return fmt.Errorf("%w: %d", ErrBlockNotFound, blockNumber)
+}
+
+// getBlockFromNumber gets block by block number, from the block cache if
+// it was fetched recently
+func (e *ethParser) getBlockFromNumber(ctx context.Context, blockNumber int) (*models.BlockWithDetails, error) {
+	if block, ok := e.blocks.get(blockNumber, ""); ok {
+		return block, nil
 	}
-	allTransactions = append(allTransactions, transactions...)
 
-	blockNumber, err := strconv.ParseInt(rpcResponse.Result.Number, 0, 0)
+	block, err := e.fetchBlockFromNumber(ctx, blockNumber)
 	if err != nil {
+		e.recordFetchError(blockNumber, "", err)
 		return nil, err
 	}
 
-	if int(blockNumber) == endingBlockNumber {
-		return allTransactions, nil
-	}
+	e.blocks.add(block)
+	return block, nil
+}
 
-	transactions, err = e.getTransactionsInBlockRange(endingBlockNumber, rpcResponse.Result.ParentHash, address)
+// fetchBlockFromNumber gets block by block number from the node
+func (e *ethParser) fetchBlockFromNumber(ctx context.Context, blockNumber int) (*models.BlockWithDetails, error) {
+	hexNumber, err := intToHex(blockNumber)
 	if err != nil {
 		return nil, err
 	}
-	allTransactions = append(allTransactions, transactions...)
 
-	return allTransactions, nil
+	return e.fetchBlock(ctx, hexNumber)
 }
 
-// getBlockFromNumber gets block by block number
-func (e *ethParser) getBlockFromNumber(blockNumber int) (*models.BlockWithDetails, error) {
+// fetchBlock gets the block at block, a hex encoded number or a block tag,
+// from the node
+func (e *ethParser) fetchBlock(ctx context.Context, block string) (*models.BlockWithDetails, error) {
 	rpcRequest := JsonRPCRequest{
-		ID:      1,
 		Jsonrpc: "2.0",
 		Method:  "eth_getBlockByNumber",
-		Params:  []interface{}{intToHex(blockNumber), true},
+		Params:  []interface{}{block, true},
 	}
 
-	rpcResponse, err := do[JsonRPCResponseBlock](rpcRequest, e.url)
+	rpcResponse, err := do[JsonRPCResponseBlock](ctx, e, rpcRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -318,50 +1584,285 @@ func (e *ethParser) getBlockFromNumber(blockNumber int) (*models.BlockWithDetail
 	return &rpcResponse.Result, nil
 }
 
-// getTransactionsFromBlock gets transactions from a block and filters them by address
-func (e *ethParser) getTransactionsFromBlock(block *models.BlockWithDetails, address string) ([]*models.Transaction, error) {
+// addressMatcher matches transactions from or to address, or from or to
+// any address starting with it when it is a pattern, restricted to the
+// method selectors address was subscribed with, if any, and accepted by the
+// transaction filter of e, if any.
+// e.m must be held by the caller.
+func (e *ethParser) addressMatcher(address string) matchFunc {
+	selectors := e.selectors[address]
+	matchAddress := addressMatch(address)
+
+	return func(tx *models.Transaction) bool {
+		if !matchAddress(tx.To) && !matchAddress(tx.From) {
+			return false
+		}
+
+		if len(selectors) > 0 && !slices.Contains(selectors, tx.MethodSelector()) {
+			return false
+		}
+
+		return e.filter == nil || e.filter(tx)
+	}
+}
+
+// addressMatch matches a transaction address against address, or against
+// any address starting with it when it is a pattern. The empty To of
+// contract creations never matches, so they only match on their From.
+func addressMatch(address string) func(txAddress string) bool {
+	if isPattern(address) {
+		return func(txAddress string) bool {
+			return txAddress != "" && strings.HasPrefix(normalizeTxAddress(txAddress), address)
+		}
+	}
+
+	return func(txAddress string) bool {
+		return txAddress != "" && normalizeTxAddress(txAddress) == address
+	}
+}
+
+// getTransactionsFromBlock gets transactions from a block and filters them
+// with match, recording the block as processed
+func (e *ethParser) getTransactionsFromBlock(block *models.BlockWithDetails, match matchFunc) ([]*models.Transaction, error) {
+	e.recordHeader(block)
+
 	var allTransactions []*models.Transaction
-	for _, tx := range block.Transactions {
-		if tx.To == address || tx.From == address {
-			allTransactions = append(allTransactions, &tx)
+	for i := range block.Transactions {
+		// point at the element rather than the loop variable, which
+		// before Go 1.22 is shared by every iteration
+		tx := &block.Transactions[i]
+		tx.BlockTimestamp = block.Timestamp
+		if match(tx) {
+			allTransactions = append(allTransactions, tx)
 		}
 	}
 
+	e.recordScan(block, len(allTransactions))
 	return allTransactions, nil
 }
 
-// do sends a JSON RPC request to the node and returns a response
-func do[T any](rpcRequest JsonRPCRequest, url string) (*T, error) {
-	requestBody, err := json.Marshal(rpcRequest)
+// do sends a JSON RPC request to the node of e and returns a response
+func do[T any](ctx context.Context, e *ethParser, rpcRequest JsonRPCRequest) (rpcResponse *T, err error) {
+	rpcRequest.ID = e.nextRequestID()
+	e.rpcStats.record(rpcRequest)
+	start := e.clock.Now()
+	defer func() { e.observeCall(rpcRequest.Method, start, err) }()
+
+	responseBody, err := post(ctx, e, rpcRequest.Method, rpcRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(requestBody))
+	return decodeResponse[T](responseBody, rpcRequest.ID)
+}
+
+// nextRequestID returns an id no other request sent by e has, so responses
+// can be told apart
+func (e *ethParser) nextRequestID() int {
+	return int(e.requestID.Add(1))
+}
+
+// decodeResponse decodes a JSON-RPC response to the request with id,
+// returning the error the node answered with if any
+func decodeResponse[T any](responseBody []byte, id int) (*T, error) {
+	var errorResponse JsonRPCResponseError
+	if err := json.Unmarshal(responseBody, &errorResponse); err != nil {
+		return nil, err
+	}
+
+	if errorResponse.Error != nil {
+		return nil, errorResponse.Error
+	}
+
+	if errorResponse.ID != id {
+		return nil, fmt.Errorf("response id %d does not match request id %d", errorResponse.ID, id)
+	}
+
+	var rpcResponse T
+	if err := json.Unmarshal(responseBody, &rpcResponse); err != nil {
+		return nil, err
+	}
+
+	return &rpcResponse, nil
+}
+
+// post sends a JSON encoded payload calling method to the node of e and
+// returns the response body, retrying transient failures unless the circuit
+// breaker is open
+func post(ctx context.Context, e *ethParser, method string, payload interface{}) ([]byte, error) {
+	requestBody, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
+
+	if err := e.breaker.allow(e.clock.Now()); err != nil {
+		return nil, err
+	}
+
+	responseBody, err := e.sendRetrying(ctx, method, requestBody)
+	// a request given up by its caller tells nothing about the node
+	if err != nil && ctx.Err() != nil {
+		e.breaker.release()
+		return nil, err
+	}
+
+	if state, changed := e.breaker.record(err != nil, e.clock.Now()); changed {
+		e.logger.Warn("circuit breaker state changed", "state", state)
+	}
+
+	return responseBody, err
+}
+
+// sendRetrying sends a JSON request body calling method to the node of e,
+// retrying transient failures with backoff
+func (e *ethParser) sendRetrying(ctx context.Context, method string, requestBody []byte) ([]byte, error) {
+	send := e.sendFailover
+	if e.ipcPath != "" {
+		send = e.sendIPC
+	}
+
+	for attempt := 1; ; attempt++ {
+		responseBody, retryable, err := send(ctx, requestBody)
+		if err == nil {
+			return responseBody, nil
+		}
+
+		if !retryable || attempt >= e.maxAttempts {
+			return nil, err
+		}
+
+		e.reportError(fmt.Errorf("request attempt %d failed, retrying: %w", attempt, err))
+		e.metrics.ObserveRetry(method)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-e.clock.After(e.backoff(attempt)):
+		}
+	}
+}
+
+// sendFailover sends a JSON request body to each endpoint in turn until one
+// answers, stopping at the first error not worth retrying
+func (e *ethParser) sendFailover(ctx context.Context, requestBody []byte) ([]byte, bool, error) {
+	var lastErr error
+	for _, node := range e.nodes.order(e.clock.Now()) {
+		if err := e.limiter.Wait(ctx); err != nil {
+			return nil, false, err
+		}
+
+		responseBody, retryable, err := e.send(ctx, requestBody, node.url)
+		if err == nil {
+			e.nodes.succeeded(node)
+			return responseBody, false, nil
+		}
+
+		if !retryable {
+			return nil, false, err
+		}
+
+		if e.nodes.failed(node, e.clock.Now()) {
+			e.logger.Warn("node endpoint failing, trying it last", "url", node.url, "cooldown", endpointCooldown)
+		}
+		lastErr = err
+	}
+
+	return nil, true, lastErr
+}
+
+// send posts a JSON request body to the node at url once within the
+// timeout of e, if positive, and returns the response body, or an error and
+// whether it is worth retrying
+func (e *ethParser) send(ctx context.Context, requestBody []byte, url string) ([]byte, bool, error) {
+	reqCtx := ctx
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, false, err
+	}
+	for key, values := range e.requestHeaders {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, retryableStatus(resp.StatusCode), fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, ctx.Err() == nil, err
 	}
 
-	var rpcResponse T
-	err = json.Unmarshal(responseBody, &rpcResponse)
+	return responseBody, false, nil
+}
+
+// parseAddress resolves address when it is an ENS name, then normalizes it.
+// Every method taking an address parses it, so that ENS names are accepted
+// everywhere with WithENSResolution.
+func (e *ethParser) parseAddress(ctx context.Context, address string) (string, error) {
+	address, err := e.resolveAddress(ctx, address)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	return &rpcResponse, nil
+	return normalizeAddress(address)
 }
 
-func intToHex(i int) string {
-	hexString := strconv.FormatInt(int64(i), 16) // Convert int to int64 and then to hex
-	return fmt.Sprintf("0x%s", hexString)
+// parseSubscription is parseAddress for the key of a subscription, either an
+// address, an ENS name or an address prefix
+func (e *ethParser) parseSubscription(ctx context.Context, key string) (string, error) {
+	key, err := e.resolveAddress(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return normalizeSubscription(key)
+}
+
+// normalizeAddress validates a 0x-prefixed 20-byte hex address and lowercases
+// it, so checksummed and lowercase forms of an address are the same
+func normalizeAddress(address string) (string, error) {
+	if !models.IsValidAddress(address) {
+		return "", fmt.Errorf("invalid address: %q", address)
+	}
+
+	return strings.ToLower(address), nil
+}
+
+// normalizeSubscription normalizes the key of a subscription, either an
+// address or an address prefix subscribed with SubscribePattern
+func normalizeSubscription(key string) (string, error) {
+	if !patternRegexp.MatchString(key) {
+		return "", fmt.Errorf("invalid address: %q", key)
+	}
+
+	return strings.ToLower(key), nil
+}
+
+// normalizeTxAddress lowercases an address read from a transaction, which
+// nodes may return checksummed
+func normalizeTxAddress(address string) string {
+	return strings.ToLower(address)
+}
+
+// intToHex hex encodes a quantity, as block numbers, for a JSON RPC param,
+// rejecting the negative ones nodes don't accept
+func intToHex(i int) (string, error) {
+	if i < 0 {
+		return "", fmt.Errorf("invalid quantity: %d", i)
+	}
+
+	return "0x" + strconv.FormatInt(int64(i), 16), nil
 }
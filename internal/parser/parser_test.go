@@ -1,32 +1,1480 @@
 package parser
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/cache"
+	"ethparser/internal/models"
 )
 
 const (
 	address       = "0xcb81fa1fc2a94461f49d9106dcb7772a29288efe"
 	nodeNumberHex = "0x13ecaeb"
+	// testChainGenesisTime is the unix time of block 0 of a test chain,
+	// mining a block every 12 seconds
+	testChainGenesisTime = 1_700_000_000
 )
 
 func TestParserGetCurrentBlock(t *testing.T) {
+	blockNumber, err := strconv.ParseInt(nodeNumberHex, 0, 0)
+	require.NoError(t, err)
+
+	_, node := newTestChain(t, int(blockNumber), map[int][]models.Transaction{
+		int(blockNumber): {{Hash: "0x01", To: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	currentBlock, err := parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, int(blockNumber), currentBlock)
+
+	err = parser.Subscribe(address)
+	require.NoError(t, err)
+	require.Equal(t, int(blockNumber), parser.addresses[address].blockNumber)
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	txs, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+}
+
+// newTestNode starts a fake JSON-RPC node that answers every request with
+// the result returned by handle for the request method and params, or with
+// the error it returns as a *JsonRPCError. Batch responses are sent in
+// reverse order, as nodes may reorder them.
+func newTestNode(t testing.TB, handle func(method string, params []interface{}) interface{}) *httptest.Server {
+	t.Helper()
+
+	respond := func(req JsonRPCRequest) interface{} {
+		result := handle(req.Method, req.Params)
+		if rpcErr, ok := result.(*JsonRPCError); ok {
+			return map[string]interface{}{"id": req.ID, "jsonrpc": "2.0", "error": rpcErr}
+		}
+
+		return map[string]interface{}{
+			"id":      req.ID,
+			"jsonrpc": "2.0",
+			"result":  result,
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var batch []JsonRPCRequest
+		if err := json.Unmarshal(body, &batch); err == nil {
+			responses := make([]interface{}, len(batch))
+			for i, req := range batch {
+				responses[len(batch)-1-i] = respond(req)
+			}
+			json.NewEncoder(w).Encode(responses)
+			return
+		}
+
+		var req JsonRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(respond(req))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// countingTransport counts the HTTP requests sent to the node
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (ct *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ct.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// blockHash is the hash of block n in a test chain
+func blockHash(n int) string {
+	return fmt.Sprintf("0xb%d", n)
+}
+
+// hexNumber hex encodes n for test blocks
+func hexNumber(n int) string {
+	return fmt.Sprintf("0x%x", n)
+}
+
+// testChain is a fake node serving a chain of blocks whose transactions are
+// set per block number, recording the blocks fetched from it
+type testChain struct {
+	head         atomic.Int64
+	transactions map[int][]models.Transaction
+	// missing are blocks the node answers with a null result
+	missing map[int]bool
+	// forkedTransactions replace transactions once the chain is forked
+	forkedTransactions map[int][]models.Transaction
+	// forkedFrom is the first block replaced by a reorg, 0 if none
+	forkedFrom atomic.Int64
+
+	m       sync.Mutex
+	fetched []int
+}
+
+func newTestChain(t *testing.T, head int, transactions map[int][]models.Transaction) (*testChain, *httptest.Server) {
+	t.Helper()
+
+	chain := &testChain{transactions: transactions}
+	chain.head.Store(int64(head))
+
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		var number int64
+		switch method {
+		case "eth_blockNumber":
+			return hexNumber(int(chain.head.Load()))
+		case "eth_getBlockByNumber":
+			number, _ = strconv.ParseInt(params[0].(string), 0, 0)
+		case "eth_getBlockByHash":
+			hash := params[0].(string)
+			number, _ = strconv.ParseInt(hash[3:], 10, 0)
+			if chain.hash(int(number)) != hash {
+				return nil
+			}
+		default:
+			return nil
+		}
+
+		return chain.block(int(number))
+	})
+
+	return chain, node
+}
+
+func (c *testChain) block(number int) *models.BlockWithDetails {
+	c.m.Lock()
+	c.fetched = append(c.fetched, number)
+	c.m.Unlock()
+
+	if c.missing[number] {
+		return nil
+	}
+
+	transactions := c.transactions[number]
+	if c.forked(number) {
+		transactions = c.forkedTransactions[number]
+	}
+
+	block := &models.BlockWithDetails{
+		Hash:       c.hash(number),
+		ParentHash: c.hash(number - 1),
+		Number:     hexNumber(number),
+		Timestamp:  hexNumber(testChainGenesisTime + 12*number),
+	}
+	for _, tx := range transactions {
+		tx.BlockHash = block.Hash
+		tx.BlockNumber = block.Number
+		block.Transactions = append(block.Transactions, tx)
+	}
+
+	return block
+}
+
+// fork replaces every block from number on with a block of a new branch
+func (c *testChain) fork(number int, transactions map[int][]models.Transaction) {
+	c.m.Lock()
+	c.forkedTransactions = transactions
+	c.m.Unlock()
+
+	c.forkedFrom.Store(int64(number))
+}
+
+func (c *testChain) forked(number int) bool {
+	forkedFrom := int(c.forkedFrom.Load())
+	return forkedFrom > 0 && number >= forkedFrom
+}
+
+// hash is the hash of block n, which differs on the forked branch
+func (c *testChain) hash(number int) string {
+	if c.forked(number) {
+		return fmt.Sprintf("0xf%d", number)
+	}
+
+	return blockHash(number)
+}
+
+// requestID reads the id of the JSON-RPC request sent in the body of r
+func requestID(t *testing.T, r *http.Request) int {
+	var req JsonRPCRequest
+	require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+	return req.ID
+}
+
+func (c *testChain) fetchedBlocks() []int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return append([]int(nil), c.fetched...)
+}
+
+func TestParserSubscribeConcurrent(t *testing.T) {
+	var calls atomic.Int32
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		return nodeNumberHex
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 20)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = parser.Subscribe(address)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(1), calls.Load())
+	require.Contains(t, parser.addresses, address)
+}
+
+func TestParserSubscribeCallerCancelled(t *testing.T) {
+	blocked, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		once.Do(func() {
+			close(blocked)
+			<-release
+		})
+		return nodeNumberHex
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	errs := make(chan error, 2)
+	firstCtx, cancel := context.WithCancel(context.Background())
+	first := newJoinedContext(firstCtx)
+	go func() { errs <- parser.SubscribeCtx(first, address) }()
+	<-blocked
+	<-first.joined
+	second := newJoinedContext(context.Background())
+	go func() { errs <- parser.SubscribeCtx(second, address) }()
+	<-second.joined
+
+	// the caller waiting for the subscription the first one started is
+	// still subscribed
+	cancel()
+	require.ErrorIs(t, <-errs, context.Canceled)
+	close(release)
+
+	require.NoError(t, <-errs)
+	require.Contains(t, parser.addresses, address)
+}
+
+func TestParserSubscribeCallersGiveUp(t *testing.T) {
+	blocked, release := make(chan struct{}), make(chan struct{})
+	var claimed atomic.Bool
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		if claimed.CompareAndSwap(false, true) {
+			close(blocked)
+			<-release
+		}
+		return nodeNumberHex
+	})
+	t.Cleanup(func() { close(release) })
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() { errs <- parser.SubscribeCtx(ctx, address) }()
+	<-blocked
+
+	// the subscription is canceled along with its only caller, the next
+	// one starting its own rather than waiting for it
+	cancel()
+	require.ErrorIs(t, <-errs, context.Canceled)
+
+	require.NoError(t, parser.Subscribe(address))
+	require.Contains(t, parser.Subscriptions(), address)
+}
+
+func TestParserContextCancelled(t *testing.T) {
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		return nodeNumberHex
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = parser.GetCurrentBlockCtx(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestParserTransactionFilter(t *testing.T) {
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		switch method {
+		case "eth_blockNumber":
+			return nodeNumberHex
+		case "eth_getBlockByNumber":
+			return models.BlockWithDetails{
+				Number: nodeNumberHex,
+				Transactions: []models.Transaction{
+					{Hash: "0x01", From: address, Value: "0xde0b6b3a7640000"},
+					{Hash: "0x02", From: address, Value: "0x1"},
+					{Hash: "0x03", From: other, Value: "0xde0b6b3a7640000"},
+				},
+			}
+		}
+		return nil
+	})
+
+	// at least one ether
+	oneEther := big.NewInt(1_000_000_000_000_000_000)
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithTransactionFilter(func(tx *models.Transaction) bool {
+		value, err := tx.ValueWei()
+		return err == nil && value.Cmp(oneEther) >= 0
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, parser.Subscribe(address))
+
+	// the filter applies on top of the address match
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, "0x01", txs[0].Hash)
+
+	_, err = NewEthParser(WithTransactionFilter(nil))
+	require.Error(t, err)
+}
+
+func TestParserSubscribeWithSelectors(t *testing.T) {
+	const approveSelector = "0x095ea7b3"
+
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		switch method {
+		case "eth_blockNumber":
+			return nodeNumberHex
+		case "eth_getBlockByNumber":
+			return models.BlockWithDetails{
+				Number: nodeNumberHex,
+				Transactions: []models.Transaction{
+					{Hash: "0x01", From: address, Input: approveSelector + "000000000000000000000000"},
+					{Hash: "0x02", From: address, Input: "0xa9059cbb000000000000000000000000"},
+					{Hash: "0x03", From: address, Input: "0x"},
+					{Hash: "0x04", From: "0xother", Input: approveSelector},
+				},
+			}
+		}
+		return nil
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	require.Error(t, parser.SubscribeWithSelectors(address, "approve"))
+	require.NoError(t, parser.SubscribeWithSelectors(address, "0x095EA7B3"))
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, "0x01", txs[0].Hash)
+}
+
+func TestParserGetTransactionsRepairsGap(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+		106: {{Hash: "0x106", To: address}},
+		109: {{Hash: "0x109", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	// blocks 105 to 107 were missed while the parser was offline
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.processed[address] = []BlockRange{{From: 100, To: 104}, {From: 108, To: 110}}
+	parser.transactionCache.AddTransactions(address, []*models.Transaction{
+		{Hash: "0x101", From: address, BlockNumber: hexNumber(101)},
+		{Hash: "0x109", From: address, BlockNumber: hexNumber(109)},
+	}, 110)
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	require.ElementsMatch(t, []string{"0x101", "0x106", "0x109"}, hashes)
+	require.Equal(t, []int{107, 106, 105}, chain.fetchedBlocks())
+
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Equal(t, []BlockRange{{From: 100, To: 110}}, ranges)
+
+	cached, _ := parser.transactionCache.GetTransactions(address)
+	require.Len(t, cached, 3)
+}
+
+func TestParserGetTransactionsEvictedFromCache(t *testing.T) {
+	_, node := newTestChain(t, 105, map[int][]models.Transaction{
+		102: {{Hash: "0x102", To: address}},
+		104: {{Hash: "0x104", To: hot}},
+	})
+
+	// the cache keeps a single address
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithCache(cache.NewMemCacheWithCapacity(1)))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.addresses[hot] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	txs, err = parser.GetTransactions(hot)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	// address was evicted, its blocks are scanned again
+	txs, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, "0x102", txs[0].Hash)
+}
+
+func TestParserGetTransactionsOverlappingCache(t *testing.T) {
+	chain, node := newTestChain(t, 108, map[int][]models.Transaction{
+		106: {{Hash: "0x106", To: address}},
+		107: {{Hash: "0x107", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	// a restored cache holds a transaction of a block recorded as not
+	// scanned yet
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.processed[address] = []BlockRange{{From: 100, To: 105}}
+	parser.transactionCache.AddTransactions(address, []*models.Transaction{
+		{Hash: "0x101", From: address, BlockNumber: hexNumber(101)},
+		{Hash: "0x106", To: address, BlockNumber: hexNumber(106)},
+	}, 106)
+
+	notifications, stop := parser.Notifications(address)
+	defer stop()
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []int{108, 107, 106}, chain.fetchedBlocks())
+
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	require.ElementsMatch(t, []string{"0x101", "0x106", "0x107"}, hashes)
+
+	// only the transaction not cached yet is new
+	require.Equal(t, "0x107", (<-notifications).Hash)
+	require.Empty(t, notifications)
+}
+
+func TestParserCurrentBlockBelowCached(t *testing.T) {
+	// the node serving the cached blocks was replaced by one on a shorter
+	// chain
+	chain, node := newTestChain(t, 105, nil)
+
+	opts := [][]EthParserOpt{nil, {WithBatchSize(2)}, {WithConcurrency(2)}}
+	for _, opt := range opts {
+		parser, err := NewEthParser(append(opt, WithNodeUrl(node.URL))...)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err = parser.getTransactionsFromBlockNumbers(ctx, 110, 105, parser.addressMatcher(address))
+		cancel()
+		require.ErrorContains(t, err, "invalid block range: 110 to 105")
+	}
+	require.Empty(t, chain.fetchedBlocks())
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.processed[address] = []BlockRange{{From: 100, To: 110}}
+	parser.transactionCache.AddTransactions(address, []*models.Transaction{
+		{Hash: "0x109", From: address, BlockNumber: hexNumber(109)},
+	}, 110)
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+}
+
+func TestParserGetTransactionsWithRange(t *testing.T) {
+	chain, node := newTestChain(t, 105, map[int][]models.Transaction{
+		102: {{Hash: "0x102", To: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	txs, fromBlock, toBlock, err := parser.GetTransactionsWithRange(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, 100, fromBlock)
+	require.Equal(t, 105, toBlock)
+
+	// cached blocks are part of the range
+	chain.head.Store(107)
+	txs, fromBlock, toBlock, err = parser.GetTransactionsWithRange(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, 100, fromBlock)
+	require.Equal(t, 107, toBlock)
+
+	_, _, _, err = parser.GetTransactionsWithRange(other)
+	require.Error(t, err)
+}
+
+func TestParserGetTransactionsReturnsCopies(t *testing.T) {
+	_, node := newTestChain(t, 105, map[int][]models.Transaction{
+		102: {{Hash: "0x102", To: address, Value: "0x1"}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	// the first call returns the transactions it just fetched, the
+	// following ones the cached transactions
+	for i := 0; i < 2; i++ {
+		txs, err := parser.GetTransactions(address)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		txs[0].Value = "0x2"
+	}
+
+	cached, _ := parser.transactionCache.GetTransactions(address)
+	require.Len(t, cached, 1)
+	require.Equal(t, "0x1", cached[0].Value)
+
+	result, err := parser.GetTransactionsWithBudget(context.Background(), address, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "0x1", result.Transactions[0].Value)
+}
+
+func TestParserGetTransactionsInBatches(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		100: {{Hash: "0x100", To: address}},
+		105: {{Hash: "0x105", From: address}},
+		110: {{Hash: "0x110", From: address}, {Hash: "0x111", From: "0xother"}},
+	})
+
+	transport := &countingTransport{}
+	parser, err := NewEthParser(
+		WithNodeUrl(node.URL),
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithBatchSize(4),
+	)
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	require.ElementsMatch(t, []string{"0x100", "0x105", "0x110"}, hashes)
+	require.ElementsMatch(t, []int{100, 101, 102, 103, 104, 105, 106, 107, 108, 109, 110}, chain.fetchedBlocks())
+
+	// one eth_blockNumber and three batches of at most 4 blocks
+	require.Equal(t, int32(4), transport.requests.Load())
+}
+
+func TestWithBatchSizeInvalid(t *testing.T) {
+	_, err := NewEthParser(WithBatchSize(0))
+	require.Error(t, err)
+}
+
+func TestParserGetTransactionsConcurrently(t *testing.T) {
+	for _, batchSize := range []int{0, 2} {
+		chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+			100: {{Hash: "0x100", To: address}},
+			103: {{Hash: "0x103a", From: address}, {Hash: "0x103b", To: address}},
+			107: {{Hash: "0x107", From: address}},
+			110: {{Hash: "0x110", From: address}},
+		})
+
+		opts := []EthParserOpt{WithNodeUrl(node.URL), WithConcurrency(3)}
+		if batchSize > 0 {
+			opts = append(opts, WithBatchSize(batchSize))
+		}
+
+		parser, err := NewEthParser(opts...)
+		require.NoError(t, err)
+		parser.addresses[address] = subscription{blockNumber: 100}
+
+		txs, err := parser.GetTransactions(address)
+		require.NoError(t, err)
+
+		hashes := make([]string, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash)
+		}
+		require.Equal(t, []string{"0x100", "0x103a", "0x103b", "0x107", "0x110"}, hashes)
+		require.ElementsMatch(t, []int{100, 101, 102, 103, 104, 105, 106, 107, 108, 109, 110}, chain.fetchedBlocks())
+	}
+}
+
+func TestParserGetTransactionsBlockTimestamp(t *testing.T) {
+	_, node := newTestChain(t, 105, map[int][]models.Transaction{
+		102: {{Hash: "0x102", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, hexNumber(testChainGenesisTime+12*102), txs[0].BlockTimestamp)
+	require.Equal(t, time.Unix(testChainGenesisTime+12*102, 0).UTC(), txs[0].BlockTime())
+}
+
+func TestParserGetTransactionsIntraBlockOrder(t *testing.T) {
+	for _, concurrency := range []int{1, 3} {
+		_, node := newTestChain(t, 105, map[int][]models.Transaction{
+			101: {{Hash: "0xc", From: address, TransactionIndex: "0x2"}, {Hash: "0xf", To: address, TransactionIndex: "0x0"}},
+			104: {
+				{Hash: "0xa", From: address, TransactionIndex: "0xa"},
+				{Hash: "0xb", To: address, TransactionIndex: "0x9"},
+				{Hash: "0xd", From: address, TransactionIndex: "0x1"},
+			},
+		})
+
+		parser, err := NewEthParser(WithNodeUrl(node.URL), WithConcurrency(concurrency))
+		require.NoError(t, err)
+		parser.addresses[address] = subscription{blockNumber: 100}
+
+		txs, err := parser.GetTransactions(address)
+		require.NoError(t, err)
+
+		hashes := make([]string, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash)
+		}
+		// within a block, transactions follow their transactionIndex rather
+		// than the order the node listed them in or their hash
+		require.Equal(t, []string{"0xf", "0xc", "0xd", "0xb", "0xa"}, hashes)
+	}
+}
+
+func TestParserGetTransactionsConcurrentlyError(t *testing.T) {
+	chain, node := newTestChain(t, 110, nil)
+	chain.missing = map[int]bool{104: true}
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConcurrency(3))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	_, err = parser.GetTransactions(address)
+	require.ErrorContains(t, err, "block not found: 104")
+}
+
+func TestParserGetTransactionsNullHead(t *testing.T) {
+	for name, opts := range map[string][]EthParserOpt{
+		"sequential":   nil,
+		"concurrently": {WithConcurrency(3)},
+		"in batches":   {WithBatchSize(4)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			chain, node := newTestChain(t, 110, nil)
+			// the node answers a head it doesn't serve yet
+			chain.missing = map[int]bool{110: true}
+
+			parser, err := NewEthParser(append(opts, WithNodeUrl(node.URL), WithConfirmations(0))...)
+			require.NoError(t, err)
+			parser.addresses[address] = subscription{blockNumber: 100}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err = parser.GetTransactionsCtx(ctx, address)
+			require.ErrorIs(t, err, ErrBlockNotYetAvailable)
+			require.NotErrorIs(t, err, ErrBlockNotFound)
+			require.NoError(t, ctx.Err())
+
+			// the scan succeeds once the node serves the head
+			chain.missing = nil
+			_, err = parser.GetTransactionsCtx(ctx, address)
+			require.NoError(t, err)
+		})
+	}
+}
+
+// gatedTransport holds the first block request sent to the node until
+// release is closed
+type gatedTransport struct {
+	once    sync.Once
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (gt *gatedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	if bytes.Contains(body, []byte("eth_getBlockBy")) {
+		gt.once.Do(func() {
+			close(gt.blocked)
+			<-gt.release
+		})
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// joinedContext closes joined the first time Done is called, which the
+// coalesced calls do once they joined the call in progress
+type joinedContext struct {
+	context.Context
+	once   sync.Once
+	joined chan struct{}
+}
+
+func newJoinedContext(ctx context.Context) *joinedContext {
+	return &joinedContext{Context: ctx, joined: make(chan struct{})}
+}
+
+func (c *joinedContext) Done() <-chan struct{} {
+	c.once.Do(func() { close(c.joined) })
+	return c.Context.Done()
+}
+
+func TestParserIsSynced(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		105: {{Hash: "0x105", To: address}},
+	})
+
+	transport := &gatedTransport{blocked: make(chan struct{}), release: make(chan struct{})}
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0), WithConfirmations(3), WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	done := make(chan error)
+	go func() {
+		_, err := parser.GetTransactions(address)
+		done <- err
+	}()
+
+	<-transport.blocked
+	synced, err := parser.IsSynced(address)
+	require.NoError(t, err)
+	require.False(t, synced)
+
+	close(transport.release)
+	require.NoError(t, <-done)
+
+	// the blocks within the confirmations, 108 to 110, do not need to be
+	// scanned yet
+	synced, err = parser.IsSynced(address)
+	require.NoError(t, err)
+	require.True(t, synced)
+
+	chain.head.Store(111)
+	synced, err = parser.IsSynced(address)
+	require.NoError(t, err)
+	require.False(t, synced)
+
+	_, err = parser.IsSynced("0x01")
+	require.Error(t, err)
+}
+
+func TestParserSyncStatus(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		105: {{Hash: "0x105", To: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0), WithConfirmations(0))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	// nothing is cached before the first walk
+	processed, head, behind, err := parser.SyncStatus(address)
+	require.NoError(t, err)
+	require.Equal(t, 99, processed)
+	require.Equal(t, 110, head)
+	require.Equal(t, 11, behind)
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	processed, head, behind, err = parser.SyncStatus(address)
+	require.NoError(t, err)
+	require.Equal(t, 110, processed)
+	require.Equal(t, 110, head)
+	require.Zero(t, behind)
+
+	chain.head.Store(150)
+	processed, head, behind, err = parser.SyncStatus(address)
+	require.NoError(t, err)
+	require.Equal(t, 110, processed)
+	require.Equal(t, 150, head)
+	require.Equal(t, 40, behind)
+
+	_, _, _, err = parser.SyncStatus(other)
+	require.ErrorIs(t, err, ErrNotSubscribed)
+}
+
+func TestIntToHex(t *testing.T) {
+	tests := []struct {
+		i       int
+		want    string
+		wantErr bool
+	}{
+		{i: 0, want: "0x0"},
+		{i: 1, want: "0x1"},
+		{i: 255, want: "0xff"},
+		{i: 20_000_000, want: "0x1312d00"},
+		{i: math.MaxInt64, want: "0x7fffffffffffffff"},
+		{i: -1, wantErr: true},
+		{i: -10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := intToHex(tt.i)
+		if tt.wantErr {
+			require.Error(t, err, tt.i)
+			continue
+		}
+		require.NoError(t, err, tt.i)
+		require.Equal(t, tt.want, got, tt.i)
+	}
+}
+
+func TestParserGetBlockFromNegativeNumber(t *testing.T) {
+	transport := &countingTransport{}
+	parser, err := NewEthParser(WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+
+	_, err = parser.getBlockFromNumber(context.Background(), -1)
+	require.ErrorContains(t, err, "invalid quantity: -1")
+	require.Zero(t, transport.requests.Load())
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	normalized, err := normalizeAddress("0xCB81Fa1fc2a94461f49d9106dcb7772a29288efe")
+	require.NoError(t, err)
+	require.Equal(t, address, normalized)
+
+	for _, invalid := range []string{"", "0x", "cb81fa1fc2a94461f49d9106dcb7772a29288efe", "0xcb81fa1fc2a94461f49d9106dcb7772a29288ef", "0xzz81fa1fc2a94461f49d9106dcb7772a29288efe"} {
+		_, err := normalizeAddress(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestParserChecksummedAddress(t *testing.T) {
+	const checksummed = "0xCB81Fa1fc2a94461f49d9106dcb7772a29288efe"
+
+	_, node := newTestChain(t, 101, map[int][]models.Transaction{
+		101: {
+			{Hash: "0x01", From: checksummed},
+			{Hash: "0x02", To: address},
+		},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	require.NoError(t, parser.Subscribe(checksummed))
+	require.Error(t, parser.Subscribe(address))
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(checksummed)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+
+	require.True(t, parser.Unsubscribe(address))
+}
+
+func TestParserSubscribeInvalidAddress(t *testing.T) {
+	transport := &countingTransport{}
+	parser, err := NewEthParser(WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+
+	for _, invalid := range []string{"", "0x01", "cb81fa1fc2a94461f49d9106dcb7772a29288efe"} {
+		require.Error(t, parser.Subscribe(invalid))
+	}
+	require.Zero(t, transport.requests.Load())
+	require.Empty(t, parser.SubscribedAddresses())
+}
+
+func TestParserSubscriptions(t *testing.T) {
 	parser, err := NewEthParser()
 	require.NoError(t, err)
 
-	res := parser.Subscribe(address)
-	require.True(t, res)
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.addresses[other] = subscription{blockNumber: 105}
+	require.Equal(t, []string{other, address}, parser.SubscribedAddresses())
 
-	blockNumber, err := strconv.ParseInt(nodeNumberHex, 0, 0)
+	subscriptions := parser.Subscriptions()
+	require.Equal(t, map[string]int{address: 100, other: 105}, subscriptions)
+
+	// the snapshots are copies
+	subscriptions[hot] = 110
+	parser.SubscribedAddresses()[0] = hot
+	require.Equal(t, []string{other, address}, parser.SubscribedAddresses())
+	require.Len(t, parser.Subscriptions(), 2)
+}
+
+func TestNewEthParserNodeUrl(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+	require.Equal(t, defaultNodeUrl, parser.nodes.endpoints[0].url)
+
+	parser, err = NewEthParser(WithNodeUrl("http://localhost:8545"))
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8545", parser.nodes.endpoints[0].url)
+
+	_, err = NewEthParser(WithNodeUrl(""))
+	require.Error(t, err)
+}
+
+func TestParserAuthHeaders(t *testing.T) {
+	const token = "s3cr3t"
+
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		authorized := r.Header.Get("Authorization") == "Bearer "+token ||
+			r.Header.Get("X-Api-Key") == token ||
+			username == "user" && password == token
+		if !authorized {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": requestID(t, r), "jsonrpc": "2.0", "result": nodeNumberHex})
+	}))
+	t.Cleanup(node.Close)
+
+	logger, records := newTestLogger(t)
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRetry(1, time.Millisecond), WithLogger(logger))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.ErrorContains(t, err, "unexpected status code: 401")
+
+	for _, opt := range []EthParserOpt{WithBearerToken(token), WithAuthHeader("X-Api-Key", token), WithBasicAuth("user", token)} {
+		parser, err := NewEthParser(WithNodeUrl(node.URL), WithRetry(1, time.Millisecond), WithLogger(logger), opt)
+		require.NoError(t, err)
+
+		_, err = parser.GetCurrentBlock()
+		require.NoError(t, err)
+	}
+
+	for _, record := range records() {
+		require.NotContains(t, fmt.Sprint(record), token)
+	}
+
+	// an empty header key is rejected by WithHeader, tested along with it
+	for _, opt := range []EthParserOpt{WithBearerToken(""), WithBasicAuth("", token)} {
+		_, err := NewEthParser(opt)
+		require.Error(t, err)
+	}
+}
+
+func TestParserRequestHeaders(t *testing.T) {
+	var headers http.Header
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": requestID(t, r), "jsonrpc": "2.0", "result": nodeNumberHex})
+	}))
+	t.Cleanup(node.Close)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(headers.Get("User-Agent"), "ethparser/"))
+
+	parser, err = NewEthParser(WithNodeUrl(node.URL), WithUserAgent("indexer/2.0"), WithHeader("X-Client", "indexer"))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, "indexer/2.0", headers.Get("User-Agent"))
+	require.Equal(t, "indexer", headers.Get("X-Client"))
+	require.Equal(t, "application/json", headers.Get("Content-Type"))
+
+	for _, opt := range []EthParserOpt{WithUserAgent(""), WithHeader("", "indexer")} {
+		_, err := NewEthParser(opt)
+		require.Error(t, err)
+	}
+}
+
+func TestNewEthParserCache(t *testing.T) {
+	c := cache.NewMemCacheWithCapacity(1)
+	parser, err := NewEthParser(WithCache(c))
+	require.NoError(t, err)
+	require.Same(t, c, parser.transactionCache)
+
+	_, err = NewEthParser(WithCache(nil))
+	require.Error(t, err)
+}
+
+func TestParserNotSubscribed(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	_, err = parser.GetTransactions(address)
+	require.ErrorIs(t, err, ErrNotSubscribed)
+
+	_, err = parser.ProcessedRanges(address)
+	require.ErrorIs(t, err, ErrNotSubscribed)
+
+	require.ErrorIs(t, parser.ResetAddress(address), ErrNotSubscribed)
+
+	// a malformed address is not a missing subscription
+	_, err = parser.GetTransactions("0xzz")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrNotSubscribed)
+}
+
+func TestParserResetAddress(t *testing.T) {
+	chain, node := newTestChain(t, 105, map[int][]models.Transaction{
+		102: {{Hash: "0x102", To: address}},
+	})
+
+	// without a block cache, the walk after the reset goes to the node
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockCacheSize(0))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, chain.fetchedBlocks(), 6)
+
+	require.NoError(t, parser.ResetAddress(address))
+
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Empty(t, ranges)
+
+	cached, _ := parser.transactionCache.GetTransactions(address)
+	require.Empty(t, cached)
+	require.Equal(t, []string{address}, parser.SubscribedAddresses())
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	// the head is fetched once more to check for reorgs before the walk
+	require.Equal(t, []int{105, 104, 103, 102, 101, 100, 105, 105, 104, 103, 102, 101, 100}, chain.fetchedBlocks())
+
+	require.Error(t, parser.ResetAddress(other))
+}
+
+func TestParserJsonRPCError(t *testing.T) {
+	rpcError := map[string]interface{}{"code": -32005, "message": "limit exceeded"}
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		if body[0] == '[' {
+			var reqs []JsonRPCRequest
+			require.NoError(t, json.Unmarshal(body, &reqs))
+			json.NewEncoder(w).Encode([]interface{}{
+				map[string]interface{}{"id": reqs[0].ID, "jsonrpc": "2.0", "result": nil},
+				map[string]interface{}{"id": reqs[1].ID, "jsonrpc": "2.0", "error": rpcError},
+			})
+			return
+		}
+
+		var req JsonRPCRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "jsonrpc": "2.0", "error": rpcError})
+	}))
+	t.Cleanup(node.Close)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.EqualError(t, err, "json-rpc error -32005: limit exceeded")
+
+	var rpcErr *JsonRPCError
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, -32005, rpcErr.Code)
+
+	_, err = batchDo[JsonRPCResponseBlock](context.Background(), parser, []JsonRPCRequest{
+		{Jsonrpc: "2.0", Method: "eth_getBlockByNumber", Params: []interface{}{"0x1", true}},
+		{Jsonrpc: "2.0", Method: "eth_getBlockByNumber", Params: []interface{}{"0x2", true}},
+	})
+	require.ErrorAs(t, err, &rpcErr)
+}
+
+func TestParserResponseIDMismatch(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 7, "jsonrpc": "2.0", "result": nodeNumberHex})
+	}))
+	t.Cleanup(node.Close)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.EqualError(t, err, "response id 7 does not match request id 1")
+}
+
+func TestParserRequestIDs(t *testing.T) {
+	var ids []int
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestID(t, r)
+		ids = append(ids, id)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "jsonrpc": "2.0", "result": nodeNumberHex})
+	}))
+	t.Cleanup(node.Close)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+
+	require.Len(t, ids, 2)
+	require.NotEqual(t, ids[0], ids[1])
+}
+
+func TestParserGetBlock(t *testing.T) {
+	chain, node := newTestChain(t, 101, map[int][]models.Transaction{
+		101: {{Hash: "0x101", To: address}},
+	})
+	chain.missing = map[int]bool{102: true}
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	block, err := parser.GetBlock(101)
+	require.NoError(t, err)
+	require.Equal(t, blockHash(101), block.Hash)
+	require.Len(t, block.Transactions, 1)
+
+	_, err = parser.GetBlock(102)
+	require.ErrorIs(t, err, ErrBlockNotFound)
+
+	_, err = parser.GetBlock(-1)
+	require.Error(t, err)
+}
+
+func TestParserGetTransactionByHash(t *testing.T) {
+	const hash = "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		require.Equal(t, "eth_getTransactionByHash", method)
+		if params[0] != hash {
+			return nil
+		}
+		return models.Transaction{Hash: hash, From: address, To: hot, Value: "0x1", BlockNumber: "0x65"}
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	tx, err := parser.GetTransactionByHash(hash)
+	require.NoError(t, err)
+	require.Equal(t, &models.Transaction{Hash: hash, From: address, To: hot, Value: "0x1", BlockNumber: "0x65"}, tx)
+
+	// the node answers null for transactions it doesn't have
+	_, err = parser.GetTransactionByHash("0x" + strings.Repeat("0", 64))
+	require.ErrorIs(t, err, ErrTransactionNotFound)
+
+	_, err = parser.GetTransactionByHash("0x01")
+	require.ErrorContains(t, err, "invalid transaction hash")
+}
+
+func TestParserResetCache(t *testing.T) {
+	_, node := newTestChain(t, 105, map[int][]models.Transaction{
+		102: {{Hash: "0x102", To: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
 	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.addresses[other] = subscription{blockNumber: 100}
 
-	parser.addresses[address] = int(blockNumber)
+	for _, a := range []string{address, other} {
+		_, err = parser.GetTransactions(a)
+		require.NoError(t, err)
+	}
 
-	txs := parser.GetTransactions(address)
-	require.NotNil(t, txs)
+	require.NoError(t, parser.ResetCache(""))
 
-	txs = parser.GetTransactions(address)
-	require.NotNil(t, txs)
+	for _, a := range []string{address, other} {
+		ranges, err := parser.ProcessedRanges(a)
+		require.NoError(t, err)
+		require.Empty(t, ranges)
+	}
+	cached, _ := parser.transactionCache.GetTransactions(address)
+	require.Empty(t, cached)
+	_, ok := parser.headers.tip()
+	require.False(t, ok)
+	_, ok = parser.blocks.get(102, "")
+	require.False(t, ok)
+	require.ElementsMatch(t, []string{address, other}, parser.SubscribedAddresses())
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+}
+
+func TestParserGetTransactionsCoalesced(t *testing.T) {
+	chain, node := newTestChain(t, 105, map[int][]models.Transaction{
+		102: {{Hash: "0x102", To: address}},
+	})
+
+	transport := &gatedTransport{blocked: make(chan struct{}), release: make(chan struct{})}
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	type result struct {
+		txs []*models.Transaction
+		err error
+	}
+
+	const callers = 5
+	results := make(chan result, callers)
+	get := func(ctx context.Context) {
+		txs, err := parser.GetTransactionsCtx(ctx, address)
+		results <- result{txs: txs, err: err}
+	}
+
+	// the first caller gives up while the others wait for the walk it
+	// started
+	firstCtx, cancel := context.WithCancel(context.Background())
+	first := newJoinedContext(firstCtx)
+	go get(first)
+	<-transport.blocked
+	<-first.joined
+	for i := 1; i < callers; i++ {
+		ctx := newJoinedContext(context.Background())
+		go get(ctx)
+		<-ctx.joined
+	}
+
+	cancel()
+	r := <-results
+	require.ErrorIs(t, r.err, context.Canceled)
+	close(transport.release)
+
+	var previous []*models.Transaction
+	for i := 1; i < callers; i++ {
+		r := <-results
+		require.NoError(t, r.err)
+		require.Len(t, r.txs, 1)
+		require.Equal(t, "0x102", r.txs[0].Hash)
+
+		// every caller gets its own copy
+		if previous != nil {
+			require.NotSame(t, previous[0], r.txs[0])
+		}
+		previous = r.txs
+	}
+
+	// the head is fetched by number, its 5 ancestors by hash
+	require.Len(t, chain.fetchedBlocks(), 6)
+}
+
+// stallingTransport holds the block requests until they are canceled
+type stallingTransport struct {
+	blockedOnce  sync.Once
+	blocked      chan struct{}
+	canceledOnce sync.Once
+	canceled     chan struct{}
+}
+
+func (st *stallingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	if !bytes.Contains(body, []byte("eth_getBlockBy")) {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+
+	st.blockedOnce.Do(func() { close(st.blocked) })
+	<-req.Context().Done()
+	st.canceledOnce.Do(func() { close(st.canceled) })
+
+	return nil, req.Context().Err()
+}
+
+func TestParserGetTransactionsCoalescedCanceled(t *testing.T) {
+	_, node := newTestChain(t, 105, nil)
+
+	transport := &stallingTransport{blocked: make(chan struct{}), canceled: make(chan struct{})}
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := parser.GetTransactionsCtx(ctx, address)
+		done <- err
+	}()
+
+	<-transport.blocked
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	// the last caller giving up cancels the walk
+	select {
+	case <-transport.canceled:
+	case <-time.After(time.Second):
+		t.Fatal("walk still running after its last caller gave up")
+	}
+
+	parser.transactionsGroup.m.Lock()
+	require.Empty(t, parser.transactionsGroup.calls)
+	parser.transactionsGroup.m.Unlock()
+}
+
+func TestParserSubscribeDuringGetTransactions(t *testing.T) {
+	_, node := newTestChain(t, 110, map[int][]models.Transaction{
+		105: {{Hash: "0x105", To: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+	require.NoError(t, parser.Subscribe(address))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := parser.GetTransactions(address)
+				require.NoError(t, err)
+			}()
+			go func() {
+				defer wg.Done()
+				require.NoError(t, parser.Subscribe(fmt.Sprintf("0x%040x", i+1)))
+			}()
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("GetTransactions and Subscribe deadlocked")
+	}
+	require.Len(t, parser.SubscribedAddresses(), 21)
+}
+
+func TestGetTransactionsFromBlockDistinctTransactions(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	block := &models.BlockWithDetails{
+		Hash:   blockHash(101),
+		Number: "0x65",
+		Transactions: []models.Transaction{
+			{Hash: "0x1", To: address},
+			{Hash: "0x2", From: other, To: other},
+			{Hash: "0x3", From: address},
+			{Hash: "0x4", To: address},
+		},
+	}
+
+	txs, err := parser.getTransactionsFromBlock(block, parser.addressMatcher(address))
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+	require.Equal(t, "0x1", txs[0].Hash)
+	require.Equal(t, "0x3", txs[1].Hash)
+	require.Equal(t, "0x4", txs[2].Hash)
+}
+
+func TestGetTransactionsFromBlockContractCreation(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	var block models.BlockWithDetails
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"hash": "0xb65",
+		"number": "0x65",
+		"transactions": [
+			{"hash": "0x1", "from": "`+address+`", "to": null},
+			{"hash": "0x2", "from": "`+other+`", "to": null},
+			{"hash": "0x3", "from": "`+other+`", "to": "`+address+`"}
+		]
+	}`), &block))
+
+	txs, err := parser.getTransactionsFromBlock(&block, parser.addressMatcher(address))
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	require.Equal(t, "0x1", txs[0].Hash)
+	require.True(t, txs[0].IsContractCreation())
+	require.Equal(t, "0x3", txs[1].Hash)
+	require.False(t, txs[1].IsContractCreation())
+
+	// the empty To of a contract creation is not an address
+	txs, err = parser.getTransactionsFromBlock(&block, parser.addressMatcher(""))
+	require.NoError(t, err)
+	require.Empty(t, txs)
 }
@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// partialWindowSize is the number of blocks walked at a time with partial
+// results or a yield, the blocks of a failed window being fetched again on
+// the next call
+const partialWindowSize = 10
+
+// PartialResultError is returned along with the transactions found before a
+// block failed to be fetched, with WithPartialResults
+type PartialResultError struct {
+	// ReachedBlock is the last block scanned without a gap from the block
+	// the address was subscribed at, the next call resuming after it
+	ReachedBlock int
+	Err          error
+}
+
+func (e *PartialResultError) Error() string {
+	return fmt.Sprintf("scan stopped after block %d: %v", e.ReachedBlock, e.Err)
+}
+
+func (e *PartialResultError) Unwrap() error {
+	return e.Err
+}
+
+// WithPartialResults keeps the transactions found before a block fails to be
+// fetched, rather than discarding the whole walk. GetTransactions caches and
+// returns them along with a *PartialResultError.
+func WithPartialResults() EthParserOpt {
+	return func(e *ethParser) error {
+		e.partialResults = true
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"errors"
+	"math"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+// newFailingChain serves a test chain whose block failing is answered with
+// an error while fail is set
+func newFailingChain(t *testing.T, head, failing int, transactions map[int][]models.Transaction) (*testChain, *atomic.Bool, *httptest.Server) {
+	t.Helper()
+
+	chain, _ := newTestChain(t, head, transactions)
+
+	var fail atomic.Bool
+	fail.Store(true)
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		switch method {
+		case "eth_blockNumber":
+			return hexNumber(head)
+		case "eth_getBlockByNumber", "eth_getBlockByHash":
+			var number int64
+			if method == "eth_getBlockByNumber" {
+				number, _ = strconv.ParseInt(params[0].(string), 0, 0)
+			} else {
+				number, _ = strconv.ParseInt(params[0].(string)[3:], 10, 0)
+			}
+
+			if fail.Load() && int(number) == failing {
+				return &JsonRPCError{Code: -32000, Message: "internal error"}
+			}
+			return chain.block(int(number))
+		}
+		return nil
+	})
+
+	return chain, &fail, node
+}
+
+func TestParserPartialResults(t *testing.T) {
+	chain, fail, node := newFailingChain(t, 150, 125, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+		115: {{Hash: "0x115", To: address}},
+		140: {{Hash: "0x140", From: address}},
+	})
+
+	parser, err := NewEthParser(
+		WithNodeUrl(node.URL),
+		WithConfirmations(0),
+		WithRetry(1, time.Millisecond),
+		WithRateLimit(math.Inf(1), 1),
+		WithPartialResults(),
+	)
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(address)
+	var partialErr *PartialResultError
+	require.True(t, errors.As(err, &partialErr))
+	var rpcErr *JsonRPCError
+	require.ErrorAs(t, err, &rpcErr)
+	// the window of block 125 failed, the ones below it were kept
+	require.Equal(t, 120, partialErr.ReachedBlock)
+	require.Len(t, txs, 2)
+
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Equal(t, []BlockRange{{From: 100, To: 120}}, ranges)
+
+	_, cachedBlockNumber := parser.transactionCache.GetTransactions(address)
+	require.Equal(t, 120, cachedBlockNumber)
+
+	// the next call resumes after the blocks kept
+	fail.Store(false)
+	fetched := len(chain.fetchedBlocks())
+
+	txs, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+	for _, blockNumber := range chain.fetchedBlocks()[fetched:] {
+		require.Greater(t, blockNumber, 120)
+	}
+}
+
+func TestParserWithoutPartialResults(t *testing.T) {
+	_, _, node := newFailingChain(t, 150, 125, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0), WithRetry(1, time.Millisecond), WithRateLimit(math.Inf(1), 1))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(address)
+	require.ErrorContains(t, err, "internal error")
+	require.Nil(t, txs)
+
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Empty(t, ranges)
+}
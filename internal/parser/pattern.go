@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"ethparser/internal/models"
+)
+
+// minPatternDigits is the number of hex digits under which a prefix is
+// expected to match a large share of mainnet transactions
+const minPatternDigits = 4
+
+// patternRegexp matches a 0x-prefixed address prefix, a full address
+// included
+var patternRegexp = regexp.MustCompile(`^0x[0-9a-fA-F]{0,40}$`)
+
+// SubscribePattern adds every address starting with prefix to the observer,
+// their transactions being listed together under prefix. "0x" matches every
+// transaction, which only suits private chains.
+func (e *ethParser) SubscribePattern(prefix string) error {
+	if prefix == "" {
+		prefix = "0x"
+	}
+
+	prefix, err := normalizeSubscription(prefix)
+	if err != nil {
+		return err
+	}
+
+	if digits := len(prefix) - len("0x"); digits < minPatternDigits {
+		e.logger.Warn("broad address pattern, expect too many transactions on mainnet", "prefix", prefix, "matchesOneIn", 1<<(4*digits))
+	}
+
+	return e.subscribeCoalesced(context.Background(), prefix, nil, 0)
+}
+
+// isPattern reports whether a subscription is an address prefix rather than
+// an address
+func isPattern(key string) bool {
+	return !models.IsValidAddress(key) && strings.HasPrefix(key, "0x")
+}
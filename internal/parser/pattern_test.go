@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserSubscribePattern(t *testing.T) {
+	const (
+		vanity1 = "0x0000000000a84d1a9b0063a910315c7ffa9cd248"
+		prefix  = "0x00000000"
+	)
+
+	_, node := newTestChain(t, 101, map[int][]models.Transaction{
+		101: {
+			{Hash: "0x01", From: address, To: vanity1},
+			{Hash: "0x02", From: "0x00000000219AB540356cBB839Cbe05303d7705Fa", To: address},
+			{Hash: "0x03", From: address, To: other},
+		},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	require.NoError(t, parser.SubscribePattern(prefix))
+	parser.addresses[prefix] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(prefix)
+	require.NoError(t, err)
+
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	require.ElementsMatch(t, []string{"0x01", "0x02"}, hashes)
+
+	require.True(t, parser.Unsubscribe(prefix))
+}
+
+func TestParserSubscribePatternInvalid(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	require.Error(t, parser.SubscribePattern("00000000"))
+	require.Error(t, parser.SubscribePattern("0xzz"))
+	require.Error(t, parser.SubscribePattern(address+"00"))
+}
+
+func TestIsPattern(t *testing.T) {
+	require.True(t, isPattern("0x"))
+	require.True(t, isPattern("0x0000"))
+	require.False(t, isPattern(address))
+}
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultPollInterval is about the time between two mainnet blocks
+	defaultPollInterval = 12 * time.Second
+)
+
+// WithPollInterval sets how often the poller refreshes subscribed addresses
+// that don't have their own poll interval
+func WithPollInterval(interval time.Duration) EthParserOpt {
+	return func(p *ethParser) error {
+		if interval <= 0 {
+			return errors.New("poll interval must be positive")
+		}
+		p.pollInterval = interval
+		return nil
+	}
+}
+
+// Start polls the subscribed addresses in the background until ctx is done
+// or the parser is closed, keeping their cached transactions up to date and
+// delivering the new ones to their webhooks, and follows new heads when a
+// WebSocket URL is set
+func (e *ethParser) Start(ctx context.Context) {
+	if e.wsURL != "" {
+		e.runBackground(ctx, e.followHeads)
+	}
+	e.runBackground(ctx, e.poll)
+	e.runBackground(ctx, e.deliverWebhooks)
+}
+
+// poll refreshes every address whose poll interval elapsed, then sleeps
+// until the next one is due
+func (e *ethParser) poll(ctx context.Context) {
+	nextPolls := make(map[string]time.Time)
+	lastBlock := 0
+
+	for {
+		schedule := e.pollSchedule()
+		for address := range nextPolls {
+			if _, ok := schedule[address]; !ok {
+				delete(nextPolls, address)
+			}
+		}
+
+		// fetch the new blocks once for every address, leaving the
+		// addresses due only the blocks they missed to fetch
+		for address := range schedule {
+			if nextPoll, ok := nextPolls[address]; !ok || !e.clock.Now().Before(nextPoll) {
+				lastBlock = e.pollBlocks(ctx, lastBlock)
+				break
+			}
+		}
+
+		wait := e.pollInterval
+		for address, interval := range schedule {
+			nextPoll, ok := nextPolls[address]
+			if !ok || !e.clock.Now().Before(nextPoll) {
+				e.pollAddress(ctx, address)
+				nextPoll = e.clock.Now().Add(interval)
+				nextPolls[address] = nextPoll
+			}
+
+			wait = min(wait, nextPoll.Sub(e.clock.Now()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.clock.After(wait):
+		}
+	}
+}
+
+// pollSchedule snapshots the poll interval of every subscribed address
+func (e *ethParser) pollSchedule() map[string]time.Duration {
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	schedule := make(map[string]time.Duration, len(e.addresses))
+	for address, sub := range e.addresses {
+		interval := sub.interval
+		if interval == 0 {
+			interval = e.pollInterval
+		}
+		schedule[address] = interval
+	}
+
+	return schedule
+}
+
+// refreshBlocks processes the blocks mined after lastBlock, returning the
+// last block processed
+func (e *ethParser) refreshBlocks(ctx context.Context, lastBlock int) int {
+	lastBlock, err := e.processNewBlocks(ctx, lastBlock)
+	if errors.Is(err, ErrBlockNotYetAvailable) {
+		// the block is processed on the next poll
+		e.logger.Debug("new block not yet available", "error", err)
+		return lastBlock
+	}
+	if err != nil && ctx.Err() == nil {
+		// the addresses fetch the blocks left behind on their own
+		e.logger.Warn("failed to poll new blocks", "error", err)
+	}
+
+	return lastBlock
+}
+
+// refreshAddress fetches the transactions of address up to the current block
+func (e *ethParser) refreshAddress(ctx context.Context, address string) {
+	_, err := e.getAllTransactions(ctx, address)
+	if errors.Is(err, ErrBlockNotYetAvailable) {
+		// the address is fetched again on its next poll
+		e.logger.Debug("block not yet available", "address", address, "error", err)
+		return
+	}
+	if err != nil && ctx.Err() == nil {
+		e.logger.Error("failed to poll address", "address", address, "error", err)
+		e.reportError(fmt.Errorf("failed to poll address %s: %w", address, err))
+	}
+}
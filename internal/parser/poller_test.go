@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	hot   = "0x00000000219ab540356cbb839cbe05303d7705fa"
+	cold  = "0xbe0eb53f46cd790cd13851d5eff43d12404d33e8"
+	other = "0x28c6c06298d514db089934071355e5743bf21d60"
+)
+
+func TestParserPollIntervals(t *testing.T) {
+	parser, err := NewEthParser(WithPollInterval(time.Hour))
+	require.NoError(t, err)
+
+	var m sync.Mutex
+	polls := make(map[string]int)
+	parser.pollAddress = func(ctx context.Context, address string) {
+		m.Lock()
+		defer m.Unlock()
+		polls[address]++
+	}
+	parser.pollBlocks = func(ctx context.Context, lastBlock int) int {
+		return lastBlock
+	}
+
+	parser.addresses[hot] = subscription{blockNumber: 1, interval: 10 * time.Millisecond}
+	parser.addresses[cold] = subscription{blockNumber: 1, interval: 200 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	parser.Start(ctx)
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+
+	m.Lock()
+	defer m.Unlock()
+	require.GreaterOrEqual(t, polls[cold], 1)
+	require.Greater(t, polls[hot], 3*polls[cold])
+}
+
+func TestParserPollIntervalDefaultsToGlobal(t *testing.T) {
+	parser, err := NewEthParser(WithPollInterval(time.Minute))
+	require.NoError(t, err)
+
+	parser.addresses[address] = subscription{blockNumber: 1}
+	parser.addresses[other] = subscription{blockNumber: 1, interval: time.Second}
+
+	require.Equal(t, map[string]time.Duration{
+		address: time.Minute,
+		other:   time.Second,
+	}, parser.pollSchedule())
+}
+
+func TestParserSubscribeWithInterval(t *testing.T) {
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		return nodeNumberHex
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithPollInterval(time.Minute))
+	require.NoError(t, err)
+
+	require.NoError(t, parser.SubscribeWithInterval(hot, time.Second))
+	require.NoError(t, parser.Subscribe(cold))
+	require.Error(t, parser.SubscribeWithInterval(other, 0))
+	require.Error(t, parser.SubscribeWithInterval(hot, time.Second))
+
+	require.Equal(t, map[string]time.Duration{
+		hot:  time.Second,
+		cold: time.Minute,
+	}, parser.pollSchedule())
+}
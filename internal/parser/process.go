@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"context"
+
+	"ethparser/internal/models"
+)
+
+// processNewBlocks fetches each block mined after lastBlock once and
+// processes it for every subscribed address, returning the last block
+// processed. Without a last block, only the current one is processed.
+func (e *ethParser) processNewBlocks(ctx context.Context, lastBlock int) (int, error) {
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	currentBlockNumber, err := e.getCurrentBlockNumber(ctx)
+	if err != nil {
+		return lastBlock, err
+	}
+
+	if err := e.checkReorg(ctx, currentBlockNumber); err != nil {
+		return lastBlock, err
+	}
+
+	finalBlockNumber := e.finalizedBlockNumber(currentBlockNumber)
+
+	from := lastBlock + 1
+	if lastBlock == 0 {
+		from = finalBlockNumber
+	}
+
+	for blockNumber := from; blockNumber <= finalBlockNumber; blockNumber++ {
+		block, err := e.getBlockFromNumber(ctx, blockNumber)
+		if err != nil {
+			return lastBlock, err
+		}
+		if block.Number == "" {
+			return lastBlock, nullBlockError(blockNumber, currentBlockNumber)
+		}
+
+		e.logger.Debug("fetching transactions", "blockNumber", blockNumber)
+
+		if err := e.processBlock(block); err != nil {
+			return lastBlock, err
+		}
+		lastBlock = blockNumber
+	}
+
+	return lastBlock, nil
+}
+
+// processBlock matches each transaction of block once against every
+// subscribed address, caching the transactions of the addresses that had not
+// processed the block yet.
+// e.m must be held by the caller.
+func (e *ethParser) processBlock(block *models.BlockWithDetails) error {
+	blockNumber, err := models.ParseHexInt(block.Number)
+	if err != nil {
+		return err
+	}
+
+	e.recordHeader(block)
+	rewinds := e.rewindCount()
+
+	type pending struct {
+		match             matchFunc
+		cachedBlockNumber int
+		processed         []BlockRange
+		found             []*models.Transaction
+	}
+
+	pendings := make(map[string]*pending)
+	for address, sub := range e.addresses {
+		initialBlockNumber := sub.blockNumber
+		if blockNumber < initialBlockNumber {
+			continue
+		}
+
+		_, cachedBlockNumber := e.transactionCache.GetTransactions(address)
+		processed := e.processedRanges(address, initialBlockNumber, cachedBlockNumber)
+		if containsBlock(processed, blockNumber) {
+			continue
+		}
+
+		pendings[address] = &pending{
+			match:             e.addressMatcher(address),
+			cachedBlockNumber: cachedBlockNumber,
+			processed:         processed,
+		}
+	}
+
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		tx.BlockTimestamp = block.Timestamp
+		for _, p := range pendings {
+			if p.match(tx) {
+				txCopy := *tx
+				p.found = append(p.found, &txCopy)
+			}
+		}
+	}
+
+	matches := 0
+	for address, p := range pendings {
+		matches += len(p.found)
+		processed := addRange(p.processed, BlockRange{From: blockNumber, To: blockNumber})
+		e.storeTransactions(address, p.found, nil, max(p.cachedBlockNumber, blockNumber), processed, rewinds)
+	}
+
+	e.recordScan(block, matches)
+	return nil
+}
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserProcessBlock(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.addresses[hot] = subscription{blockNumber: 100}
+	parser.addresses[cold] = subscription{blockNumber: 106}
+	parser.addresses[other] = subscription{blockNumber: 100}
+	parser.processed[other] = []BlockRange{{From: 100, To: 105}}
+
+	block := &models.BlockWithDetails{
+		Hash:       blockHash(105),
+		ParentHash: blockHash(104),
+		Number:     hexNumber(105),
+		Transactions: []models.Transaction{
+			{Hash: "0x1", From: address, To: hot, BlockNumber: hexNumber(105)},
+			{Hash: "0x2", From: cold, To: other, BlockNumber: hexNumber(105)},
+		},
+	}
+	require.NoError(t, parser.processBlock(block))
+
+	cached, blockNumber := parser.transactionCache.GetTransactions(address)
+	require.Len(t, cached, 1)
+	require.Equal(t, "0x1", cached[0].Hash)
+	require.Equal(t, 105, blockNumber)
+
+	cached, _ = parser.transactionCache.GetTransactions(hot)
+	require.Len(t, cached, 1)
+	require.Equal(t, []BlockRange{{From: 105, To: 105}}, parser.processed[hot])
+
+	// cold subscribed after the block and other already processed it
+	cached, _ = parser.transactionCache.GetTransactions(cold)
+	require.Empty(t, cached)
+	cached, _ = parser.transactionCache.GetTransactions(other)
+	require.Empty(t, cached)
+	require.Equal(t, []BlockRange{{From: 100, To: 105}}, parser.processed[other])
+}
+
+func TestParserProcessNewBlocks(t *testing.T) {
+	chain, node := newTestChain(t, 105, map[int][]models.Transaction{
+		103: {{Hash: "0x103", From: address, To: hot}},
+		105: {{Hash: "0x105", To: hot}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.addresses[hot] = subscription{blockNumber: 100}
+	parser.processed[address] = []BlockRange{{From: 100, To: 102}}
+	parser.processed[hot] = []BlockRange{{From: 100, To: 102}}
+
+	lastBlock, err := parser.processNewBlocks(context.Background(), 102)
+	require.NoError(t, err)
+	require.Equal(t, 105, lastBlock)
+
+	// each new block is fetched once for both addresses
+	require.Equal(t, []int{103, 104, 105}, chain.fetchedBlocks())
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	txs, err = parser.GetTransactions(hot)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	// only the head is fetched again, to check for reorgs
+	require.Subset(t, []int{103, 104, 105}, chain.fetchedBlocks())
+}
+
+func TestParserProcessNewBlocksNullHead(t *testing.T) {
+	chain, node := newTestChain(t, 105, nil)
+	chain.missing = map[int]bool{105: true}
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	lastBlock, err := parser.processNewBlocks(context.Background(), 102)
+	require.ErrorIs(t, err, ErrBlockNotYetAvailable)
+	require.Equal(t, 104, lastBlock)
+
+	// the poller processes the head on its next round
+	require.Equal(t, 104, parser.refreshBlocks(context.Background(), 102))
+	chain.missing = nil
+	require.Equal(t, 105, parser.refreshBlocks(context.Background(), 104))
+}
@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrHistoryUnavailable is returned when a scan needs blocks a pruned node
+// no longer serves
+var ErrHistoryUnavailable = errors.New("history unavailable")
+
+// HistoryUnavailableError is the ErrHistoryUnavailable of a scan from
+// FromBlock, naming the earliest block the node serves
+type HistoryUnavailableError struct {
+	FromBlock     int
+	EarliestBlock int
+}
+
+func (err *HistoryUnavailableError) Error() string {
+	return fmt.Sprintf("%s: cannot scan from block %d, the earliest block available is %d",
+		ErrHistoryUnavailable, err.FromBlock, err.EarliestBlock)
+}
+
+func (err *HistoryUnavailableError) Is(target error) bool {
+	return target == ErrHistoryUnavailable
+}
+
+// prunedMessages are parts of the errors nodes answer for pruned data
+var prunedMessages = []string{
+	"missing trie node",
+	"header not found",
+	"pruned",
+}
+
+// WithEarliestBlock sets the earliest block scanned, addresses subscribed
+// before it being scanned from there, for nodes pruned below it
+func WithEarliestBlock(blockNumber int) EthParserOpt {
+	return func(p *ethParser) error {
+		if blockNumber < 0 {
+			return errors.New("earliest block cannot be negative")
+		}
+		p.earliestBlock = blockNumber
+		return nil
+	}
+}
+
+// isHistoryUnavailable reports whether err is the node missing a block or
+// the data of a block
+func isHistoryUnavailable(err error) bool {
+	if errors.Is(err, ErrBlockNotFound) {
+		return true
+	}
+
+	var rpcErr *JsonRPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	message := strings.ToLower(rpcErr.Message)
+	for _, pruned := range prunedMessages {
+		if strings.Contains(message, pruned) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// historyError turns err, the error of a scan from fromBlock up to toBlock,
+// into a HistoryUnavailableError when the node is missing the earliest
+// blocks of the scan
+func (e *ethParser) historyError(ctx context.Context, fromBlock, toBlock int, err error) error {
+	if !isHistoryUnavailable(err) {
+		return err
+	}
+
+	// a node missing the most recent block isn't pruned
+	if available, availableErr := e.isBlockAvailable(ctx, toBlock); availableErr != nil || !available {
+		return err
+	}
+
+	earliest, searchErr := e.findEarliestBlock(ctx, fromBlock, toBlock)
+	if searchErr != nil {
+		return err
+	}
+
+	// the scan failed on something else than missing history
+	if earliest == fromBlock {
+		return err
+	}
+
+	return &HistoryUnavailableError{FromBlock: fromBlock, EarliestBlock: earliest}
+}
+
+// findEarliestBlock binary searches the earliest block the node serves from
+// fromBlock, which may be pruned, up to toBlock, which is not
+func (e *ethParser) findEarliestBlock(ctx context.Context, fromBlock, toBlock int) (int, error) {
+	for fromBlock < toBlock {
+		middle := fromBlock + (toBlock-fromBlock)/2
+
+		available, err := e.isBlockAvailable(ctx, middle)
+		if err != nil {
+			return 0, err
+		}
+
+		if available {
+			toBlock = middle
+		} else {
+			fromBlock = middle + 1
+		}
+	}
+
+	return toBlock, nil
+}
+
+// isBlockAvailable reports whether the node serves block blockNumber
+func (e *ethParser) isBlockAvailable(ctx context.Context, blockNumber int) (bool, error) {
+	block, err := e.fetchBlockFromNumber(ctx, blockNumber)
+	if isHistoryUnavailable(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return block.Number != "", nil
+}
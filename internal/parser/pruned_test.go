@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserHistoryUnavailable(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		108: {{Hash: "0x108", From: address, BlockNumber: "0x6c"}},
+	})
+	// the node is pruned below block 104
+	chain.missing = map[int]bool{}
+	for number := 0; number < 104; number++ {
+		chain.missing[number] = true
+	}
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 95}
+
+	_, err = parser.GetTransactions(address)
+	require.ErrorIs(t, err, ErrHistoryUnavailable)
+
+	var historyErr *HistoryUnavailableError
+	require.True(t, errors.As(err, &historyErr))
+	require.Equal(t, 95, historyErr.FromBlock)
+	require.Equal(t, 104, historyErr.EarliestBlock)
+
+	_, err = parser.GetTransactionsFrom(address, 100)
+	require.ErrorIs(t, err, ErrHistoryUnavailable)
+}
+
+func TestParserEarliestBlock(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		108: {{Hash: "0x108", From: address, BlockNumber: "0x6c"}},
+	})
+	chain.missing = map[int]bool{}
+	for number := 0; number < 104; number++ {
+		chain.missing[number] = true
+	}
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithEarliestBlock(104))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 95}
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, "0x108", txs[0].Hash)
+	require.NotContains(t, chain.fetchedBlocks(), 103)
+
+	txs, err = parser.GetTransactionsFrom(address, 100)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	_, err = NewEthParser(WithEarliestBlock(-1))
+	require.Error(t, err)
+}
+
+func TestIsHistoryUnavailable(t *testing.T) {
+	require.True(t, isHistoryUnavailable(ErrBlockNotFound))
+	require.True(t, isHistoryUnavailable(&JsonRPCError{Code: -32000, Message: "missing trie node 0x12 (path )"}))
+	require.True(t, isHistoryUnavailable(&JsonRPCError{Code: -32000, Message: "header not found"}))
+	require.False(t, isHistoryUnavailable(&JsonRPCError{Code: -32601, Message: "method not found"}))
+	require.False(t, isHistoryUnavailable(errors.New("unexpected status code: 503")))
+	require.False(t, isHistoryUnavailable(nil))
+}
@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"slices"
+)
+
+// BlockRange is an inclusive range of block numbers
+type BlockRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// addRange adds r to sorted non-overlapping ranges, merging the ones that
+// overlap or are adjacent
+func addRange(ranges []BlockRange, r BlockRange) []BlockRange {
+	all := append(slices.Clone(ranges), r)
+	slices.SortFunc(all, func(a, b BlockRange) int {
+		return a.From - b.From
+	})
+
+	merged := make([]BlockRange, 0, len(all))
+	for _, cur := range all {
+		if n := len(merged); n > 0 && cur.From <= merged[n-1].To+1 {
+			merged[n-1].To = max(merged[n-1].To, cur.To)
+			continue
+		}
+		merged = append(merged, cur)
+	}
+
+	return merged
+}
+
+// missingRanges gets the parts of [from, to] not covered by sorted
+// non-overlapping ranges
+func missingRanges(ranges []BlockRange, from, to int) []BlockRange {
+	var missing []BlockRange
+
+	next := from
+	for _, r := range ranges {
+		if r.To < next {
+			continue
+		}
+		if r.From > to {
+			break
+		}
+		if r.From > next {
+			missing = append(missing, BlockRange{From: next, To: r.From - 1})
+		}
+		next = r.To + 1
+	}
+
+	if next <= to {
+		missing = append(missing, BlockRange{From: next, To: to})
+	}
+
+	return missing
+}
+
+// scannedRange gets the range of blocks scanned without gaps from
+// initialBlockNumber, whose end is below initialBlockNumber when none was
+func scannedRange(ranges []BlockRange, initialBlockNumber int) (int, int) {
+	for _, r := range ranges {
+		if r.From <= initialBlockNumber && initialBlockNumber <= r.To {
+			return initialBlockNumber, r.To
+		}
+	}
+
+	return initialBlockNumber, initialBlockNumber - 1
+}
+
+// rangesSize counts the blocks of non-overlapping ranges
+func rangesSize(ranges []BlockRange) int {
+	size := 0
+	for _, r := range ranges {
+		size += r.To - r.From + 1
+	}
+
+	return size
+}
+
+// containsBlock reports whether one of ranges contains blockNumber
+func containsBlock(ranges []BlockRange, blockNumber int) bool {
+	return slices.ContainsFunc(ranges, func(r BlockRange) bool {
+		return r.From <= blockNumber && blockNumber <= r.To
+	})
+}
+
+// truncateRanges drops the parts of ranges above to
+func truncateRanges(ranges []BlockRange, to int) []BlockRange {
+	truncated := make([]BlockRange, 0, len(ranges))
+	for _, r := range ranges {
+		if r.From > to {
+			break
+		}
+		truncated = append(truncated, BlockRange{From: r.From, To: min(r.To, to)})
+	}
+
+	return truncated
+}
+
+// splitRanges splits sorted ranges into ranges of at most size blocks,
+// ordered from the highest block down
+func splitRanges(ranges []BlockRange, size int) []BlockRange {
+	var split []BlockRange
+	for i := len(ranges) - 1; i >= 0; i-- {
+		for to := ranges[i].To; to >= ranges[i].From; to -= size {
+			split = append(split, BlockRange{From: max(to-size+1, ranges[i].From), To: to})
+		}
+	}
+
+	return split
+}
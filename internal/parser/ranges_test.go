@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRange(t *testing.T) {
+	var ranges []BlockRange
+
+	ranges = addRange(ranges, BlockRange{From: 10, To: 12})
+	ranges = addRange(ranges, BlockRange{From: 1, To: 3})
+	require.Equal(t, []BlockRange{{From: 1, To: 3}, {From: 10, To: 12}}, ranges)
+
+	// adjacent ranges are merged
+	ranges = addRange(ranges, BlockRange{From: 4, To: 5})
+	require.Equal(t, []BlockRange{{From: 1, To: 5}, {From: 10, To: 12}}, ranges)
+
+	// overlapping ranges are merged
+	ranges = addRange(ranges, BlockRange{From: 5, To: 11})
+	require.Equal(t, []BlockRange{{From: 1, To: 12}}, ranges)
+}
+
+func TestMissingRanges(t *testing.T) {
+	ranges := []BlockRange{{From: 3, To: 4}, {From: 8, To: 9}}
+
+	require.Equal(t, []BlockRange{{From: 1, To: 2}, {From: 5, To: 7}, {From: 10, To: 12}}, missingRanges(ranges, 1, 12))
+	require.Equal(t, []BlockRange{{From: 5, To: 7}}, missingRanges(ranges, 3, 9))
+	require.Empty(t, missingRanges(ranges, 8, 9))
+	require.Equal(t, []BlockRange{{From: 1, To: 12}}, missingRanges(nil, 1, 12))
+}
+
+func TestSplitRanges(t *testing.T) {
+	ranges := []BlockRange{{From: 1, To: 3}, {From: 10, To: 24}}
+
+	require.Equal(t, []BlockRange{
+		{From: 20, To: 24},
+		{From: 15, To: 19},
+		{From: 10, To: 14},
+		{From: 1, To: 3},
+	}, splitRanges(ranges, 5))
+	require.Empty(t, splitRanges(nil, 5))
+}
+
+func TestScannedRange(t *testing.T) {
+	from, to := scannedRange([]BlockRange{{From: 100, To: 104}, {From: 108, To: 110}}, 100)
+	require.Equal(t, 100, from)
+	require.Equal(t, 104, to)
+
+	from, to = scannedRange([]BlockRange{{From: 108, To: 110}}, 100)
+	require.Equal(t, 100, from)
+	require.Equal(t, 99, to)
+}
+
+func TestRangesSize(t *testing.T) {
+	require.Zero(t, rangesSize(nil))
+	require.Equal(t, 1, rangesSize([]BlockRange{{From: 5, To: 5}}))
+	require.Equal(t, 8, rangesSize([]BlockRange{{From: 1, To: 3}, {From: 6, To: 10}}))
+}
@@ -0,0 +1,28 @@
+package parser
+
+import (
+	"errors"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultRateLimit keeps clear of the limits of public endpoints
+	defaultRateLimit = 10
+	defaultRateBurst = 10
+)
+
+// WithRateLimit caps the requests sent to the node to rps per second, with
+// bursts of up to burst requests. A rate of math.Inf(1) disables the limit.
+func WithRateLimit(rps float64, burst int) EthParserOpt {
+	return func(p *ethParser) error {
+		if rps <= 0 {
+			return errors.New("rate limit must be positive")
+		}
+		if burst < 1 {
+			return errors.New("rate limit burst must be at least 1")
+		}
+		p.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRateLimitInvalid(t *testing.T) {
+	_, err := NewEthParser(WithRateLimit(0, 1))
+	require.Error(t, err)
+
+	_, err = NewEthParser(WithRateLimit(1, 0))
+	require.Error(t, err)
+}
+
+func TestParserRateLimit(t *testing.T) {
+	node, requests := newFlakyNode(t, 0, 0)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRateLimit(20, 1), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := parser.GetCurrentBlock()
+		require.NoError(t, err)
+	}
+
+	// the first request uses the burst, the next two wait 50ms each
+	require.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	require.Equal(t, int32(3), requests.Load())
+}
+
+func TestParserRateLimitRespectsContext(t *testing.T) {
+	node, requests := newFlakyNode(t, 0, 0)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRateLimit(0.01, 1), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = parser.GetCurrentBlockCtx(ctx)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, int32(1), requests.Load())
+}
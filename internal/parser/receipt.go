@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ethparser/internal/models"
+)
+
+// ErrReceiptNotFound is returned for transactions the node has no receipt
+// for, as pending or unknown transactions
+var ErrReceiptNotFound = errors.New("receipt not found")
+
+type JsonRPCResponseReceipt struct {
+	Result models.Receipt `json:"result"`
+}
+
+func (e *ethParser) GetTransactionReceipt(hash string) (*models.Receipt, error) {
+	return e.GetTransactionReceiptCtx(context.Background(), hash)
+}
+
+// GetTransactionReceiptCtx gets the receipt of the transaction with hash,
+// telling whether it succeeded or reverted
+func (e *ethParser) GetTransactionReceiptCtx(ctx context.Context, hash string) (*models.Receipt, error) {
+	if !models.IsValidHash(hash) {
+		return nil, fmt.Errorf("invalid transaction hash: %q", hash)
+	}
+
+	rpcRequest := JsonRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_getTransactionReceipt",
+		Params:  []interface{}{hash},
+	}
+
+	rpcResponse, err := do[JsonRPCResponseReceipt](ctx, e, rpcRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	// the node answers null until the transaction is mined
+	if rpcResponse.Result.TransactionHash == "" {
+		return nil, fmt.Errorf("%w: %s", ErrReceiptNotFound, hash)
+	}
+
+	return &rpcResponse.Result, nil
+}
+
+// methodNotFoundCode is the JSON-RPC error code of nodes that don't support
+// a method
+const methodNotFoundCode = -32601
+
+type JsonRPCResponseReceipts struct {
+	Result []models.Receipt `json:"result"`
+}
+
+func (e *ethParser) GetBlockReceipts(blockNumber int) ([]*models.Receipt, error) {
+	return e.GetBlockReceiptsCtx(context.Background(), blockNumber)
+}
+
+// GetBlockReceiptsCtx gets the receipts of every transaction of a block in a
+// single eth_getBlockReceipts call, getting them one transaction at a time
+// from nodes that don't support it
+func (e *ethParser) GetBlockReceiptsCtx(ctx context.Context, blockNumber int) ([]*models.Receipt, error) {
+	if blockNumber < 0 {
+		return nil, fmt.Errorf("invalid block number: %d", blockNumber)
+	}
+
+	if !e.blockReceiptsUnsupported.Load() {
+		receipts, err := e.fetchBlockReceipts(ctx, blockNumber)
+		if err == nil || !isMethodNotFound(err) {
+			return receipts, err
+		}
+
+		// the node won't support it on the next call either
+		e.blockReceiptsUnsupported.Store(true)
+	}
+
+	block, err := e.GetBlockCtx(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := make([]*models.Receipt, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		receipt, err := e.GetTransactionReceiptCtx(ctx, tx.Hash)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts, nil
+}
+
+// fetchBlockReceipts gets the receipts of a block with eth_getBlockReceipts
+func (e *ethParser) fetchBlockReceipts(ctx context.Context, blockNumber int) ([]*models.Receipt, error) {
+	hexNumber, err := intToHex(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcRequest := JsonRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_getBlockReceipts",
+		Params:  []interface{}{hexNumber},
+	}
+
+	rpcResponse, err := do[JsonRPCResponseReceipts](ctx, e, rpcRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	// the node answers null for blocks it doesn't have
+	if rpcResponse.Result == nil {
+		return nil, fmt.Errorf("%w: %d", ErrBlockNotFound, blockNumber)
+	}
+
+	receipts := make([]*models.Receipt, 0, len(rpcResponse.Result))
+	for i := range rpcResponse.Result {
+		receipts = append(receipts, &rpcResponse.Result[i])
+	}
+
+	return receipts, nil
+}
+
+// isMethodNotFound reports whether err is the error of a node that doesn't
+// support the method called
+func isMethodNotFound(err error) bool {
+	var rpcErr *JsonRPCError
+	return errors.As(err, &rpcErr) && rpcErr.Code == methodNotFoundCode
+}
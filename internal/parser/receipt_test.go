@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserGetTransactionReceipt(t *testing.T) {
+	const (
+		succeeded = "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+		reverted  = "0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060"
+		pending   = "0x4e3a3754410177e6937ef1f84bba68ea139e8d1a2258c5f85db9f1cd715a1bdd"
+	)
+
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		require.Equal(t, "eth_getTransactionReceipt", method)
+		switch params[0] {
+		case succeeded:
+			return models.Receipt{
+				TransactionHash: succeeded,
+				StatusCode:      "0x1",
+				GasUsed:         "0x5208",
+				Logs:            []models.Log{{Address: hot, Topics: []string{models.TransferEventTopic}}},
+			}
+		case reverted:
+			return models.Receipt{TransactionHash: reverted, StatusCode: "0x0", GasUsed: "0x5208"}
+		}
+		return nil
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	receipt, err := parser.GetTransactionReceipt(succeeded)
+	require.NoError(t, err)
+	require.True(t, receipt.Status())
+	require.Len(t, receipt.Logs, 1)
+	require.Equal(t, hot, receipt.Logs[0].Address)
+
+	receipt, err = parser.GetTransactionReceipt(reverted)
+	require.NoError(t, err)
+	require.False(t, receipt.Status())
+
+	_, err = parser.GetTransactionReceipt(pending)
+	require.ErrorIs(t, err, ErrReceiptNotFound)
+
+	_, err = parser.GetTransactionReceipt("0x01")
+	require.ErrorContains(t, err, "invalid transaction hash")
+}
+
+func TestParserGetBlockReceipts(t *testing.T) {
+	var calls []string
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		calls = append(calls, method)
+		require.Equal(t, "eth_getBlockReceipts", method)
+		if params[0] != "0x10" {
+			return nil
+		}
+
+		return []models.Receipt{
+			{TransactionHash: "0x01", BlockNumber: "0x10", StatusCode: "0x1", GasUsed: "0x5208"},
+			{TransactionHash: "0x02", BlockNumber: "0x10", StatusCode: "0x0", GasUsed: "0x5208"},
+		}
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	receipts, err := parser.GetBlockReceipts(16)
+	require.NoError(t, err)
+	require.Len(t, receipts, 2)
+	require.Equal(t, "0x01", receipts[0].TransactionHash)
+	require.True(t, receipts[0].Status())
+	require.False(t, receipts[1].Status())
+	require.Equal(t, []string{"eth_getBlockReceipts"}, calls)
+
+	_, err = parser.GetBlockReceipts(17)
+	require.ErrorIs(t, err, ErrBlockNotFound)
+
+	_, err = parser.GetBlockReceipts(-1)
+	require.Error(t, err)
+}
+
+func TestParserGetBlockReceiptsFallback(t *testing.T) {
+	const (
+		first  = "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+		second = "0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060"
+	)
+
+	var calls []string
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		calls = append(calls, method)
+		switch method {
+		case "eth_getBlockReceipts":
+			return &JsonRPCError{Code: methodNotFoundCode, Message: "the method eth_getBlockReceipts does not exist/is not available"}
+		case "eth_getBlockByNumber":
+			return models.BlockWithDetails{
+				Hash:         "0xb16",
+				Number:       "0x10",
+				Transactions: []models.Transaction{{Hash: first}, {Hash: second}},
+			}
+		case "eth_getTransactionReceipt":
+			return models.Receipt{TransactionHash: params[0].(string), BlockNumber: "0x10", StatusCode: "0x1"}
+		}
+		return nil
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	receipts, err := parser.GetBlockReceipts(16)
+	require.NoError(t, err)
+	require.Len(t, receipts, 2)
+	require.Equal(t, first, receipts[0].TransactionHash)
+	require.Equal(t, second, receipts[1].TransactionHash)
+	require.Equal(t, []string{
+		"eth_getBlockReceipts",
+		"eth_getBlockByNumber",
+		"eth_getTransactionReceipt",
+		"eth_getTransactionReceipt",
+	}, calls)
+
+	// the node is not asked for the block receipts again
+	calls = nil
+	_, err = parser.GetBlockReceipts(16)
+	require.NoError(t, err)
+	require.NotContains(t, calls, "eth_getBlockReceipts")
+}
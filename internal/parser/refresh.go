@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+)
+
+func (e *ethParser) RefreshRange(address string, fromBlock, toBlock int) error {
+	return e.RefreshRangeCtx(context.Background(), address, fromBlock, toBlock)
+}
+
+// RefreshRangeCtx fetches the blocks from fromBlock to toBlock again, bypassing
+// the block cache, and merges the transactions of address they hold into its
+// cached ones, as when a window is suspected to have been missed. The range
+// is clamped to the blocks of the subscription up to the last finalized one.
+func (e *ethParser) RefreshRangeCtx(ctx context.Context, address string, fromBlock, toBlock int) error {
+	address, err := e.parseSubscription(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	if fromBlock < 0 || toBlock < fromBlock {
+		return fmt.Errorf("invalid block range: %d-%d", fromBlock, toBlock)
+	}
+
+	// no walk must store the transactions of address in between
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	initialBlockNumber, err := e.initialBlockNumber(address)
+	if err != nil {
+		return err
+	}
+
+	currentBlockNumber, err := e.getCurrentBlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+
+	fromBlock = max(fromBlock, initialBlockNumber)
+	toBlock = min(toBlock, e.finalizedBlockNumber(currentBlockNumber))
+	if fromBlock > toBlock {
+		return nil
+	}
+
+	if err := e.checkScanSize(toBlock - fromBlock + 1); err != nil {
+		return err
+	}
+
+	e.blocks.dropRange(fromBlock, toBlock)
+	found, err := e.getTransactionsFromBlockNumbers(ctx, fromBlock, toBlock, e.addressMatcher(address))
+	if err != nil {
+		return e.historyError(ctx, fromBlock, toBlock, err)
+	}
+
+	// e.m is held exclusively, no rewind runs until the transactions are
+	// stored
+	rewinds := e.rewindCount()
+	cachedTransactions, cachedBlockNumber := e.transactionCache.GetTransactions(address)
+	e.forgetEvicted(address, cachedBlockNumber)
+
+	// the cached block number only moves up to the blocks now scanned
+	// without gaps, as a cache restored without its ranges assumes
+	processed := e.processedRanges(address, initialBlockNumber, cachedBlockNumber)
+	processed = addRange(processed, BlockRange{From: fromBlock, To: toBlock})
+	_, scannedTo := scannedRange(processed, initialBlockNumber)
+
+	e.storeTransactions(address, found, cachedTransactions, max(cachedBlockNumber, scannedTo), processed, rewinds)
+	return nil
+}
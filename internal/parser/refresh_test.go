@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserRefreshRange(t *testing.T) {
+	chain, node := newTestChain(t, 105, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	// the node now has a transaction the first walk missed, which the
+	// cached blocks don't
+	chain.transactions[103] = []models.Transaction{{Hash: "0x103", To: address}}
+
+	txs, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	require.NoError(t, parser.RefreshRange(address, 102, 104))
+
+	txs, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	require.ElementsMatch(t, []string{"0x101", "0x103"}, []string{txs[0].Hash, txs[1].Hash})
+
+	// a range past the cached block moves it only up to the blocks scanned
+	// without gaps
+	chain.head.Store(110)
+	require.NoError(t, parser.RefreshRange(address, 108, 200))
+
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Equal(t, []BlockRange{{From: 100, To: 105}, {From: 108, To: 110}}, ranges)
+
+	_, cachedBlockNumber := parser.transactionCache.GetTransactions(address)
+	require.Equal(t, 105, cachedBlockNumber)
+}
+
+func TestParserRefreshRangeErrors(t *testing.T) {
+	_, node := newTestChain(t, 105, nil)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	require.ErrorIs(t, parser.RefreshRange(address, 100, 105), ErrNotSubscribed)
+
+	parser.addresses[address] = subscription{blockNumber: 100}
+	require.Error(t, parser.RefreshRange(address, 105, 100))
+	require.Error(t, parser.RefreshRange(address, -1, 100))
+
+	// blocks before the subscription are not scanned
+	require.NoError(t, parser.RefreshRange(address, 10, 20))
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Empty(t, ranges)
+}
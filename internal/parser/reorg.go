@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	// defaultReorgDepth is the depth after which blocks are no longer
+	// checked for reorgs
+	defaultReorgDepth = 12
+)
+
+// WithConfirmations only returns transactions from blocks at least n blocks
+// below the head, which cannot be reorganized away, the cache being filled up
+// to that block rather than the head. Transactions then show up n blocks
+// later, about 12 seconds each on mainnet, in exchange for never being
+// dropped once returned. With the default of 0, transactions are returned up
+// to the head and removed on reorgs.
+func WithConfirmations(n int) EthParserOpt {
+	return func(p *ethParser) error {
+		if n < 0 {
+			return errors.New("confirmations cannot be negative")
+		}
+		p.confirmations = n
+		return nil
+	}
+}
+
+// WithReorgDepth treats blocks more than n blocks below the head as final,
+// so they are no longer checked for reorgs
+func WithReorgDepth(n int) EthParserOpt {
+	return func(p *ethParser) error {
+		if n < 0 {
+			return errors.New("reorg depth cannot be negative")
+		}
+		p.reorgDepth = n
+		return nil
+	}
+}
+
+// finalizedBlockNumber is the highest block transactions are returned from
+// when the head is at headBlockNumber
+func (e *ethParser) finalizedBlockNumber(headBlockNumber int) int {
+	return headBlockNumber - e.confirmations
+}
+
+// checkReorg compares the most recent processed block with the block the
+// node reports at the same height. When they differ the chain reorganized,
+// so the processed state is rewound to their common ancestor and the
+// divergent range gets fetched again.
+// e.m must be held by the caller, at least for reading.
+func (e *ethParser) checkReorg(ctx context.Context, headBlockNumber int) error {
+	tip, ok := e.headers.tip()
+	if !ok {
+		return nil
+	}
+
+	// a node behind the processed tip is checked at its own head
+	if tip.Number > headBlockNumber {
+		if tip, ok = e.headers.byNumber(headBlockNumber); !ok {
+			return nil
+		}
+	}
+
+	if tip.Number <= headBlockNumber-e.reorgDepth {
+		return nil
+	}
+
+	// the block cache would hide the reorg
+	block, err := e.fetchBlockFromNumber(ctx, tip.Number)
+	if err != nil {
+		return err
+	}
+
+	if block.Hash == tip.Hash {
+		return nil
+	}
+
+	ancestor, err := e.findCommonAncestor(ctx, block)
+	if err != nil {
+		return err
+	}
+
+	reorgErr := &ReorgError{Block: tip.Number, Ancestor: ancestor.Number}
+	e.logger.Warn("chain reorganization", "blockNumber", tip.Number, "ancestor", ancestor.Number)
+	e.reportError(reorgErr)
+
+	e.rewind(ancestor.Number)
+	return nil
+}
+
+// rewind forgets everything processed after blockNumber. e.m must be held
+// by the caller, at least for reading: the scans of other addresses running
+// alongside drop what they read before, see storeTransactions.
+func (e *ethParser) rewind(blockNumber int) {
+	e.processedM.Lock()
+	defer e.processedM.Unlock()
+
+	e.rewinds++
+
+	e.headers.dropAfter(blockNumber)
+	e.blocks.dropAfter(blockNumber)
+
+	for address, ranges := range e.processed {
+		e.processed[address] = truncateRanges(ranges, blockNumber)
+	}
+
+	for address := range e.addresses {
+		e.transactionCache.Rewind(address, blockNumber)
+	}
+}
@@ -0,0 +1,192 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserGetTransactionsHandlesReorg(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		105: {{Hash: "0x105", To: address}},
+		109: {{Hash: "0x109", From: address}},
+		110: {{Hash: "0x110", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0), WithReorgDepth(5))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+
+	// blocks 109 and 110 get orphaned by a branch mined up to 111
+	chain.fork(109, map[int][]models.Transaction{
+		110: {{Hash: "0x110b", To: address}},
+		111: {{Hash: "0x111", From: address}},
+	})
+	chain.head.Store(111)
+
+	txs, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	require.ElementsMatch(t, []string{"0x105", "0x110b", "0x111"}, hashes)
+
+	cached, blockNumber := parser.transactionCache.GetTransactions(address)
+	require.Len(t, cached, 3)
+	require.Equal(t, 111, blockNumber)
+
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Equal(t, []BlockRange{{From: 100, To: 111}}, ranges)
+}
+
+// blockGate holds the first request for a block by number until released
+type blockGate struct {
+	param   []byte
+	claimed atomic.Bool
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (bg *blockGate) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	if bytes.Contains(body, []byte("eth_getBlockByNumber")) && bytes.Contains(body, bg.param) && bg.claimed.CompareAndSwap(false, true) {
+		close(bg.blocked)
+		<-bg.release
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestParserConcurrentScansAcrossReorg(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		105: {{Hash: "0x105", To: hot}},
+		109: {{Hash: "0x109", From: address}},
+	})
+
+	// the new head, only asked for once the head moves
+	gate := &blockGate{param: []byte(`"0x70"`), blocked: make(chan struct{}), release: make(chan struct{})}
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0), WithReorgDepth(5), WithHTTPClient(&http.Client{Transport: gate}))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+	parser.addresses[hot] = subscription{blockNumber: 100}
+
+	for _, a := range []string{address, hot} {
+		_, err = parser.GetTransactions(a)
+		require.NoError(t, err)
+	}
+
+	// the scan of address holds on to its cached transactions while
+	// waiting for the new head
+	chain.head.Store(112)
+	done := make(chan error)
+	go func() {
+		_, err := parser.GetTransactions(address)
+		done <- err
+	}()
+	<-gate.blocked
+
+	// meanwhile block 109 gets orphaned, which the scan of hot rewinds
+	chain.fork(109, map[int][]models.Transaction{
+		110: {{Hash: "0x110b", To: address}},
+	})
+	_, err = parser.GetTransactions(hot)
+	require.NoError(t, err)
+
+	close(gate.release)
+	require.NoError(t, <-done)
+
+	// the scan of address doesn't put back what the rewind dropped
+	cached, _ := parser.transactionCache.GetTransactions(address)
+	for _, tx := range cached {
+		require.NotEqual(t, "0x109", tx.Hash)
+	}
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, "0x110b", txs[0].Hash)
+
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Equal(t, []BlockRange{{From: 100, To: 112}}, ranges)
+}
+
+func TestParserReorgBelowConfirmationsIgnored(t *testing.T) {
+	chain, node := newTestChain(t, 110, nil)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0), WithReorgDepth(2))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 105}
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	// the processed tip is final once the head is 2 blocks past it
+	chain.head.Store(112)
+	fetched := len(chain.fetchedBlocks())
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Equal(t, []int{112, 111}, chain.fetchedBlocks()[fetched:])
+}
+
+func TestParserConfirmations(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{
+		105: {{Hash: "0x105", To: address}},
+		109: {{Hash: "0x109", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0), WithConfirmations(3))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, "0x105", txs[0].Hash)
+	require.Equal(t, 107, slices.Max(chain.fetchedBlocks()))
+
+	// the cache is up to the final block rather than the head
+	_, blockNumber := parser.transactionCache.GetTransactions(address)
+	require.Equal(t, 107, blockNumber)
+
+	chain.head.Store(112)
+	txs, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Equal(t, []BlockRange{{From: 100, To: 109}}, ranges)
+}
+
+func TestWithConfirmations(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+	require.Zero(t, parser.confirmations)
+	require.Equal(t, 110, parser.finalizedBlockNumber(110))
+
+	_, err = NewEthParser(WithConfirmations(-1))
+	require.Error(t, err)
+	_, err = NewEthParser(WithReorgDepth(-1))
+	require.Error(t, err)
+}
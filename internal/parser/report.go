@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// errorsBufferSize is the number of errors buffered for Errors, errors being
+// dropped when it is full
+const errorsBufferSize = 64
+
+// ReorgError reports a chain reorganization orphaning the processed blocks
+// after Ancestor
+type ReorgError struct {
+	// Block is the processed block found orphaned
+	Block int
+	// Ancestor is the last block shared with the new branch
+	Ancestor int
+}
+
+func (re *ReorgError) Error() string {
+	return fmt.Sprintf("reorg detected at block %d, rewinding to block %d", re.Block, re.Ancestor)
+}
+
+// Errors streams the non-fatal errors met in the background, such as failed
+// block fetches and reorgs. Errors not read in time are dropped and counted
+// in Stats.
+func (e *ethParser) Errors() <-chan error {
+	return e.errs
+}
+
+// reportError sends err to Errors without blocking
+func (e *ethParser) reportError(err error) {
+	select {
+	case e.errs <- err:
+	default:
+		e.droppedErrors.Add(1)
+	}
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserErrorsReportsPollFailures(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(node.Close)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithPollInterval(10*time.Millisecond), WithRetry(1, time.Millisecond))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	parser.Start(ctx)
+
+	select {
+	case err := <-parser.Errors():
+		require.ErrorContains(t, err, "failed to poll address "+address)
+	case <-time.After(time.Second):
+		t.Fatal("no error reported")
+	}
+}
+
+func TestParserErrorsReportsReorgs(t *testing.T) {
+	chain, node := newTestChain(t, 110, map[int][]models.Transaction{})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithReorgDepth(5))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	chain.fork(109, nil)
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	var reorgErr *ReorgError
+	require.True(t, errors.As(<-parser.Errors(), &reorgErr))
+	require.Equal(t, ReorgError{Block: 110, Ancestor: 108}, *reorgErr)
+}
+
+func TestParserErrorsDropsUnread(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	for i := 0; i < errorsBufferSize+3; i++ {
+		parser.reportError(errors.New("failed"))
+	}
+
+	require.Len(t, parser.Errors(), errorsBufferSize)
+	require.Equal(t, int64(3), parser.Stats().DroppedErrors)
+}
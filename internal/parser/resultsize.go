@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+
+	"ethparser/internal/models"
+)
+
+// ErrResultTruncated is returned along with the most recent transactions of
+// an address when it has more than the max result size set by
+// WithMaxResultSize, for the caller to page through the others
+var ErrResultTruncated = errors.New("result truncated")
+
+// WithMaxResultSize caps the transactions returned by GetTransactions and
+// GetTransactionsWithRange to the n most recent ones, returned along with
+// ErrResultTruncated when there are more, so that an address with an
+// enormous history cannot exhaust the memory of the caller. 0 returns every
+// transaction.
+func WithMaxResultSize(n int) EthParserOpt {
+	return func(p *ethParser) error {
+		if n < 0 {
+			return errors.New("max result size cannot be negative")
+		}
+		p.maxResultSize = n
+		return nil
+	}
+}
+
+// capTransactions keeps the max result size last of transactions, sorted in
+// chain order, failing with ErrResultTruncated when some are dropped
+func (e *ethParser) capTransactions(transactions []*models.Transaction) ([]*models.Transaction, error) {
+	if e.maxResultSize == 0 || len(transactions) <= e.maxResultSize {
+		return transactions, nil
+	}
+
+	capped := transactions[len(transactions)-e.maxResultSize:]
+	return capped, fmt.Errorf("%w: %d most recent of %d transactions returned", ErrResultTruncated, len(capped), len(transactions))
+}
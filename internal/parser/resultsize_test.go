@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserMaxResultSize(t *testing.T) {
+	_, node := newTestChain(t, 110, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+		103: {{Hash: "0x103", To: address}},
+		105: {{Hash: "0x105a", From: address, TransactionIndex: "0x0"}, {Hash: "0x105b", To: address, TransactionIndex: "0x1"}},
+		108: {{Hash: "0x108", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0), WithMaxResultSize(3))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(address)
+	require.ErrorIs(t, err, ErrResultTruncated)
+
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	// the most recent ones are kept
+	require.Equal(t, []string{"0x105a", "0x105b", "0x108"}, hashes)
+
+	txs, fromBlock, toBlock, err := parser.GetTransactionsWithRange(address)
+	require.ErrorIs(t, err, ErrResultTruncated)
+	require.Len(t, txs, 3)
+	require.Equal(t, 100, fromBlock)
+	require.Equal(t, 110, toBlock)
+
+	// the calls going through every transaction are not capped
+	count, _, _, err := parser.GetTransactionSummary(address)
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+
+	page, err := parser.GetTransactionsPaged(address, 0, 0, 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, 5, page.Total)
+
+	// a query is capped after paging, the older transactions staying reachable
+	page, err = parser.QueryTransactions(context.Background(), address, TransactionsQuery{Direction: Outbound, Limit: 3, Offset: 0})
+	require.NoError(t, err)
+	require.Equal(t, 3, page.Total)
+	require.Equal(t, 100, page.ScannedFrom)
+	require.Equal(t, 110, page.ScannedTo)
+
+	page, err = parser.QueryTransactions(context.Background(), address, TransactionsQuery{Limit: 4, Offset: 1})
+	require.ErrorIs(t, err, ErrResultTruncated)
+	require.Equal(t, 5, page.Total)
+	require.Len(t, page.Transactions, 3)
+}
+
+func TestParserMaxResultSizeNotReached(t *testing.T) {
+	_, node := newTestChain(t, 110, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0), WithMaxResultSize(1))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	txs, err := parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	_, err = NewEthParser(WithMaxResultSize(-1))
+	require.Error(t, err)
+}
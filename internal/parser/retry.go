@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultRetryBase   = 100 * time.Millisecond
+)
+
+// WithRetry sends requests failing with a network error, a 429 or a 5xx
+// status up to maxAttempts times, backing off exponentially from base with
+// jitter. A maxAttempts of 1 disables retries.
+func WithRetry(maxAttempts int, base time.Duration) EthParserOpt {
+	return func(p *ethParser) error {
+		if maxAttempts < 1 {
+			return errors.New("max attempts must be at least 1")
+		}
+		if base <= 0 {
+			return errors.New("retry base must be positive")
+		}
+		p.maxAttempts = maxAttempts
+		p.retryBase = base
+		return nil
+	}
+}
+
+// retryableStatus reports whether a request failing with statusCode may
+// succeed when sent again
+func retryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// backoff is the wait before retrying a request after attempt failed, half
+// of it being random so clients failing together do not retry together
+func (e *ethParser) backoff(attempt int) time.Duration {
+	delay := e.retryBase << (attempt - 1)
+	return delay/2 + rand.N(delay/2+1)
+}
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyNode starts a node failing the first failures requests with
+// statusCode, then answering eth_blockNumber
+func newFlakyNode(t *testing.T, failures int32, statusCode int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			http.Error(w, http.StatusText(statusCode), statusCode)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": requestID(t, r), "jsonrpc": "2.0", "result": nodeNumberHex})
+	}))
+	t.Cleanup(node.Close)
+
+	return node, &requests
+}
+
+func TestParserRetriesTransientFailures(t *testing.T) {
+	for _, statusCode := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable} {
+		node, requests := newFlakyNode(t, 2, statusCode)
+
+		parser, err := NewEthParser(WithNodeUrl(node.URL), WithRetry(3, time.Millisecond))
+		require.NoError(t, err)
+
+		blockNumber, err := parser.GetCurrentBlock()
+		require.NoError(t, err, statusCode)
+		require.Equal(t, 0x13ecaeb, blockNumber)
+		require.Equal(t, int32(3), requests.Load())
+	}
+}
+
+func TestParserRetryGivesUp(t *testing.T) {
+	node, requests := newFlakyNode(t, 5, http.StatusServiceUnavailable)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRetry(3, time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.ErrorContains(t, err, "503")
+	require.Equal(t, int32(3), requests.Load())
+}
+
+func TestParserDoesNotRetryClientErrors(t *testing.T) {
+	node, requests := newFlakyNode(t, 1, http.StatusBadRequest)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRetry(3, time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.ErrorContains(t, err, "400")
+	require.Equal(t, int32(1), requests.Load())
+}
+
+func TestParserBackoff(t *testing.T) {
+	parser, err := NewEthParser(WithRetry(5, 100*time.Millisecond))
+	require.NoError(t, err)
+
+	for attempt, delay := range []time.Duration{100, 200, 400, 800} {
+		backoff := parser.backoff(attempt + 1)
+		require.GreaterOrEqual(t, backoff, delay*time.Millisecond/2)
+		require.LessOrEqual(t, backoff, delay*time.Millisecond)
+	}
+}
+
+func TestWithRetryInvalid(t *testing.T) {
+	_, err := NewEthParser(WithRetry(0, time.Second))
+	require.Error(t, err)
+
+	_, err = NewEthParser(WithRetry(3, 0))
+	require.Error(t, err)
+}
+
+func TestGetTransactionsInBlockRangeNodeDown(t *testing.T) {
+	node, requests := newFlakyNode(t, 1<<30, http.StatusInternalServerError)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRetry(3, time.Millisecond))
+	require.NoError(t, err)
+
+	require.NotPanics(t, func() {
+		_, err = parser.getTransactionsInBlockRange(context.Background(), 100, blockHash(105), parser.addressMatcher(address))
+	})
+	require.ErrorContains(t, err, "500")
+	require.Equal(t, int32(3), requests.Load())
+}
+
+func TestParserTimeout(t *testing.T) {
+	var requests atomic.Int32
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first request hangs past the timeout
+		if requests.Add(1) == 1 {
+			// reading the body lets the server notice the client going away
+			io.Copy(io.Discard, r.Body)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": requestID(t, r), "jsonrpc": "2.0", "result": nodeNumberHex})
+	}))
+	t.Cleanup(node.Close)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithTimeout(50*time.Millisecond), WithRetry(1, time.Millisecond), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = parser.GetCurrentBlock()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+
+	// a request timing out is retried
+	requests.Store(0)
+	parser, err = NewEthParser(WithNodeUrl(node.URL), WithTimeout(50*time.Millisecond), WithRetry(2, time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = parser.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, int32(2), requests.Load())
+
+	_, err = NewEthParser(WithTimeout(-time.Second))
+	require.Error(t, err)
+}
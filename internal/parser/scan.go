@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"context"
+	"errors"
+
+	"ethparser/internal/models"
+)
+
+// ScanStrategy is the order in which the blocks not scanned yet are walked
+type ScanStrategy int
+
+const (
+	// FullRange walks every block not scanned yet in one pass, the default
+	FullRange ScanStrategy = iota
+	// Incremental walks down from the head in windows that double in size
+	// while they find nothing, so recent activity is found first
+	Incremental
+)
+
+// incrementalWindowSize is the size of the first window walked by the
+// Incremental strategy
+const incrementalWindowSize = 16
+
+// WithScanStrategy sets the order in which the blocks not scanned yet are
+// walked
+func WithScanStrategy(strategy ScanStrategy) EthParserOpt {
+	return func(e *ethParser) error {
+		if strategy != FullRange && strategy != Incremental {
+			return errors.New("scan strategy must be FullRange or Incremental")
+		}
+
+		e.scanStrategy = strategy
+		return nil
+	}
+}
+
+// GetTransactionsProgressive is GetTransactionsCtx calling yield with the
+// cached transactions first and then with those of each window as it is
+// scanned, rather than only returning them once the whole walk is done. An
+// error yield returns stops the walk, and the walk stops with
+// ErrNotSubscribed when address is unsubscribed while yield runs.
+func (e *ethParser) GetTransactionsProgressive(ctx context.Context, address string, yield func([]*models.Transaction) error) ([]*models.Transaction, error) {
+	address, err := e.parseSubscription(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if yield == nil {
+		return nil, errors.New("yield cannot be nil")
+	}
+
+	result, err := e.getTransactions(ctx, address, 0, yield)
+	if result == nil {
+		return nil, err
+	}
+
+	return copyTransactions(result.Transactions), err
+}
+
+// takeWindow splits off the highest size blocks of ranges, sorted in
+// ascending order, returning them and the ranges left
+func takeWindow(ranges []BlockRange, size int) (BlockRange, []BlockRange) {
+	last := ranges[len(ranges)-1]
+	if last.To-last.From+1 <= size {
+		return last, ranges[:len(ranges)-1]
+	}
+
+	window := BlockRange{From: last.To - size + 1, To: last.To}
+	rest := append(ranges[:len(ranges)-1:len(ranges)-1], BlockRange{From: last.From, To: window.From - 1})
+	return window, rest
+}
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserIncrementalScan(t *testing.T) {
+	_, node := newTestChain(t, 150, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+		125: {{Hash: "0x125", To: address}},
+		149: {{Hash: "0x149", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithScanStrategy(Incremental))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	var yielded [][]string
+	yield := func(transactions []*models.Transaction) error {
+		var hashes []string
+		for _, tx := range transactions {
+			hashes = append(hashes, tx.Hash)
+		}
+		yielded = append(yielded, hashes)
+		return nil
+	}
+
+	transactions, err := parser.GetTransactionsProgressive(context.Background(), address, yield)
+	require.NoError(t, err)
+	require.Len(t, transactions, 3)
+
+	// the most recent transactions come first
+	require.Equal(t, [][]string{{"0x149"}, {"0x125"}, {"0x101"}}, yielded)
+
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Equal(t, []BlockRange{{From: 100, To: 150}}, ranges)
+
+	// the cached transactions are yielded at once on the next call
+	yielded = nil
+	_, err = parser.GetTransactionsProgressive(context.Background(), address, yield)
+	require.NoError(t, err)
+	require.Len(t, yielded, 1)
+	require.Len(t, yielded[0], 3)
+}
+
+func TestParserGetTransactionsProgressiveStops(t *testing.T) {
+	_, node := newTestChain(t, 150, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+		149: {{Hash: "0x149", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithScanStrategy(Incremental))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	errStop := errors.New("stop")
+	_, err = parser.GetTransactionsProgressive(context.Background(), address, func([]*models.Transaction) error {
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+
+	_, err = parser.GetTransactionsProgressive(context.Background(), address, nil)
+	require.Error(t, err)
+}
+
+func TestParserGetTransactionsProgressiveUnlocked(t *testing.T) {
+	_, node := newTestChain(t, 150, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+		149: {{Hash: "0x149", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithScanStrategy(Incremental), WithRateLimit(math.Inf(1), 1))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	// yield may call back into the parser, Subscribe taking e.m
+	done := make(chan error)
+	go func() {
+		_, err := parser.GetTransactionsProgressive(context.Background(), address, func([]*models.Transaction) error {
+			if _, ok := parser.Subscriptions()[hot]; ok {
+				return nil
+			}
+			return parser.Subscribe(hot)
+		})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("yield deadlocked calling back into the parser")
+	}
+	require.Contains(t, parser.Subscriptions(), hot)
+
+	// the walk stops without caching anything once address is unsubscribed
+	// by yield
+	parser, err = NewEthParser(WithNodeUrl(node.URL), WithScanStrategy(Incremental), WithRateLimit(math.Inf(1), 1))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+	_, err = parser.GetTransactionsProgressive(context.Background(), address, func([]*models.Transaction) error {
+		parser.Unsubscribe(address)
+		return nil
+	})
+	require.ErrorIs(t, err, ErrNotSubscribed)
+
+	txs, _ := parser.transactionCache.GetTransactions(address)
+	require.Empty(t, txs)
+}
+
+func TestTakeWindow(t *testing.T) {
+	ranges := []BlockRange{{From: 1, To: 5}, {From: 10, To: 30}}
+
+	window, rest := takeWindow(ranges, 16)
+	require.Equal(t, BlockRange{From: 15, To: 30}, window)
+	require.Equal(t, []BlockRange{{From: 1, To: 5}, {From: 10, To: 14}}, rest)
+	// the ranges taken from are left untouched
+	require.Equal(t, BlockRange{From: 10, To: 30}, ranges[1])
+
+	window, rest = takeWindow(rest, 16)
+	require.Equal(t, BlockRange{From: 10, To: 14}, window)
+	require.Equal(t, []BlockRange{{From: 1, To: 5}}, rest)
+}
+
+func TestWithScanStrategy(t *testing.T) {
+	_, err := NewEthParser(WithScanStrategy(ScanStrategy(2)))
+	require.Error(t, err)
+
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+	require.Equal(t, FullRange, parser.scanStrategy)
+}
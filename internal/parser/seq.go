@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	"ethparser/internal/models"
+)
+
+// errStopIteration stops the walk of GetTransactionsSeq once its loop breaks
+var errStopIteration = errors.New("iteration stopped")
+
+// GetTransactionsSeq is GetTransactionsSeqCtx without a ctx
+func (e *ethParser) GetTransactionsSeq(address string) (iter.Seq[*models.Transaction], func() error) {
+	return e.GetTransactionsSeqCtx(context.Background(), address)
+}
+
+// GetTransactionsSeqCtx iterates over the cached transactions of address,
+// then over those of each window of blocks as it is scanned, rather than
+// returning them once the whole walk is done. Breaking out of the loop stops
+// the walk, the windows scanned so far being kept. The returned func reports
+// the error that ended the iteration early, if any, once the loop is done.
+func (e *ethParser) GetTransactionsSeqCtx(ctx context.Context, address string) (iter.Seq[*models.Transaction], func() error) {
+	var err error
+	seq := func(yield func(*models.Transaction) bool) {
+		_, err = e.GetTransactionsProgressive(ctx, address, func(transactions []*models.Transaction) error {
+			for _, tx := range transactions {
+				if !yield(tx) {
+					return errStopIteration
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, errStopIteration) {
+			err = nil
+		}
+	}
+
+	return seq, func() error { return err }
+}
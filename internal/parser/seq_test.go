@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserGetTransactionsSeq(t *testing.T) {
+	chain, node := newTestChain(t, 149, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+		102: {{Hash: "0x102", To: address}},
+		140: {{Hash: "0x140", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0), WithRateLimit(math.Inf(1), 1))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	seq, seqErr := parser.GetTransactionsSeq(address)
+	var hashes []string
+	for tx := range seq {
+		hashes = append(hashes, tx.Hash)
+		break
+	}
+	require.NoError(t, seqErr())
+	require.Equal(t, []string{"0x101"}, hashes)
+
+	// breaking out of the loop stopped the walk after the first window,
+	// windows being aligned on the head
+	for _, blockNumber := range chain.fetchedBlocks() {
+		require.Less(t, blockNumber, 100+partialWindowSize)
+	}
+	ranges, err := parser.ProcessedRanges(address)
+	require.NoError(t, err)
+	require.Equal(t, []BlockRange{{From: 100, To: 100 + partialWindowSize - 1}}, ranges)
+
+	// the next iteration starts with the cached transactions and resumes
+	// after the window already scanned
+	fetched := len(chain.fetchedBlocks())
+	seq, seqErr = parser.GetTransactionsSeq(address)
+	hashes = nil
+	for tx := range seq {
+		hashes = append(hashes, tx.Hash)
+	}
+	require.NoError(t, seqErr())
+	require.Equal(t, []string{"0x101", "0x102", "0x140"}, hashes)
+	for _, blockNumber := range chain.fetchedBlocks()[fetched:] {
+		require.GreaterOrEqual(t, blockNumber, 100+partialWindowSize)
+	}
+}
+
+func TestParserGetTransactionsSeqCallsParser(t *testing.T) {
+	_, node := newTestChain(t, 149, map[int][]models.Transaction{
+		101: {{Hash: "0x101", From: address}},
+		140: {{Hash: "0x140", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0), WithRateLimit(math.Inf(1), 1))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	// the loop body may subscribe and unsubscribe while iterating
+	done := make(chan error)
+	go func() {
+		seq, seqErr := parser.GetTransactionsSeq(address)
+		for range seq {
+			if err := parser.Subscribe(hot); err != nil {
+				done <- err
+				return
+			}
+			parser.Unsubscribe(hot)
+		}
+		done <- seqErr()
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("loop body deadlocked calling back into the parser")
+	}
+	require.NotContains(t, parser.Subscriptions(), hot)
+}
+
+func TestParserGetTransactionsSeqError(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	seq, seqErr := parser.GetTransactionsSeq(address)
+	for range seq {
+		t.Fatal("no transaction expected")
+	}
+	require.ErrorIs(t, seqErr(), ErrNotSubscribed)
+}
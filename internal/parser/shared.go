@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// sharedCalls coalesces concurrent calls by key like a singleflight.Group.
+// A shared call runs until the last of its callers is done rather than until
+// the ctx of the call starting it is, a caller giving up not failing the
+// others, and is canceled once none of them wait for it anymore. The zero
+// value is ready to use.
+type sharedCalls struct {
+	group singleflight.Group
+
+	m     sync.Mutex
+	calls map[string]*sharedCall
+}
+
+// sharedCall is the ctx of a shared call along with the number of callers
+// waiting for it
+type sharedCall struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// do runs fn once for the concurrent calls with the same key, with a ctx
+// derived from parent that is done once every caller waiting for it is
+func (sc *sharedCalls) do(ctx, parent context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	call := sc.join(parent, key)
+	defer sc.leave(key, call)
+
+	result := sc.group.DoChan(key, func() (interface{}, error) {
+		return fn(call.ctx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-result:
+		return r.Val, r.Err
+	}
+}
+
+// join counts a caller waiting for the shared call of key, starting a new
+// one when there is none
+func (sc *sharedCalls) join(parent context.Context, key string) *sharedCall {
+	sc.m.Lock()
+	defer sc.m.Unlock()
+
+	if sc.calls == nil {
+		sc.calls = make(map[string]*sharedCall)
+	}
+
+	call, ok := sc.calls[key]
+	if !ok {
+		call = &sharedCall{}
+		call.ctx, call.cancel = context.WithCancel(parent)
+		sc.calls[key] = call
+	}
+	call.waiters++
+
+	return call
+}
+
+// leave uncounts a caller of the shared call of key, canceling the call when
+// it was the last one
+func (sc *sharedCalls) leave(key string, call *sharedCall) {
+	sc.m.Lock()
+	defer sc.m.Unlock()
+
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+
+	call.cancel()
+	delete(sc.calls, key)
+	// the next caller starts a new call rather than joining the canceled
+	// one
+	sc.group.Forget(key)
+}
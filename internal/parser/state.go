@@ -0,0 +1,262 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"ethparser/internal/models"
+)
+
+// stateVersion is the version of the format written by DumpState. LoadState
+// refuses snapshots written by a newer version it doesn't understand.
+const stateVersion = 1
+
+type parserState struct {
+	Version int `json:"version"`
+	// Subscriptions maps each observed address to its initial block number
+	Subscriptions map[string]int `json:"subscriptions"`
+	// Selectors maps addresses to the method selectors they are restricted to
+	Selectors map[string][]string `json:"selectors,omitempty"`
+	// Intervals maps addresses to their own poll interval
+	Intervals map[string]time.Duration `json:"intervals,omitempty"`
+	// Cache holds the cached transactions of each observed address
+	Cache map[string]cacheState `json:"cache"`
+	// Processed maps addresses to the block ranges scanned for them
+	Processed map[string][]BlockRange `json:"processed,omitempty"`
+	// Webhooks maps addresses to the callback URL of their webhook
+	Webhooks map[string]string `json:"webhooks,omitempty"`
+}
+
+type cacheState struct {
+	BlockNumber  int                   `json:"blockNumber"`
+	Transactions []*models.Transaction `json:"transactions"`
+}
+
+// DumpState writes a snapshot of the subscriptions and their cached
+// transactions to w, taken under the observer lock so it is coherent
+func (e *ethParser) DumpState(w io.Writer) error {
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	state := parserState{
+		Version:       stateVersion,
+		Subscriptions: make(map[string]int, len(e.addresses)),
+		Selectors:     make(map[string][]string, len(e.selectors)),
+		Intervals:     make(map[string]time.Duration),
+		Cache:         make(map[string]cacheState),
+	}
+
+	e.processedM.Lock()
+	state.Processed = make(map[string][]BlockRange, len(e.processed))
+	for address, ranges := range e.processed {
+		state.Processed[address] = ranges
+	}
+	e.processedM.Unlock()
+
+	e.webhooksM.Lock()
+	state.Webhooks = make(map[string]string, len(e.webhooks))
+	for address, callbackURL := range e.webhooks {
+		state.Webhooks[address] = callbackURL
+	}
+	e.webhooksM.Unlock()
+
+	for address, sub := range e.addresses {
+		state.Subscriptions[address] = sub.blockNumber
+		if selectors, ok := e.selectors[address]; ok {
+			state.Selectors[address] = selectors
+		}
+		if sub.interval > 0 {
+			state.Intervals[address] = sub.interval
+		}
+
+		// a snapshot is not a lookup, left out of the cache stats
+		transactions, cachedBlockNumber := e.transactionCache.Peek(address)
+		if cachedBlockNumber == 0 {
+			continue
+		}
+
+		state.Cache[address] = cacheState{
+			BlockNumber:  cachedBlockNumber,
+			Transactions: transactions,
+		}
+	}
+
+	return json.NewEncoder(w).Encode(state)
+}
+
+// LoadState replaces the subscriptions, cached transactions and webhooks
+// with a snapshot previously written by DumpState. The last errors and
+// warm-ups of addresses missing from the snapshot are dropped. The addresses
+// are lowercased, and the current state is left untouched if the snapshot
+// cannot be decoded or holds an invalid address or callback URL.
+func (e *ethParser) LoadState(r io.Reader) error {
+	var state parserState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return err
+	}
+
+	if state.Version < 1 || state.Version > stateVersion {
+		return fmt.Errorf("unsupported state version: %d", state.Version)
+	}
+
+	if err := state.normalize(); err != nil {
+		return err
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	for address := range e.addresses {
+		e.transactionCache.ClearAddress(address)
+	}
+
+	e.addresses = make(map[string]subscription, len(state.Subscriptions))
+	for address, blockNumber := range state.Subscriptions {
+		e.addresses[address] = subscription{blockNumber: blockNumber, interval: state.Intervals[address]}
+	}
+	e.saveSubscriptions()
+
+	e.selectors = make(map[string][]string, len(state.Selectors))
+	for address, selectors := range state.Selectors {
+		if _, ok := e.addresses[address]; ok {
+			e.selectors[address] = selectors
+		}
+	}
+
+	for address, cached := range state.Cache {
+		if _, ok := e.addresses[address]; !ok {
+			continue
+		}
+		e.transactionCache.AddTransactions(address, cached.Transactions, cached.BlockNumber)
+	}
+
+	e.processedM.Lock()
+	e.processed = make(map[string][]BlockRange, len(state.Processed))
+	for address, ranges := range state.Processed {
+		if _, ok := e.addresses[address]; ok {
+			e.processed[address] = ranges
+		}
+	}
+	e.processedM.Unlock()
+
+	// the errors and warm-ups of addresses the snapshot doesn't subscribe
+	// would otherwise outlive their subscription
+	e.lastErrorsM.Lock()
+	for address := range e.lastErrors {
+		if _, ok := e.addresses[address]; !ok {
+			delete(e.lastErrors, address)
+		}
+	}
+	e.lastErrorsM.Unlock()
+
+	e.warmupsM.Lock()
+	for address := range e.warmups {
+		if _, ok := e.addresses[address]; !ok {
+			delete(e.warmups, address)
+		}
+	}
+	e.warmupsM.Unlock()
+
+	e.webhooksM.Lock()
+	e.webhooks = make(map[string]string, len(state.Webhooks))
+	for address, callbackURL := range state.Webhooks {
+		if _, ok := e.addresses[address]; ok {
+			e.webhooks[address] = callbackURL
+		}
+	}
+	e.webhooksM.Unlock()
+
+	return nil
+}
+
+// normalize lowercases the addresses of s, as Subscribe does, failing on
+// the invalid ones and on callback URLs RegisterWebhook would refuse
+func (s *parserState) normalize() error {
+	var err error
+	if s.Subscriptions, err = normalizeKeys(s.Subscriptions); err != nil {
+		return err
+	}
+	if s.Selectors, err = normalizeKeys(s.Selectors); err != nil {
+		return err
+	}
+	if s.Intervals, err = normalizeKeys(s.Intervals); err != nil {
+		return err
+	}
+	if s.Cache, err = normalizeKeys(s.Cache); err != nil {
+		return err
+	}
+	if s.Processed, err = normalizeKeys(s.Processed); err != nil {
+		return err
+	}
+	if s.Webhooks, err = normalizeKeys(s.Webhooks); err != nil {
+		return err
+	}
+	for _, callbackURL := range s.Webhooks {
+		if err := validateCallbackURL(callbackURL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// normalizeKeys normalizes the subscriptions m is keyed by
+func normalizeKeys[V any](m map[string]V) (map[string]V, error) {
+	normalized := make(map[string]V, len(m))
+	for key, v := range m {
+		key, err := normalizeSubscription(key)
+		if err != nil {
+			return nil, err
+		}
+		normalized[key] = v
+	}
+
+	return normalized, nil
+}
+
+// ExportAll gets the cached transactions of every subscribed address, as
+// they were last fetched, without asking the node for new ones. Addresses
+// with nothing cached are left out.
+func (e *ethParser) ExportAll() map[string][]*models.Transaction {
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	export := make(map[string][]*models.Transaction, len(e.addresses))
+	for address := range e.addresses {
+		transactions := e.exportAddress(address)
+		if len(transactions) == 0 {
+			continue
+		}
+
+		export[address] = transactions
+	}
+
+	return export
+}
+
+// ExportAddress is ExportAll for a single address, nil when the address is
+// not subscribed, so that an export can be written an address at a time
+func (e *ethParser) ExportAddress(address string) []*models.Transaction {
+	address, err := normalizeSubscription(address)
+	if err != nil {
+		return nil
+	}
+
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	if _, ok := e.addresses[address]; !ok {
+		return nil
+	}
+
+	return e.exportAddress(address)
+}
+
+// exportAddress gets the cached transactions of address, e.m being held.
+// An export is not a lookup, left out of the cache stats and eviction order.
+func (e *ethParser) exportAddress(address string) []*models.Transaction {
+	transactions, _ := e.transactionCache.Peek(address)
+	return transactions
+}
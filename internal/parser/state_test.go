@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserStateRoundTrip(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	tx := &models.Transaction{Hash: "0x01", From: address, To: "0x02", BlockNumber: "0x10"}
+	parser.addresses[address] = subscription{blockNumber: 0x10}
+	parser.addresses["0x02"] = subscription{blockNumber: 0x11, interval: time.Minute}
+	parser.selectors["0x02"] = []string{"0x095ea7b3"}
+	parser.transactionCache.AddTransactions(address, []*models.Transaction{tx}, 0x12)
+	parser.processed[address] = []BlockRange{{From: 0x10, To: 0x12}}
+	parser.webhooks[address] = "https://example.com/hook"
+
+	var buf bytes.Buffer
+	require.NoError(t, parser.DumpState(&buf))
+
+	restored, err := NewEthParser()
+	require.NoError(t, err)
+	restored.addresses["0x03"] = subscription{blockNumber: 0x01}
+	restored.webhooks["0x02"] = "https://example.com/stale"
+	require.NoError(t, restored.LoadState(&buf))
+
+	require.Equal(t, parser.addresses, restored.addresses)
+	require.Equal(t, parser.selectors, restored.selectors)
+	require.Equal(t, parser.processed, restored.processed)
+	require.Equal(t, parser.webhooks, restored.webhooks)
+
+	txs, blockNumber := restored.transactionCache.GetTransactions(address)
+	require.Equal(t, 0x12, blockNumber)
+	require.Equal(t, []*models.Transaction{tx}, txs)
+}
+
+func TestParserLoadStateDropsStaleAddresses(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 0x10}
+	parser.webhooks[address] = "http://localhost/hook"
+
+	var buf bytes.Buffer
+	require.NoError(t, parser.DumpState(&buf))
+
+	restored, err := NewEthParser()
+	require.NoError(t, err)
+	for _, addr := range []string{address, hot} {
+		restored.addresses[addr] = subscription{blockNumber: 0x01}
+		restored.lastErrors[addr] = errors.New("scan failed")
+		restored.warmups[addr] = &warmup{total: 1, done: true}
+		restored.webhooks[addr] = "http://localhost/hook"
+	}
+	require.NoError(t, restored.LoadState(&buf))
+
+	require.Equal(t, map[string]error{address: errors.New("scan failed")}, restored.lastErrors)
+	require.Equal(t, map[string]*warmup{address: {total: 1, done: true}}, restored.warmups)
+	require.Equal(t, map[string]string{address: "http://localhost/hook"}, restored.webhooks)
+}
+
+func TestParserLoadStateNormalizesAddresses(t *testing.T) {
+	const checksummed = "0xCB81fA1FC2A94461f49D9106dcB7772A29288EFE"
+
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	state := `{"version":1,"subscriptions":{"` + checksummed + `":16},"cache":{"` + checksummed + `":{"blockNumber":18,"transactions":[{"hash":"0x01"}]}}}`
+	require.NoError(t, parser.LoadState(strings.NewReader(state)))
+	require.Equal(t, map[string]subscription{strings.ToLower(checksummed): {blockNumber: 16}}, parser.addresses)
+
+	_, blockNumber := parser.transactionCache.GetTransactions(strings.ToLower(checksummed))
+	require.Equal(t, 18, blockNumber)
+
+	err = parser.LoadState(strings.NewReader(`{"version":1,"subscriptions":{"vitalik.eth":16}}`))
+	require.ErrorContains(t, err, "invalid address")
+	require.Contains(t, parser.addresses, strings.ToLower(checksummed))
+
+	state = `{"version":1,"subscriptions":{"` + checksummed + `":16},"webhooks":{"` + checksummed + `":"https://example.com/hook"}}`
+	require.NoError(t, parser.LoadState(strings.NewReader(state)))
+	require.Equal(t, map[string]string{strings.ToLower(checksummed): "https://example.com/hook"}, parser.webhooks)
+
+	state = `{"version":1,"subscriptions":{"` + checksummed + `":16},"webhooks":{"` + checksummed + `":"ftp://example.com/hook"}}`
+	require.ErrorContains(t, parser.LoadState(strings.NewReader(state)), "invalid callback URL")
+	require.Equal(t, map[string]string{strings.ToLower(checksummed): "https://example.com/hook"}, parser.webhooks)
+}
+
+func TestParserDumpStateLeavesCacheStats(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 0x10}
+	parser.transactionCache.AddTransactions(address, []*models.Transaction{{Hash: "0x01"}}, 0x12)
+
+	require.NoError(t, parser.DumpState(&bytes.Buffer{}))
+	require.Zero(t, parser.CacheStats().Hits)
+	require.Zero(t, parser.CacheStats().Misses)
+}
+
+func TestParserLoadStateUnsupportedVersion(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 1}
+
+	err = parser.LoadState(strings.NewReader(`{"version":99,"subscriptions":{}}`))
+	require.Error(t, err)
+	require.Contains(t, parser.addresses, address)
+}
+
+func TestParserExportAll(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	parser.addresses[address] = subscription{blockNumber: 0x10}
+	parser.addresses[hot] = subscription{blockNumber: 0x10}
+	parser.addresses[cold] = subscription{blockNumber: 0x10}
+	parser.transactionCache.AddTransactions(address, []*models.Transaction{
+		{Hash: "0x02", From: address, BlockNumber: "0x11"},
+		{Hash: "0x01", To: address, BlockNumber: "0x10"},
+	}, 0x12)
+	parser.transactionCache.AddTransactions(hot, []*models.Transaction{{Hash: "0x03", To: hot, BlockNumber: "0x12"}}, 0x12)
+	// other was unsubscribed, leaving its transactions behind
+	parser.transactionCache.AddTransactions(other, []*models.Transaction{{Hash: "0x04", To: other}}, 0x12)
+
+	export := parser.ExportAll()
+	require.Len(t, export, 2)
+	require.Equal(t, "0x01", export[address][0].Hash)
+	require.Equal(t, "0x02", export[address][1].Hash)
+	require.Equal(t, "0x03", export[hot][0].Hash)
+
+	transactions := parser.ExportAddress(address)
+	require.Len(t, transactions, 2)
+	require.Equal(t, "0x01", transactions[0].Hash)
+	// the transactions of unsubscribed addresses are not exported
+	require.Nil(t, parser.ExportAddress(other))
+
+	// exporting is not counted as cache lookups
+	require.Zero(t, parser.CacheStats().Hits)
+	require.Zero(t, parser.CacheStats().Misses)
+}
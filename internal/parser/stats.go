@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+
+	"ethparser/internal/cache"
+)
+
+// defaultMethodWeight is the compute units of a call whose method has no weight
+const defaultMethodWeight = 1
+
+// Stats are the JSON-RPC calls sent to the node and the errors dropped
+type Stats struct {
+	// Calls are the number of calls by method, batched calls counting each
+	Calls map[string]int64 `json:"calls"`
+	// ComputeUnits estimates the provider usage, weighting each call by its
+	// method
+	ComputeUnits int64 `json:"computeUnits"`
+	// DroppedErrors is the number of errors dropped as Errors was not read
+	DroppedErrors int64 `json:"droppedErrors"`
+}
+
+// rpcStats counts the JSON-RPC calls sent to the node
+type rpcStats struct {
+	m     sync.Mutex
+	calls map[string]int64
+	// weights are the compute units charged per call by method
+	weights map[string]int64
+}
+
+func newRPCStats() *rpcStats {
+	return &rpcStats{
+		calls:   make(map[string]int64),
+		weights: make(map[string]int64),
+	}
+}
+
+// WithMethodWeights sets the compute units a provider charges per call of
+// each method, a call of any other method weighing 1
+func WithMethodWeights(weights map[string]int) EthParserOpt {
+	return func(p *ethParser) error {
+		for method, weight := range weights {
+			if weight < 0 {
+				return fmt.Errorf("method weight cannot be negative: %s", method)
+			}
+			p.rpcStats.weights[method] = int64(weight)
+		}
+		return nil
+	}
+}
+
+func (e *ethParser) Stats() Stats {
+	stats := e.rpcStats.snapshot()
+	stats.DroppedErrors = e.droppedErrors.Load()
+
+	return stats
+}
+
+func (e *ethParser) CacheStats() cache.CacheStats {
+	return e.transactionCache.Stats()
+}
+
+// record counts the calls of rpcRequests
+func (rs *rpcStats) record(rpcRequests ...JsonRPCRequest) {
+	rs.m.Lock()
+	defer rs.m.Unlock()
+
+	for _, rpcRequest := range rpcRequests {
+		rs.calls[rpcRequest.Method]++
+	}
+}
+
+// snapshot copies the counts and estimates their compute units
+func (rs *rpcStats) snapshot() Stats {
+	rs.m.Lock()
+	defer rs.m.Unlock()
+
+	stats := Stats{Calls: maps.Clone(rs.calls)}
+	for method, calls := range rs.calls {
+		weight, ok := rs.weights[method]
+		if !ok {
+			weight = defaultMethodWeight
+		}
+		stats.ComputeUnits += calls * weight
+	}
+
+	return stats
+}
@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserStats(t *testing.T) {
+	_, node := newTestChain(t, 110, map[int][]models.Transaction{
+		105: {{Hash: "0x105", To: address}},
+	})
+
+	parser, err := NewEthParser(
+		WithNodeUrl(node.URL),
+		WithMethodWeights(map[string]int{"eth_blockNumber": 10, "eth_getBlockByHash": 0}),
+	)
+	require.NoError(t, err)
+	require.Empty(t, parser.Stats().Calls)
+
+	require.NoError(t, parser.Subscribe(address))
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	// the block number fetched by Subscribe is reused, the head is fetched
+	// by number and its ancestors by hash
+	require.Equal(t, map[string]int64{
+		"eth_blockNumber":      1,
+		"eth_getBlockByNumber": 1,
+		"eth_getBlockByHash":   10,
+	}, parser.Stats().Calls)
+
+	parser.batchSize = 4
+	parser.addresses[address] = subscription{blockNumber: 90}
+	parser.processed[address] = []BlockRange{{From: 100, To: 110}}
+
+	_, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+
+	// the head is fetched again to check for reorgs, then the 10 missing
+	// blocks in 3 batches
+	stats := parser.Stats()
+	require.Equal(t, map[string]int64{
+		"eth_blockNumber":      1,
+		"eth_getBlockByNumber": 12,
+		"eth_getBlockByHash":   10,
+	}, stats.Calls)
+	require.Equal(t, int64(1*10+12), stats.ComputeUnits)
+}
+
+func TestWithMethodWeightsInvalid(t *testing.T) {
+	_, err := NewEthParser(WithMethodWeights(map[string]int{"eth_call": -1}))
+	require.Error(t, err)
+}
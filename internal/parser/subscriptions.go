@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SubscriptionStore persists the subscribed addresses, mapped by the block
+// number they were subscribed at, across restarts
+type SubscriptionStore interface {
+	// Save replaces the stored subscriptions with addresses
+	Save(addresses map[string]int) error
+	// Load gets the stored subscriptions
+	Load() (map[string]int, error)
+}
+
+// fileSubscriptionStore stores subscriptions in a JSON file
+type fileSubscriptionStore struct {
+	path string
+}
+
+// NewFileSubscriptionStore stores subscriptions as a JSON object in the file
+// at path
+func NewFileSubscriptionStore(path string) SubscriptionStore {
+	return &fileSubscriptionStore{path: path}
+}
+
+// Save writes addresses to a temporary file renamed over the store, so a
+// crash never leaves it half written
+func (fs *fileSubscriptionStore) Save(addresses map[string]int) error {
+	data, err := json.Marshal(addresses)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(fs.path), filepath.Base(fs.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), fs.path)
+}
+
+func (fs *fileSubscriptionStore) Load() (map[string]int, error) {
+	data, err := os.ReadFile(fs.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses map[string]int
+	if err := json.Unmarshal(data, &addresses); err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+// WithSubscriptionStore restores the subscriptions saved in store on
+// creation and saves them whenever they change
+func WithSubscriptionStore(store SubscriptionStore) EthParserOpt {
+	return func(p *ethParser) error {
+		if store == nil {
+			return errors.New("subscription store cannot be nil")
+		}
+		p.subscriptionStore = store
+		return nil
+	}
+}
+
+// restoreSubscriptions loads the saved subscriptions, starting without any
+// when they cannot be read
+func (e *ethParser) restoreSubscriptions() {
+	if e.subscriptionStore == nil {
+		return
+	}
+
+	addresses, err := e.subscriptionStore.Load()
+	if err != nil {
+		e.logger.Warn("failed to load subscriptions, starting without any", "error", err)
+		return
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	for saved, blockNumber := range addresses {
+		address, err := normalizeSubscription(saved)
+		if err != nil {
+			e.logger.Warn("dropped saved subscription", "address", saved, "error", err)
+			continue
+		}
+		e.addresses[address] = subscription{blockNumber: blockNumber}
+	}
+}
+
+// saveSubscriptions saves the current subscriptions.
+// e.m must be held by the caller.
+func (e *ethParser) saveSubscriptions() {
+	if e.subscriptionStore == nil {
+		return
+	}
+
+	if err := e.subscriptionStore.Save(e.subscriptionBlocks()); err != nil {
+		e.logger.Error("failed to save subscriptions", "error", err)
+		e.reportError(err)
+	}
+}
+
+// subscription is what the observer keeps for a subscribed address
+type subscription struct {
+	// blockNumber is the latest block number when the address was added
+	blockNumber int
+	// interval is how often the poller refreshes the address, 0 for the
+	// poll interval of the parser
+	interval time.Duration
+}
+
+// subscriptionBlocks maps the subscribed addresses to the block number they
+// were subscribed at.
+// e.m must be held by the caller.
+func (e *ethParser) subscriptionBlocks() map[string]int {
+	blocks := make(map[string]int, len(e.addresses))
+	for address, sub := range e.addresses {
+		blocks[address] = sub.blockNumber
+	}
+
+	return blocks
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserSubscriptionStore(t *testing.T) {
+	node, _ := newFlakyNode(t, 0, 0)
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithSubscriptionStore(NewFileSubscriptionStore(path)))
+	require.NoError(t, err)
+	require.Empty(t, parser.SubscribedAddresses())
+
+	require.NoError(t, parser.Subscribe(address))
+	require.NoError(t, parser.Subscribe(hot))
+	require.True(t, parser.Unsubscribe(hot))
+
+	// a restarted parser observes the same addresses from the same block
+	restarted, err := NewEthParser(WithNodeUrl(node.URL), WithSubscriptionStore(NewFileSubscriptionStore(path)))
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{address: 0x13ecaeb}, restarted.Subscriptions())
+
+	saved, err := NewFileSubscriptionStore(path).Load()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{address: 0x13ecaeb}, saved)
+}
+
+func TestParserSubscriptionStoreUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.json")
+	require.NoError(t, os.WriteFile(corrupt, []byte("{"), 0o600))
+
+	for _, path := range []string{filepath.Join(dir, "missing.json"), corrupt} {
+		logger, records := newTestLogger(t)
+
+		parser, err := NewEthParser(WithLogger(logger), WithSubscriptionStore(NewFileSubscriptionStore(path)))
+		require.NoError(t, err)
+		require.Empty(t, parser.SubscribedAddresses())
+
+		require.Len(t, records(), 1)
+		require.Equal(t, "WARN", records()[0]["level"])
+	}
+
+	_, err := NewEthParser(WithSubscriptionStore(nil))
+	require.Error(t, err)
+}
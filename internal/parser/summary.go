@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// TransactionsHash gets a digest of the transaction hashes of an address,
+// which changes whenever a transaction is added or removed. Clients compare
+// it to a stored one to only fetch the transactions again on change.
+func (e *ethParser) TransactionsHash(address string) (string, error) {
+	transactions, err := e.getAllTransactions(context.Background(), address)
+	if err != nil {
+		return "", err
+	}
+
+	hashes := make([]string, 0, len(transactions))
+	for _, tx := range transactions {
+		hashes = append(hashes, strings.ToLower(tx.Hash))
+	}
+	sort.Strings(hashes)
+
+	digest := sha256.New()
+	for _, hash := range hashes {
+		digest.Write([]byte(hash))
+		digest.Write([]byte{'\n'})
+	}
+
+	return "0x" + hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+func (e *ethParser) GetTransactionSummary(address string) (int, *big.Int, *big.Int, error) {
+	return e.GetTransactionSummaryCtx(context.Background(), address)
+}
+
+// GetTransactionSummaryCtx counts the transactions GetTransactions gets for
+// address and sums the wei they send to it, totalIn, and from it, totalOut.
+// A transaction from address to itself adds to both totals.
+func (e *ethParser) GetTransactionSummaryCtx(ctx context.Context, address string) (count int, totalIn, totalOut *big.Int, err error) {
+	transactions, err := e.getAllTransactions(ctx, address)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	matchAddress := addressMatch(strings.ToLower(address))
+
+	totalIn, totalOut = new(big.Int), new(big.Int)
+	for _, tx := range transactions {
+		value, err := tx.ValueWei()
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("transaction %s: %w", tx.Hash, err)
+		}
+
+		if matchAddress(tx.To) {
+			totalIn.Add(totalIn, value)
+		}
+		if matchAddress(tx.From) {
+			totalOut.Add(totalOut, value)
+		}
+	}
+
+	return len(transactions), totalIn, totalOut, nil
+}
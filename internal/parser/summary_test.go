@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserTransactionsHash(t *testing.T) {
+	chain, node := newTestChain(t, 101, map[int][]models.Transaction{
+		101: {{Hash: "0x101", To: address}},
+		103: {{Hash: "0x103", From: address}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithBlockNumberTTL(0))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	hash, err := parser.TransactionsHash(address)
+	require.NoError(t, err)
+	require.Len(t, hash, 66)
+
+	// the hash is stable while no transaction is added
+	chain.head.Store(102)
+	again, err := parser.TransactionsHash(address)
+	require.NoError(t, err)
+	require.Equal(t, hash, again)
+
+	chain.head.Store(103)
+	changed, err := parser.TransactionsHash(address)
+	require.NoError(t, err)
+	require.NotEqual(t, hash, changed)
+}
+
+func TestParserTransactionsHashNotSubscribed(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	_, err = parser.TransactionsHash(address)
+	require.Error(t, err)
+}
+
+func TestParserGetTransactionSummary(t *testing.T) {
+	_, node := newTestChain(t, 103, map[int][]models.Transaction{
+		// 2^70 wei each, summing above an int64
+		101: {{Hash: "0x101a", From: other, To: address, Value: "0x400000000000000000"}},
+		102: {
+			{Hash: "0x102a", From: hot, To: address, Value: "0x400000000000000000"},
+			{Hash: "0x102b", From: address, To: other, Value: "0x1"},
+		},
+		// to itself, both in and out
+		103: {{Hash: "0x103a", From: address, To: address, Value: "0x2"}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	count, totalIn, totalOut, err := parser.GetTransactionSummary(address)
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+	require.Equal(t, "2361183241434822606850", totalIn.String())
+	require.Equal(t, "3", totalOut.String())
+}
+
+func TestParserGetTransactionSummaryInvalidValue(t *testing.T) {
+	_, node := newTestChain(t, 101, map[int][]models.Transaction{
+		101: {{Hash: "0x101a", From: other, To: address, Value: "0xzz"}},
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	_, _, _, err = parser.GetTransactionSummary(address)
+	require.ErrorContains(t, err, "0x101a")
+
+	_, _, _, err = parser.GetTransactionSummary(other)
+	require.ErrorIs(t, err, ErrNotSubscribed)
+}
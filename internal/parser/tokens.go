@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"context"
+
+	"ethparser/internal/models"
+)
+
+type JsonRPCResponseLogs struct {
+	Result []models.Log `json:"result"`
+}
+
+// GetTokenTransfers lists the ERC-20 transfers of token sent or received by
+// a subscribed address since it was subscribed
+func (e *ethParser) GetTokenTransfers(address string, token string) ([]*models.TokenTransfer, error) {
+	address, err := e.parseAddress(context.Background(), address)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err = e.parseAddress(context.Background(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	initialBlockNumber, err := e.getAddressInitialBlockNumber(address)
+	if err != nil {
+		return nil, err
+	}
+
+	addressTopic := models.AddressToTopic(address)
+
+	// topics are ANDed by position, so sent and received transfers take a
+	// query each
+	var allTransfers []*models.TokenTransfer
+	seen := make(map[string]bool)
+	for _, topics := range [][]interface{}{
+		{models.TransferEventTopic, addressTopic},
+		{models.TransferEventTopic, nil, addressTopic},
+	} {
+		logs, err := e.getLogs(ctx, token, initialBlockNumber, topics)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, l := range logs {
+			transfer, err := models.ParseTokenTransfer(&l)
+			if err != nil {
+				return nil, err
+			}
+
+			// self transfers match both queries
+			key := transfer.TxHash + transfer.LogIndex
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			allTransfers = append(allTransfers, transfer)
+		}
+	}
+
+	return allTransfers, nil
+}
+
+// getLogs gets the logs emitted by a contract from fromBlockNumber to the
+// latest block matching topics
+func (e *ethParser) getLogs(ctx context.Context, contract string, fromBlockNumber int, topics []interface{}) ([]models.Log, error) {
+	fromBlock, err := intToHex(fromBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcRequest := JsonRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_getLogs",
+		Params: []interface{}{map[string]interface{}{
+			"address":   contract,
+			"fromBlock": fromBlock,
+			"toBlock":   "latest",
+			"topics":    topics,
+		}},
+	}
+
+	rpcResponse, err := do[JsonRPCResponseLogs](ctx, e, rpcRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return rpcResponse.Result, nil
+}
@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+const usdc = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+
+func TestParserGetTokenTransfers(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/eth_getLogs.json")
+	require.NoError(t, err)
+
+	var logs []models.Log
+	require.NoError(t, json.Unmarshal(fixture, &logs))
+
+	var filters []map[string]interface{}
+	node := newTestNode(t, func(method string, params []interface{}) interface{} {
+		require.Equal(t, "eth_getLogs", method)
+		filters = append(filters, params[0].(map[string]interface{}))
+
+		// the sent query matches both logs, the received one the self transfer
+		if len(filters) == 1 {
+			return logs
+		}
+		return logs[1:]
+	})
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+
+	_, err = parser.GetTokenTransfers(address, usdc)
+	require.Error(t, err)
+
+	parser.addresses[address] = subscription{blockNumber: 0x13ecaeb}
+
+	transfers, err := parser.GetTokenTransfers(address, usdc)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+
+	require.Equal(t, usdc, transfers[0].Token)
+	require.Equal(t, address, transfers[0].From)
+	require.Equal(t, "0x28c6c06298d514db089934071355e5743bf21d60", transfers[0].To)
+	require.Equal(t, "1000000", transfers[0].Value.String())
+	require.Equal(t, "0x13ecaf0", transfers[0].BlockNumber)
+	require.Equal(t, address, transfers[1].To)
+
+	require.Len(t, filters, 2)
+	for _, filter := range filters {
+		require.Equal(t, usdc, filter["address"])
+		require.Equal(t, "0x13ecaeb", filter["fromBlock"])
+	}
+	require.Equal(t, []interface{}{models.TransferEventTopic, models.AddressToTopic(address)}, filters[0]["topics"])
+	require.Equal(t, []interface{}{models.TransferEventTopic, nil, models.AddressToTopic(address)}, filters[1]["topics"])
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoWarmup is returned for addresses no warm-up was started for
+var ErrNoWarmup = errors.New("no warm-up started")
+
+// WarmupProgress is how far the warm-up of an address went
+type WarmupProgress struct {
+	// Scanned is the number of blocks scanned so far
+	Scanned int
+	// Total is the number of blocks the warm-up scans, 0 until it is known
+	Total int
+	// Done is true once the warm-up returned, Err being why it failed if it
+	// did
+	Done bool
+	Err  error
+}
+
+// warmup tracks the blocks a warm-up scanned
+type warmup struct {
+	total   int
+	scanned []BlockRange
+	done    bool
+	err     error
+}
+
+// SubscribeAndWarm adds address to the observer and scans its history in
+// the background, so that its transactions are cached by the time they are
+// asked for. It returns once subscribed; WarmupStatus tells how far the scan
+// went. An address already subscribed is warmed without being subscribed
+// again, and a warm-up still running for it is not started again.
+func (e *ethParser) SubscribeAndWarm(address string) error {
+	address, err := e.parseAddress(context.Background(), address)
+	if err != nil {
+		return err
+	}
+
+	e.m.RLock()
+	_, subscribed := e.addresses[address]
+	e.m.RUnlock()
+	if !subscribed {
+		if err := e.subscribeCoalesced(context.Background(), address, nil, 0); err != nil {
+			return err
+		}
+	}
+
+	e.warmupsM.Lock()
+	defer e.warmupsM.Unlock()
+
+	if w, ok := e.warmups[address]; ok && !w.done {
+		return nil
+	}
+
+	w := &warmup{}
+	e.warmups[address] = w
+	e.runBackground(context.Background(), func(ctx context.Context) {
+		err := e.warm(ctx, address, w)
+
+		e.warmupsM.Lock()
+		defer e.warmupsM.Unlock()
+
+		w.done = true
+		w.err = err
+	})
+
+	return nil
+}
+
+// warm scans the blocks of address not scanned yet, in windows recorded by
+// recordWarmupScan as they are done
+func (e *ethParser) warm(ctx context.Context, address string, w *warmup) error {
+	total, _, err := e.EstimateScanCtx(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	e.warmupsM.Lock()
+	w.total = total
+	e.warmupsM.Unlock()
+
+	// a GetTransactions running at the same time shares the walk instead of
+	// scanning the same blocks again
+	_, err = e.getTransactionsCoalesced(ctx, address)
+	return err
+}
+
+// WarmupStatus gets how far the last warm-up started for address by
+// SubscribeAndWarm went
+func (e *ethParser) WarmupStatus(address string) (WarmupProgress, error) {
+	address, err := e.parseSubscription(context.Background(), address)
+	if err != nil {
+		return WarmupProgress{}, err
+	}
+
+	e.warmupsM.Lock()
+	defer e.warmupsM.Unlock()
+
+	w, ok := e.warmups[address]
+	if !ok {
+		return WarmupProgress{}, fmt.Errorf("%w: %s", ErrNoWarmup, address)
+	}
+
+	progress := WarmupProgress{
+		Scanned: min(rangesSize(w.scanned), w.total),
+		Total:   w.total,
+		Done:    w.done,
+		Err:     w.err,
+	}
+	if w.done && w.err == nil {
+		progress.Scanned = w.total
+	}
+
+	return progress, nil
+}
+
+// warming tells whether a warm-up is running for address, the scan then
+// walking its blocks one window at a time so the progress is recorded as
+// they are done
+func (e *ethParser) warming(address string) bool {
+	e.warmupsM.Lock()
+	defer e.warmupsM.Unlock()
+
+	w, ok := e.warmups[address]
+	return ok && !w.done
+}
+
+// recordWarmupScan adds the blocks of gap, just scanned for address, to the
+// progress of its running warm-up if any
+func (e *ethParser) recordWarmupScan(address string, gap BlockRange) {
+	e.warmupsM.Lock()
+	defer e.warmupsM.Unlock()
+
+	w, ok := e.warmups[address]
+	if !ok || w.done {
+		return
+	}
+
+	// ranges scanned by a concurrent call are only counted once
+	w.scanned = addRange(w.scanned, gap)
+}
@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+func TestParserSubscribeAndWarm(t *testing.T) {
+	chain, node := newTestChain(t, 150, map[int][]models.Transaction{
+		110: {{Hash: "0x110", To: address}},
+		140: {{Hash: "0x140", From: address}},
+	})
+
+	transport := &gatedTransport{blocked: make(chan struct{}), release: make(chan struct{})}
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0), WithRateLimit(math.Inf(1), 1), WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+	t.Cleanup(func() { parser.Close() })
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	_, err = parser.WarmupStatus(address)
+	require.ErrorIs(t, err, ErrNoWarmup)
+
+	// SubscribeAndWarm returns while the warm-up is held
+	require.NoError(t, parser.SubscribeAndWarm(address))
+	<-transport.blocked
+
+	progress, err := parser.WarmupStatus(address)
+	require.NoError(t, err)
+	require.False(t, progress.Done)
+	require.Equal(t, 51, progress.Total)
+	require.Zero(t, progress.Scanned)
+
+	// a warm-up is already running for address
+	require.NoError(t, parser.SubscribeAndWarm(address))
+
+	close(transport.release)
+	require.Eventually(t, func() bool {
+		progress, err := parser.WarmupStatus(address)
+		return err == nil && progress.Done
+	}, 5*time.Second, 10*time.Millisecond)
+
+	progress, err = parser.WarmupStatus(address)
+	require.NoError(t, err)
+	require.NoError(t, progress.Err)
+	require.Equal(t, WarmupProgress{Scanned: 51, Total: 51, Done: true}, progress)
+
+	// the cache was populated in the background, each block fetched once
+	txs, blockNumber := parser.transactionCache.GetTransactions(address)
+	require.Equal(t, 150, blockNumber)
+	require.Len(t, txs, 2)
+	require.Len(t, chain.fetchedBlocks(), 51)
+
+	// only the tip is fetched again, to check for a reorg
+	fetched := len(chain.fetchedBlocks())
+	txs, err = parser.GetTransactions(address)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	require.Equal(t, []int{150}, chain.fetchedBlocks()[fetched:])
+}
+
+func TestParserSubscribeAndWarmCoalesced(t *testing.T) {
+	chain, node := newTestChain(t, 150, map[int][]models.Transaction{
+		110: {{Hash: "0x110", To: address}},
+	})
+
+	transport := &gatedTransport{blocked: make(chan struct{}), release: make(chan struct{})}
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithRateLimit(math.Inf(1), 1), WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+	t.Cleanup(func() { parser.Close() })
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	require.NoError(t, parser.SubscribeAndWarm(address))
+	<-transport.blocked
+
+	// a GetTransactions while the warm-up is held joins its walk
+	ctx := newJoinedContext(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		txs, err := parser.GetTransactionsCtx(ctx, address)
+		if err == nil && len(txs) != 1 {
+			err = fmt.Errorf("got %d transactions", len(txs))
+		}
+		result <- err
+	}()
+	<-ctx.joined
+
+	close(transport.release)
+	require.NoError(t, <-result)
+
+	// every block was fetched once, by the shared walk
+	require.Len(t, chain.fetchedBlocks(), 51)
+	require.Eventually(t, func() bool {
+		progress, err := parser.WarmupStatus(address)
+		return err == nil && progress.Done
+	}, 5*time.Second, 10*time.Millisecond)
+
+	progress, err := parser.WarmupStatus(address)
+	require.NoError(t, err)
+	require.Equal(t, WarmupProgress{Scanned: 51, Total: 51, Done: true}, progress)
+}
+
+func TestParserSubscribeAndWarmClose(t *testing.T) {
+	_, node := newTestChain(t, 150, nil)
+
+	transport := &gatedTransport{blocked: make(chan struct{}), release: make(chan struct{})}
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0), WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	require.NoError(t, parser.SubscribeAndWarm(address))
+	<-transport.blocked
+
+	// Close cancels the warm-up and waits for it
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(transport.release)
+	}()
+	require.NoError(t, parser.Close())
+
+	progress, err := parser.WarmupStatus(address)
+	require.NoError(t, err)
+	require.True(t, progress.Done)
+	require.Error(t, progress.Err)
+	require.Less(t, progress.Scanned, progress.Total)
+}
+
+func TestParserWarmupStatusUnsubscribed(t *testing.T) {
+	_, node := newTestChain(t, 100, nil)
+
+	parser, err := NewEthParser(WithNodeUrl(node.URL))
+	require.NoError(t, err)
+	t.Cleanup(func() { parser.Close() })
+
+	require.NoError(t, parser.SubscribeAndWarm(address))
+	require.Eventually(t, func() bool {
+		progress, err := parser.WarmupStatus(address)
+		return err == nil && progress.Done
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.True(t, parser.Unsubscribe(address))
+	_, err = parser.WarmupStatus(address)
+	require.ErrorIs(t, err, ErrNoWarmup)
+
+	_, err = parser.WarmupStatus("invalid")
+	require.Error(t, err)
+}
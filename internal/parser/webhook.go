@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"ethparser/internal/models"
+)
+
+const (
+	// webhookQueueSize is the number of deliveries waiting to be sent,
+	// deliveries being dropped when it is full
+	webhookQueueSize = 64
+	// webhookTimeout bounds an attempt to deliver to a callback URL
+	webhookTimeout = 10 * time.Second
+	// webhookMaxAttempts is the number of times a delivery is sent before
+	// it is dropped
+	webhookMaxAttempts = 3
+	// webhookRetryBase is the wait before sending a failed delivery again,
+	// doubling after each attempt
+	webhookRetryBase = time.Second
+	// WebhookSignatureHeader holds the hex encoded HMAC-SHA256 of the body of
+	// a delivery, keyed with the secret set by WithWebhookSecret
+	WebhookSignatureHeader = "X-Ethparser-Signature"
+)
+
+// WebhookPayload is the JSON body POSTed to the callback URL of an address
+// with its new transactions
+type WebhookPayload struct {
+	Address      string                `json:"address"`
+	Transactions []*models.Transaction `json:"transactions"`
+}
+
+// webhookDelivery is a payload waiting to be POSTed to callbackURL
+type webhookDelivery struct {
+	callbackURL string
+	payload     WebhookPayload
+}
+
+// WithWebhookSecret signs the webhook deliveries with secret, in the
+// WebhookSignatureHeader header as "sha256=" followed by the hex encoded
+// HMAC-SHA256 of the body
+func WithWebhookSecret(secret string) EthParserOpt {
+	return func(p *ethParser) error {
+		if secret == "" {
+			return errors.New("webhook secret cannot be empty")
+		}
+		p.webhookSecret = []byte(secret)
+		return nil
+	}
+}
+
+// RegisterWebhook POSTs the new transactions of a subscribed address found
+// while the parser is started to callbackURL, replacing the callback URL
+// registered before for it. Deliveries failing with a network error, a 429
+// or a 5xx status are retried a few times before being dropped.
+func (e *ethParser) RegisterWebhook(address, callbackURL string) error {
+	address, err := e.parseSubscription(context.Background(), address)
+	if err != nil {
+		return err
+	}
+
+	if err := validateCallbackURL(callbackURL); err != nil {
+		return err
+	}
+
+	e.m.RLock()
+	_, ok := e.addresses[address]
+	e.m.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotSubscribed, address)
+	}
+
+	e.webhooksM.Lock()
+	defer e.webhooksM.Unlock()
+
+	e.webhooks[address] = callbackURL
+	return nil
+}
+
+// validateCallbackURL checks that callbackURL is an http or https URL
+func validateCallbackURL(callbackURL string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("invalid callback URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid callback URL: %q must be an http or https URL", callbackURL)
+	}
+
+	return nil
+}
+
+// queueWebhook queues the delivery of transactions to the callback URL of
+// address, if any, dropping it when the queue is full
+func (e *ethParser) queueWebhook(address string, transactions []*models.Transaction) {
+	e.webhooksM.Lock()
+	callbackURL, ok := e.webhooks[address]
+	e.webhooksM.Unlock()
+	if !ok {
+		return
+	}
+
+	delivery := webhookDelivery{
+		callbackURL: callbackURL,
+		payload:     WebhookPayload{Address: address, Transactions: copyTransactions(transactions)},
+	}
+
+	select {
+	case e.webhookQueue <- delivery:
+	default:
+		e.logger.Error("dropped webhook delivery, queue full", "address", address, "url", callbackURL)
+	}
+}
+
+// deliverWebhooks sends the queued deliveries one at a time until ctx is
+// done
+func (e *ethParser) deliverWebhooks(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case delivery := <-e.webhookQueue:
+			if err := e.deliverWebhook(ctx, delivery); err != nil && ctx.Err() == nil {
+				e.logger.Error("dropped webhook delivery", "address", delivery.payload.Address, "url", delivery.callbackURL, "error", err)
+				e.reportError(fmt.Errorf("failed to deliver webhook for %s: %w", delivery.payload.Address, err))
+			}
+		}
+	}
+}
+
+// deliverWebhook POSTs delivery, sending it again after a backoff while it
+// fails with a retryable error
+func (e *ethParser) deliverWebhook(ctx context.Context, delivery webhookDelivery) error {
+	body, err := json.Marshal(delivery.payload)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		retryable, err := e.postWebhook(ctx, delivery.callbackURL, body)
+		if err == nil || !retryable || attempt >= e.webhookMaxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-e.clock.After(e.webhookRetryBase << (attempt - 1)):
+		}
+	}
+}
+
+// postWebhook POSTs body to callbackURL once, reporting whether it may
+// succeed when sent again if it fails
+func (e *ethParser) postWebhook(ctx context.Context, callbackURL string, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(e.webhookSecret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(e.webhookSecret, body))
+	}
+
+	resp, err := e.webhookClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return retryableStatus(resp.StatusCode), fmt.Errorf("callback answered %s", resp.Status)
+	}
+
+	return false, nil
+}
+
+// SignWebhook computes the WebhookSignatureHeader of a delivery body, for
+// receivers to compare with hmac.Equal
+func SignWebhook(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
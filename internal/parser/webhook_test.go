@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ethparser/internal/models"
+)
+
+// webhookRequest is a delivery received by a test webhook receiver
+type webhookRequest struct {
+	body      []byte
+	signature string
+}
+
+// newWebhookReceiver answers the first failures deliveries with status and
+// the next ones with 200, sending the ones answered with 200 to the returned
+// channel
+func newWebhookReceiver(t *testing.T, failures int, status int) (*httptest.Server, <-chan webhookRequest, *atomic.Int64) {
+	t.Helper()
+
+	var attempts atomic.Int64
+	received := make(chan webhookRequest, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		if attempts.Add(1) <= int64(failures) {
+			w.WriteHeader(status)
+			return
+		}
+		received <- webhookRequest{body: body, signature: r.Header.Get(WebhookSignatureHeader)}
+	}))
+	t.Cleanup(receiver.Close)
+
+	return receiver, received, &attempts
+}
+
+func TestParserWebhook(t *testing.T) {
+	_, node := newTestChain(t, 105, map[int][]models.Transaction{
+		102: {{Hash: "0x102", From: address}},
+	})
+	receiver, received, attempts := newWebhookReceiver(t, 1, http.StatusServiceUnavailable)
+
+	secret := "s3cr3t"
+	parser, err := NewEthParser(WithNodeUrl(node.URL), WithConfirmations(0), WithWebhookSecret(secret))
+	require.NoError(t, err)
+	parser.webhookRetryBase = time.Millisecond
+	parser.addresses[address] = subscription{blockNumber: 100}
+
+	require.NoError(t, parser.RegisterWebhook(address, receiver.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	parser.Start(ctx)
+
+	var delivery webhookRequest
+	select {
+	case delivery = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+	// the first attempt failed and was retried
+	require.Equal(t, int64(2), attempts.Load())
+
+	var payload WebhookPayload
+	require.NoError(t, json.Unmarshal(delivery.body, &payload))
+	require.Equal(t, address, payload.Address)
+	require.Len(t, payload.Transactions, 1)
+	require.Equal(t, "0x102", payload.Transactions[0].Hash)
+	require.True(t, hmac.Equal([]byte(SignWebhook([]byte(secret), delivery.body)), []byte(delivery.signature)))
+
+	cancel()
+	require.NoError(t, parser.Close())
+}
+
+func TestParserWebhookDropped(t *testing.T) {
+	receiver, _, attempts := newWebhookReceiver(t, 10, http.StatusBadRequest)
+
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+	parser.addresses[address] = subscription{blockNumber: 100}
+	require.NoError(t, parser.RegisterWebhook(address, receiver.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go parser.deliverWebhooks(ctx)
+
+	parser.notify(address, []*models.Transaction{{Hash: "0x01", From: address}})
+
+	select {
+	case err := <-parser.Errors():
+		require.ErrorContains(t, err, "400")
+	case <-time.After(5 * time.Second):
+		t.Fatal("dropped delivery not reported")
+	}
+	// a 400 is not retried
+	require.Equal(t, int64(1), attempts.Load())
+}
+
+func TestParserRegisterWebhookInvalid(t *testing.T) {
+	parser, err := NewEthParser()
+	require.NoError(t, err)
+
+	require.ErrorIs(t, parser.RegisterWebhook(address, "https://example.com/hook"), ErrNotSubscribed)
+
+	parser.addresses[address] = subscription{blockNumber: 100}
+	for _, callbackURL := range []string{"", "example.com/hook", "ftp://example.com/hook", "http://"} {
+		require.Error(t, parser.RegisterWebhook(address, callbackURL), callbackURL)
+	}
+
+	_, err = NewEthParser(WithWebhookSecret(""))
+	require.Error(t, err)
+}